@@ -125,6 +125,15 @@ Features
 		logging - customized logging settings
 		jwt - jwt starter
 		grpc - grpc application starter
+		cors - cross-origin resource sharing
+		ratelimit - rate limiting with pluggable stores
+		requestid - request correlation id for logs and grpc calls
+		tracing - distributed tracing with W3C traceparent propagation
+		session - server side session with pluggable stores
+		security - csrf protection and security response headers
+		compress - gzip, deflate and brotli response compression
+		static - static resources with precompressed files and single page app fallback
+		websocket - websocket hub with rooms and jwt authenticated connections
 
 	Tags
 		inject - inject generic instance into object
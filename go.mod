@@ -5,6 +5,7 @@ require (
 	github.com/Joker/jade v1.0.0
 	github.com/Shopify/goreferrer v0.0.0-20180807163728-b9777dc9f9cc
 	github.com/ajg/form v0.0.0-20160822230020-523a5da1a92f
+	github.com/andybalholm/brotli v1.0.4
 	github.com/aymerick/raymond v2.0.2+incompatible
 	github.com/davecgh/go-spew v1.1.1
 	github.com/deckarep/golang-set v1.7.1
@@ -44,12 +44,16 @@ type Application interface {
 	GetProperty(name string) (value interface{}, ok bool)
 	SetAddCommandLineProperties(enabled bool) Application
 	Run()
+	Shutdown()
 }
 
 // ApplicationContext is the alias interface of Application
 type ApplicationContext interface {
 	RegisterController(controller interface{}) error
 	Use(handlers ...context.Handler)
+	Handle(method, path string, handlers ...context.Handler)
+	RegisterShutdownHook(hook func())
+	Fail(err error)
 	GetProperty(name string) (value interface{}, ok bool)
 	GetInstance(params ...interface{}) (instance interface{})
 }
@@ -67,6 +71,8 @@ type BaseApplication struct {
 	mu                  sync.Mutex
 	// SetAddCommandLineProperties
 	addCommandLineProperties bool
+	shutdownHooks            []func()
+	failures                 chan error
 }
 
 var (
@@ -124,6 +130,7 @@ func (a *BaseApplication) Initialize() (err error) {
 	a.properties = cmap.New()
 	a.configurations = cmap.New()
 	a.instances = cmap.New()
+	a.failures = make(chan error, 1)
 	// set add command line properties to true as default
 	a.SetAddCommandLineProperties(true)
 	return nil
@@ -216,6 +223,43 @@ func (a *BaseApplication) RegisterController(controller interface{}) error {
 func (a *BaseApplication) Use(handlers ...context.Handler) {
 }
 
+// Handle register the route handlers
+func (a *BaseApplication) Handle(method, path string, handlers ...context.Handler) {
+}
+
+// RegisterShutdownHook register the hook that is called when the application shuts down
+func (a *BaseApplication) RegisterShutdownHook(hook func()) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.shutdownHooks = append(a.shutdownHooks, hook)
+}
+
+// Fail stops the application run with err, e.g. the error of a server that failed to listen,
+// only the first error is kept
+func (a *BaseApplication) Fail(err error) {
+	select {
+	case a.failures <- err:
+	default:
+	}
+}
+
+// Failures returns the channel that receives the error passed to Fail
+func (a *BaseApplication) Failures() <-chan error {
+	return a.failures
+}
+
+// Shutdown call the shutdown hooks in the reverse order of registration, each hook is called only once
+func (a *BaseApplication) Shutdown() {
+	a.mu.Lock()
+	hooks := a.shutdownHooks
+	a.shutdownHooks = nil
+	a.mu.Unlock()
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i]()
+	}
+}
+
 // SetAddCommandLineProperties set add command line properties to be enabled or disabled
 func (a *BaseApplication) SetAddCommandLineProperties(enabled bool) Application {
 	a.addCommandLineProperties = enabled
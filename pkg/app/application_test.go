@@ -15,6 +15,7 @@
 package app_test
 
 import (
+	"errors"
 	"github.com/stretchr/testify/assert"
 	"hidevops.io/hiboot/pkg/app"
 	"hidevops.io/hiboot/pkg/log"
@@ -197,6 +198,26 @@ func TestBaseApplication(t *testing.T) {
 		assert.Equal(t, []interface{}{"baz", "buz"}, prop)
 	})
 
+	t.Run("should call shutdown hooks in reverse order only once", func(t *testing.T) {
+		var calls []string
+		ba.RegisterShutdownHook(func() { calls = append(calls, "foo") })
+		ba.RegisterShutdownHook(func() { calls = append(calls, "bar") })
+		ba.Shutdown()
+		ba.Shutdown()
+		assert.Equal(t, []string{"bar", "foo"}, calls)
+	})
+
+	t.Run("should keep the first failure", func(t *testing.T) {
+		ba.Fail(errors.New("foo"))
+		ba.Fail(errors.New("bar"))
+		assert.Equal(t, errors.New("foo"), <-ba.Failures())
+		select {
+		case err := <-ba.Failures():
+			t.Errorf("unexpected failure: %v", err)
+		default:
+		}
+	})
+
 	ba.PrintStartupMessages()
 
 	ba.Use()
@@ -110,4 +110,5 @@ func (a *application) Run() {
 	if a.root != nil {
 		a.root.Exec()
 	}
+	a.Shutdown()
 }
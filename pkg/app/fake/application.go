@@ -31,6 +31,21 @@ func (a *ApplicationContext) Use(handlers ...context.Handler) {
 
 }
 
+// Handle register the route handlers
+func (a *ApplicationContext) Handle(method, path string, handlers ...context.Handler) {
+
+}
+
+// RegisterShutdownHook register the hook that is called when the application shuts down
+func (a *ApplicationContext) RegisterShutdownHook(hook func()) {
+
+}
+
+// Fail stops the application run with err
+func (a *ApplicationContext) Fail(err error) {
+
+}
+
 // GetProperty get application property by name
 func (a *ApplicationContext) GetProperty(name string) (value interface{}, ok bool) {
 	return
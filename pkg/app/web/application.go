@@ -15,17 +15,22 @@
 package web
 
 import (
+	stdctx "context"
 	"errors"
-	"fmt"
 	"github.com/kataras/iris"
+	"github.com/kataras/iris/core/host"
 	"hidevops.io/hiboot/pkg/app"
 	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/app/web/server"
 	"hidevops.io/hiboot/pkg/at"
 	"hidevops.io/hiboot/pkg/log"
+	"hidevops.io/hiboot/pkg/system"
 	"hidevops.io/hiboot/pkg/utils/io"
 	"hidevops.io/hiboot/pkg/utils/str"
+	"net"
 	"os"
 	"regexp"
+	"strings"
 	"time"
 )
 
@@ -85,17 +90,65 @@ func (a *application) Initialize() error {
 
 // Run run web application
 func (a *application) Run() {
-	serverPort := ":8080"
 	err := a.build()
 	conf := a.SystemConfig()
-	if conf != nil && conf.Server.Port != "" {
-		serverPort = fmt.Sprintf(":%v", conf.Server.Port)
+	if err == nil {
+		// do not serve if any component failed to start, e.g. the grpc server failed to listen
+		select {
+		case err = <-a.Failures():
+			log.Errorf("Failed to start %v: %v", conf.App.Name, err)
+		default:
+		}
 	}
 	if err == nil {
-		log.Infof("Hiboot started on port(s) http://localhost%v", serverPort)
-		timeDiff := time.Since(a.startUpTime)
-		log.Infof("Started %v in %f seconds", conf.App.Name, timeDiff.Seconds())
-		err = a.webApp.Run(iris.Addr(fmt.Sprintf(serverPort)), iris.WithConfiguration(defaultConfiguration()))
+		serverProperties := &system.Server{Port: "8080"}
+		if conf != nil && conf.Server.Port != "" {
+			serverProperties = &conf.Server
+		}
+		err = a.webApp.Run(iris.Raw(func() error {
+			return a.serve(conf.App.Name, server.New(serverProperties, a.webApp.Router))
+		}), iris.WithConfiguration(defaultConfiguration()))
+		log.Infof("Shutting down %v", conf.App.Name)
+	}
+	a.Shutdown()
+}
+
+// serve runs the servers until one of them returns or the application fails,
+// the servers are hosted by iris so that they are shut down on interrupt
+func (a *application) serve(name string, servers []*server.Server) error {
+	var urls []string
+	listeners := make([]net.Listener, 0, len(servers))
+	for _, s := range servers {
+		l, err := s.Listen()
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return err
+		}
+		listeners = append(listeners, l)
+		urls = append(urls, s.URL())
+	}
+
+	log.Infof("Hiboot started on %v", strings.Join(urls, ", "))
+	timeDiff := time.Since(a.startUpTime)
+	log.Infof("Started %v in %f seconds", name, timeDiff.Seconds())
+
+	errs := make(chan error, len(servers))
+	for i, s := range servers {
+		go func(su *host.Supervisor, l net.Listener) {
+			errs <- su.Serve(l)
+		}(a.webApp.NewHost(s.Server), listeners[i])
+	}
+	select {
+	case err := <-errs:
+		return err
+	case err := <-a.Failures():
+		log.Errorf("%v failed: %v", name, err)
+		ctx, cancel := stdctx.WithTimeout(stdctx.Background(), 5*time.Second)
+		defer cancel()
+		a.webApp.Shutdown(ctx)
+		return err
 	}
 }
 
@@ -159,6 +212,15 @@ func (a *application) Use(handlers ...context.Handler) {
 	}
 }
 
+// Handle register the route handlers that are not bound to a controller, e.g. the grpc gateway routes
+func (a *application) Handle(method, path string, handlers ...context.Handler) {
+	var hdls []iris.Handler
+	for _, hdl := range handlers {
+		hdls = append(hdls, Handler(hdl))
+	}
+	a.webApp.Handle(method, path, hdls...)
+}
+
 func (a *application) initialize(controllers ...interface{}) (err error) {
 	io.EnsureWorkDir(3, "config/application.yml")
 
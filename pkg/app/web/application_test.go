@@ -15,12 +15,14 @@
 package web_test
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"github.com/stretchr/testify/assert"
 	"hidevops.io/hiboot/pkg/app"
 	"hidevops.io/hiboot/pkg/app/web"
 	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/app/web/sse"
 	"hidevops.io/hiboot/pkg/at"
 	"hidevops.io/hiboot/pkg/log"
 	"hidevops.io/hiboot/pkg/model"
@@ -30,7 +32,9 @@ import (
 	_ "hidevops.io/hiboot/pkg/starter/logging"
 	"hidevops.io/hiboot/pkg/utils/io"
 	"hidevops.io/hiboot/pkg/utils/reflector"
+	"mime/multipart"
 	"net/http"
+	"strconv"
 	"testing"
 	"time"
 )
@@ -748,3 +752,113 @@ func TestAnonymousController(t *testing.T) {
 		assert.NotEqual(t, nil, testApp)
 	})
 }
+
+type eventController struct {
+	at.RestController
+}
+
+func newEventController() *eventController {
+	return &eventController{}
+}
+
+// GetTicks GET /event/ticks
+func (c *eventController) GetTicks(ctx context.Context) <-chan sse.Event {
+	events := make(chan sse.Event)
+	go func() {
+		defer close(events)
+		for i := 1; i <= 3; i++ {
+			select {
+			case events <- sse.Event{ID: strconv.Itoa(i), Name: "tick", Data: map[string]int{"count": i}}:
+			case <-ctx.Request().Context().Done():
+				return
+			}
+		}
+	}()
+	return events
+}
+
+// GetResume GET /event/resume
+func (c *eventController) GetResume(writer sse.Writer) {
+	writer.Send(sse.Event{ID: "43", Data: "resumed after " + writer.LastEventID()})
+}
+
+func TestServerSentEvents(t *testing.T) {
+	testApp := web.RunTestApplication(t, newEventController)
+
+	t.Run("should stream the returned events", func(t *testing.T) {
+		res := testApp.Get("/event/ticks").
+			Expect().Status(http.StatusOK)
+		res.Header("Content-Type").Contains(sse.ContentType)
+		res.Body().Equal("id: 1\nevent: tick\ndata: {\"count\":1}\n\n" +
+			"id: 2\nevent: tick\ndata: {\"count\":2}\n\n" +
+			"id: 3\nevent: tick\ndata: {\"count\":3}\n\n")
+	})
+
+	t.Run("should inject the event writer", func(t *testing.T) {
+		testApp.Get("/event/resume").
+			WithHeader(sse.LastEventIDHeader, "42").
+			Expect().Status(http.StatusOK).
+			Body().Equal("id: 43\ndata: resumed after 42\n\n")
+	})
+}
+
+type avatarRequest struct {
+	at.RequestFile
+	Name   string                  `form:"name" validate:"required"`
+	Avatar *multipart.FileHeader   `form:"avatar" accept:"image/*" validate:"required"`
+	Photos []*multipart.FileHeader `form:"photos"`
+}
+
+type uploadController struct {
+	at.RestController
+}
+
+func newUploadController() *uploadController {
+	return &uploadController{}
+}
+
+// PostAvatar POST /upload/avatar
+func (c *uploadController) PostAvatar(request *avatarRequest) string {
+	return fmt.Sprintf("%v uploaded %v with %v photos", request.Name, request.Avatar.Filename, len(request.Photos))
+}
+
+func TestRequestFile(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	testApp := web.NewTestApp(newUploadController).
+		SetProperty("web.upload.maxSize", 1024).
+		Run(t)
+
+	t.Run("should bind the uploaded files", func(t *testing.T) {
+		testApp.Post("/upload/avatar").
+			WithMultipart().
+			WithFormField("name", "johnd").
+			WithFile("avatar", "me.png", bytes.NewReader(png)).
+			WithFile("photos", "a.png", bytes.NewReader(png)).
+			WithFile("photos", "b.png", bytes.NewReader(png)).
+			Expect().Status(http.StatusOK).
+			Body().Equal("johnd uploaded me.png with 2 photos")
+	})
+
+	t.Run("should reject the file type that is not accepted", func(t *testing.T) {
+		testApp.Post("/upload/avatar").
+			WithMultipart().
+			WithFormField("name", "johnd").
+			WithFile("avatar", "me.png", bytes.NewReader([]byte("hello"))).
+			Expect().Status(http.StatusUnsupportedMediaType)
+	})
+
+	t.Run("should reject the upload that is too large", func(t *testing.T) {
+		testApp.Post("/upload/avatar").
+			WithMultipart().
+			WithFormField("name", "johnd").
+			WithFile("avatar", "me.png", bytes.NewReader(append(png, make([]byte, 2048)...))).
+			Expect().Status(http.StatusRequestEntityTooLarge)
+	})
+
+	t.Run("should validate the required file", func(t *testing.T) {
+		testApp.Post("/upload/avatar").
+			WithMultipart().
+			WithFormField("name", "johnd").
+			Expect().Status(http.StatusBadRequest)
+	})
+}
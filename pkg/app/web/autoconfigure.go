@@ -5,6 +5,7 @@ import (
 	"github.com/kataras/iris"
 	"hidevops.io/hiboot/pkg/app"
 	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/app/web/upload"
 	"hidevops.io/hiboot/pkg/at"
 	"hidevops.io/hiboot/pkg/log"
 )
@@ -30,6 +31,11 @@ func (c *configuration) Context(app *webApp) context.Context {
 	return NewContext(app)
 }
 
+// Upload is the instance of the upload properties that the files of at.RequestFile are bound with
+func (c *configuration) Upload() *upload.Properties {
+	return &c.Properties.Upload
+}
+
 // DefaultView set the default view
 func (c *configuration) DefaultView(app *webApp) {
 
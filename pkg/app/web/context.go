@@ -21,6 +21,7 @@ import (
 	ctx "github.com/kataras/iris/context"
 	"github.com/kataras/iris/middleware/i18n"
 	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/app/web/upload"
 	"hidevops.io/hiboot/pkg/model"
 	"hidevops.io/hiboot/pkg/utils/mapstruct"
 	"hidevops.io/hiboot/pkg/utils/validator"
@@ -162,6 +163,24 @@ func RequestForm(c context.Context, data interface{}) error {
 	})
 }
 
+// RequestFile get RequestFile, the files are bound before the form fields,
+// the caller removes the temp files by upload.RemoveAll once they are handled, the handler of at.RequestFile does it already
+func RequestFile(c context.Context, data interface{}, p *upload.Properties) error {
+	if err := upload.Bind(c.ResponseWriter(), c.Request(), p, data); err != nil {
+		upload.RemoveAll(c.Request())
+		code := http.StatusBadRequest
+		switch err {
+		case upload.ErrTooLarge:
+			code = http.StatusRequestEntityTooLarge
+		case upload.ErrTypeNotAllowed:
+			code = http.StatusUnsupportedMediaType
+		}
+		c.ResponseError(err.Error(), code)
+		return err
+	}
+	return RequestForm(c, data)
+}
+
 // RequestParams get RequestParams
 func RequestParams(c context.Context, data interface{}) error {
 
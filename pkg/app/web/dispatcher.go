@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"github.com/fatih/camelcase"
 	"github.com/kataras/iris"
+	"github.com/kataras/iris/core/router"
 	"hidevops.io/hiboot/pkg/app"
 	"hidevops.io/hiboot/pkg/app/web/context"
 	"hidevops.io/hiboot/pkg/factory"
@@ -42,10 +43,24 @@ var httpMethods = []string{
 
 const Any = "ANY"
 
+// Route is the route that is mapped onto the method of the controller
+type Route struct {
+	// Method is the http method
+	Method string
+	// Path is the full path of the route, e.g. /foo/{id}
+	Path string
+	// Controller is the full name of the controller, e.g. github.com/foo/bar/fooController
+	Controller string
+}
+
+// RouteListener is notified of the routes of each controller once they are registered
+type RouteListener func(routes []Route)
+
 type Dispatcher struct {
 	webApp *webApp
 	// inject context aware dependencies
 	configurableFactory factory.ConfigurableFactory
+	routeListeners      []RouteListener
 
 	//contextAwareInstances []interface{}
 }
@@ -62,6 +77,12 @@ func init() {
 	app.Register(newDispatcher)
 }
 
+// AddRouteListener add the listener that is notified of the routes of the controllers that are registered later,
+// e.g. the cors starter maps OPTIONS onto the paths of the controllers
+func (d *Dispatcher) AddRouteListener(listener RouteListener) {
+	d.routeListeners = append(d.routeListeners, listener)
+}
+
 func (d *Dispatcher) register(controllers []*factory.MetaData) (err error) {
 	for _, metaData := range controllers {
 		c := metaData.Instance
@@ -121,6 +142,10 @@ func (d *Dispatcher) register(controllers []*factory.MetaData) (err error) {
 			}))
 		}
 
+		// the routes that mapped onto current controller
+		var routes []Route
+		fullName := pkgPath + "/" + fieldName
+
 		for mi := 0; mi < numOfMethod; mi++ {
 			method := fieldType.Method(mi)
 			methodName := method.Name
@@ -156,14 +181,25 @@ func (d *Dispatcher) register(controllers []*factory.MetaData) (err error) {
 					c.Next()
 				})
 
+				var rs []*router.Route
 				if hasAnyMethod {
-					party.Any(apiContextMapping, methodHandler)
+					rs = party.Any(apiContextMapping, methodHandler)
 				} else if hasGenericMethod {
-					route := party.Handle(httpMethod, apiContextMapping, methodHandler)
-					route.MainHandlerName = fmt.Sprintf("%s/%s.%s", pkgPath, fieldName, methodName)
+					rs = append(rs, party.Handle(httpMethod, apiContextMapping, methodHandler))
+				}
+				for _, route := range rs {
+					if route == nil {
+						continue
+					}
+					route.MainHandlerName = fmt.Sprintf("%s.%s", fullName, methodName)
+					routes = append(routes, Route{Method: route.Method, Path: route.Path, Controller: fullName})
 				}
 			}
 		}
+
+		for _, listener := range d.routeListeners {
+			listener(routes)
+		}
 	}
 	return nil
 }
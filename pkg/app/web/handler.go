@@ -18,6 +18,8 @@ import (
 	"errors"
 	"fmt"
 	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/app/web/sse"
+	"hidevops.io/hiboot/pkg/app/web/upload"
 	"hidevops.io/hiboot/pkg/at"
 	"hidevops.io/hiboot/pkg/factory"
 	"hidevops.io/hiboot/pkg/log"
@@ -37,6 +39,9 @@ const (
 
 var (
 	ErrCanNotInterface = errors.New("response can not interface")
+
+	sseWriterType = reflect.TypeOf((*sse.Writer)(nil)).Elem()
+	sseEventsType = reflect.TypeOf((<-chan sse.Event)(nil))
 )
 
 type request struct {
@@ -75,6 +80,10 @@ type handler struct {
 	runtimeInstance factory.Instance
 	contextName     string
 	dependencies    []*factory.MetaData
+	// streaming is true if the method returns the events or takes the event writer
+	streaming bool
+	// uploadProperties is the properties that the files of at.RequestFile are bound with
+	uploadProperties *upload.Properties
 }
 
 type requestSet struct {
@@ -82,7 +91,10 @@ type requestSet struct {
 	callback func(ctx context.Context, data interface{}) error
 }
 
-var requestSets []requestSet
+var (
+	requestSets     []requestSet
+	requestFileName = newRequestTypeName(new(at.RequestFile))
+)
 
 func newRequestTypeName(in interface{}) string {
 	return reflector.GetName(in)
@@ -123,6 +135,7 @@ func (h *handler) parse(method reflect.Method, object interface{}, path string)
 	h.numOut = method.Type.NumOut()
 	//h.inputs = make([]reflect.Value, h.numIn)
 	h.ctlVal = reflect.ValueOf(object)
+	h.uploadProperties, _ = h.factory.GetInstance(reflector.GetLowerCamelFullName(new(upload.Properties))).(*upload.Properties)
 
 	//log.Debugf("method: %v", method.Name)
 
@@ -192,6 +205,13 @@ func (h *handler) parse(method reflect.Method, object interface{}, path string)
 					break
 				}
 			}
+			if field, ok := iTyp.FieldByName(requestFileName); ok && field.Anonymous {
+				h.requests[i].typeName = requestFileName
+				h.requests[i].callback = h.requestFile
+			}
+		}
+		if typ == sseWriterType {
+			h.streaming = true
 		}
 		h.requests[i].fullName = reflector.GetLowerCamelFullNameByType(iTyp)
 	}
@@ -206,6 +226,9 @@ func (h *handler) parse(method reflect.Method, object interface{}, path string)
 		h.responses[i].typeName = typ.Name()
 		//log.Debug(h.responses[i])
 	}
+	if h.numOut > 0 && h.responses[0].typ == sseEventsType {
+		h.streaming = true
+	}
 	if path != "" {
 		log.Infof("Mapped \"%v\" onto %v.%v()", path, idv.Type(), method.Name)
 	}
@@ -271,6 +294,24 @@ func (h *handler) responseData(ctx context.Context, numOut int, results []reflec
 	return
 }
 
+// requestFile binds the uploaded files with the upload properties of the web configuration
+func (h *handler) requestFile(ctx context.Context, data interface{}) error {
+	if h.uploadProperties == nil {
+		return RequestFile(ctx, data, &upload.Properties{MaxSize: 32 << 20, MaxMemory: 10 << 20})
+	}
+	return RequestFile(ctx, data, h.uploadProperties)
+}
+
+// responseEvents forwards the returned events to the event stream until the channel is closed or the client disconnects
+func (h *handler) responseEvents(stream *sse.Stream, results []reflect.Value) {
+	if h.numOut == 0 || h.responses[0].typ != sseEventsType {
+		return
+	}
+	if events, ok := results[0].Interface().(<-chan sse.Event); ok {
+		stream.Forward(events)
+	}
+}
+
 func (h *handler) call(ctx context.Context) {
 
 	var request interface{}
@@ -278,6 +319,7 @@ func (h *handler) call(ctx context.Context) {
 	var path string
 	var pvs []string
 	var runtimeInstance factory.Instance
+	var stream *sse.Stream
 	//var err error
 
 	if h.lenOfPathParams != 0 {
@@ -295,11 +337,18 @@ func (h *handler) call(ctx context.Context) {
 		request = req.iVal.Interface()
 
 		if req.callback != nil {
+			if req.typeName == requestFileName {
+				// the files are removed once the controller method returns
+				defer upload.RemoveAll(ctx.Request())
+			}
 			reqErr = req.callback(ctx, request)
 			inputs[i] = reflect.ValueOf(request)
 		} else if req.kind == reflect.Interface && model.Context == req.typeName {
 			request = ctx
 			inputs[i] = reflect.ValueOf(request)
+		} else if req.typ == sseWriterType {
+			// the event writer is injected once the stream starts
+			continue
 		} else if h.lenOfPathParams != 0 {
 			strVal := pvs[req.pathIdx]
 			val := str.Convert(strVal, req.kind)
@@ -325,6 +374,28 @@ func (h *handler) call(ctx context.Context) {
 
 	//var respErr error
 	var results []reflect.Value
+	if reqErr == nil && h.streaming {
+		var err error
+		// the events are written to the client directly instead of the response recorder, e.g. the one of the compress middleware
+		if recorder, ok := ctx.IsRecording(); ok {
+			header := recorder.ResponseWriter.Header()
+			for key, values := range recorder.Header() {
+				header[key] = values
+			}
+			ctx.ResetResponseWriter(recorder.ResponseWriter)
+		}
+		stream, err = sse.NewStream(ctx.ResponseWriter(), ctx.Request())
+		if err != nil {
+			ctx.ResponseError(err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer stream.Close()
+		for i := 1; i < h.numIn; i++ {
+			if h.requests[i].typ == sseWriterType {
+				inputs[i] = reflect.ValueOf(stream)
+			}
+		}
+	}
 	if reqErr == nil {
 		if h.hasCtxField {
 			reflector.SetFieldValue(h.controller, "Ctx", ctx)
@@ -332,6 +403,10 @@ func (h *handler) call(ctx context.Context) {
 		// call controller method
 		results = h.method.Func.Call(inputs)
 
-		h.responseData(ctx, h.numOut, results)
+		if h.streaming {
+			h.responseEvents(stream, results)
+		} else {
+			h.responseData(ctx, h.numOut, results)
+		}
 	}
 }
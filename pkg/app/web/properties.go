@@ -1,5 +1,7 @@
 package web
 
+import "hidevops.io/hiboot/pkg/app/web/upload"
+
 const (
 	// ViewEnabled is the property for enabling web view
 	ViewEnabled = "web.view.enabled"
@@ -29,4 +31,6 @@ type view struct {
 type properties struct {
 	// View is the properties for setting web view
 	View view
+	// Upload is the properties of the multipart file uploads
+	Upload upload.Properties
 }
@@ -0,0 +1,151 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package server provides the http servers of the web application,
+// they are configured by the server properties, e.g. server.host, server.tls.cert_file or server.unix
+package server
+
+import (
+	"crypto/tls"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"hidevops.io/hiboot/pkg/system"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	tcp  = "tcp"
+	unix = "unix"
+)
+
+// Server is the http server and the listener it serves on
+type Server struct {
+	*http.Server
+
+	network  string
+	certFile string
+	keyFile  string
+}
+
+// New creates the servers of the handler, the first one is the main server that listens on the port,
+// it is followed by the redirect server if tls is enabled and the server of the unix domain socket
+func New(p *system.Server, handler http.Handler) (servers []*Server) {
+	secure := p.TLS.CertFile != "" && p.TLS.KeyFile != ""
+
+	main := newServer(p, tcp, net.JoinHostPort(p.Host, p.Port), handler)
+	if secure {
+		main.certFile, main.keyFile = p.TLS.CertFile, p.TLS.KeyFile
+	} else if p.H2C {
+		main.Handler = h2c.NewHandler(handler, &http2.Server{})
+	}
+	servers = append(servers, main)
+
+	if secure && p.TLS.RedirectPort != "" {
+		servers = append(servers, newServer(p, tcp, net.JoinHostPort(p.Host, p.TLS.RedirectPort), RedirectHandler(p.Port)))
+	}
+
+	if p.Unix != "" {
+		socket := newServer(p, unix, p.Unix, handler)
+		if p.H2C {
+			socket.Handler = h2c.NewHandler(handler, &http2.Server{})
+		}
+		servers = append(servers, socket)
+	}
+	return
+}
+
+func newServer(p *system.Server, network, addr string, handler http.Handler) *Server {
+	return &Server{
+		Server: &http.Server{
+			Addr:         addr,
+			Handler:      handler,
+			ReadTimeout:  time.Duration(p.ReadTimeout) * time.Second,
+			WriteTimeout: time.Duration(p.WriteTimeout) * time.Second,
+			IdleTimeout:  time.Duration(p.IdleTimeout) * time.Second,
+		},
+		network: network,
+	}
+}
+
+// Secure reports whether the server serves https
+func (s *Server) Secure() bool {
+	return s.certFile != ""
+}
+
+// Listen announces on the address of the server, the stale unix domain socket is removed first,
+// the listener of https negotiates http/2 by alpn
+func (s *Server) Listen() (l net.Listener, err error) {
+	if s.network == unix {
+		if fi, e := os.Stat(s.Addr); e == nil && fi.Mode()&os.ModeSocket != 0 {
+			os.Remove(s.Addr)
+		}
+	}
+
+	l, err = net.Listen(s.network, s.Addr)
+	if err != nil || !s.Secure() {
+		return
+	}
+
+	var cert tls.Certificate
+	cert, err = tls.LoadX509KeyPair(s.certFile, s.keyFile)
+	if err != nil {
+		l.Close()
+		return nil, err
+	}
+	s.TLSConfig = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{http2.NextProtoTLS, "http/1.1"},
+	}
+	return tls.NewListener(l, s.TLSConfig), nil
+}
+
+// URL returns the url the server is reachable at, e.g. https://localhost:8443 or unix:/var/run/app.sock
+func (s *Server) URL() string {
+	if s.network == unix {
+		return unix + ":" + s.Addr
+	}
+
+	scheme := "http"
+	if s.Secure() {
+		scheme = "https"
+	}
+	host, port, _ := net.SplitHostPort(s.Addr)
+	if host == "" {
+		host = "localhost"
+	}
+	return scheme + "://" + net.JoinHostPort(host, port)
+}
+
+// RedirectHandler redirects the requests to https on the port,
+// the requests except GET and HEAD are redirected with the method and the body kept
+func RedirectHandler(port string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(r.Host); err == nil {
+			host = h
+		}
+		if port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+
+		code := http.StatusPermanentRedirect
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			code = http.StatusMovedPermanently
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), code)
+	})
+}
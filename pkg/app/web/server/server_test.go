@@ -0,0 +1,196 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/http2"
+	"hidevops.io/hiboot/pkg/system"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var protoHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprint(w, r.Proto)
+})
+
+func writeCertificate(t *testing.T, dir string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Equal(t, nil, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Equal(t, nil, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.Equal(t, nil, err)
+
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	assert.Equal(t, nil, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.Equal(t, nil, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	return
+}
+
+func serve(t *testing.T, s *Server) net.Listener {
+	l, err := s.Listen()
+	assert.Equal(t, nil, err)
+	go s.Serve(l)
+	return l
+}
+
+func get(t *testing.T, client *http.Client, url string) string {
+	resp, err := client.Get(url)
+	assert.Equal(t, nil, err)
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, nil, err)
+	return string(body)
+}
+
+func TestNew(t *testing.T) {
+	t.Run("should create the main server", func(t *testing.T) {
+		servers := New(&system.Server{Port: "8080", ReadTimeout: 5, WriteTimeout: 10, IdleTimeout: 60}, protoHandler)
+		assert.Equal(t, 1, len(servers))
+		assert.Equal(t, ":8080", servers[0].Addr)
+		assert.Equal(t, "http://localhost:8080", servers[0].URL())
+		assert.Equal(t, 5*time.Second, servers[0].ReadTimeout)
+		assert.Equal(t, 10*time.Second, servers[0].WriteTimeout)
+		assert.Equal(t, time.Minute, servers[0].IdleTimeout)
+	})
+
+	t.Run("should create the servers of https, redirect and unix domain socket", func(t *testing.T) {
+		servers := New(&system.Server{
+			Host: "127.0.0.1",
+			Port: "8443",
+			Unix: "/tmp/app.sock",
+			TLS:  system.TLS{CertFile: "cert.pem", KeyFile: "key.pem", RedirectPort: "8080"},
+		}, protoHandler)
+		assert.Equal(t, 3, len(servers))
+		assert.Equal(t, "https://127.0.0.1:8443", servers[0].URL())
+		assert.Equal(t, true, servers[0].Secure())
+		assert.Equal(t, "http://127.0.0.1:8080", servers[1].URL())
+		assert.Equal(t, "unix:/tmp/app.sock", servers[2].URL())
+	})
+
+	t.Run("should not redirect without tls", func(t *testing.T) {
+		servers := New(&system.Server{Port: "8080", TLS: system.TLS{RedirectPort: "80"}}, protoHandler)
+		assert.Equal(t, 1, len(servers))
+		assert.Equal(t, false, servers[0].Secure())
+	})
+}
+
+func TestServe(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+
+	t.Run("should serve http/2 over tls", func(t *testing.T) {
+		certFile, keyFile := writeCertificate(t, dir)
+		s := New(&system.Server{Host: "127.0.0.1", Port: "0", TLS: system.TLS{CertFile: certFile, KeyFile: keyFile}}, protoHandler)[0]
+		l := serve(t, s)
+		defer s.Close()
+
+		client := &http.Client{Transport: &http2.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+		assert.Equal(t, "HTTP/2.0", get(t, client, "https://"+l.Addr().String()))
+	})
+
+	t.Run("should report the invalid certificate", func(t *testing.T) {
+		s := New(&system.Server{Host: "127.0.0.1", Port: "0", TLS: system.TLS{CertFile: "missing.pem", KeyFile: "missing.pem"}}, protoHandler)[0]
+		_, err := s.Listen()
+		assert.NotEqual(t, nil, err)
+	})
+
+	t.Run("should serve http/2 over cleartext", func(t *testing.T) {
+		s := New(&system.Server{Host: "127.0.0.1", Port: "0", H2C: true}, protoHandler)[0]
+		l := serve(t, s)
+		defer s.Close()
+
+		client := &http.Client{Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, cfg *tls.Config) (net.Conn, error) {
+				return net.Dial(network, addr)
+			},
+		}}
+		assert.Equal(t, "HTTP/2.0", get(t, client, "http://"+l.Addr().String()))
+		assert.Equal(t, "HTTP/1.1", get(t, http.DefaultClient, "http://"+l.Addr().String()))
+	})
+
+	t.Run("should serve on the unix domain socket", func(t *testing.T) {
+		socket := filepath.Join(dir, "app.sock")
+		stale, err := net.Listen(unix, socket)
+		assert.Equal(t, nil, err)
+		stale.(*net.UnixListener).SetUnlinkOnClose(false)
+		stale.Close()
+
+		s := New(&system.Server{Port: "0", Unix: socket}, protoHandler)[1]
+		serve(t, s)
+		defer s.Close()
+
+		client := &http.Client{Transport: &http.Transport{
+			DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
+				return net.Dial(unix, socket)
+			},
+		}}
+		assert.Equal(t, "HTTP/1.1", get(t, client, "http://unix/"))
+	})
+}
+
+func TestRedirectHandler(t *testing.T) {
+	testCases := []struct {
+		method   string
+		target   string
+		port     string
+		code     int
+		location string
+	}{
+		{http.MethodGet, "http://example.com/foo?bar=baz", "443", http.StatusMovedPermanently, "https://example.com/foo?bar=baz"},
+		{http.MethodGet, "http://example.com:8080/foo", "8443", http.StatusMovedPermanently, "https://example.com:8443/foo"},
+		{http.MethodPost, "http://example.com/foo", "443", http.StatusPermanentRedirect, "https://example.com/foo"},
+	}
+	for _, tc := range testCases {
+		t.Run("should redirect "+tc.method+" "+tc.target, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			RedirectHandler(tc.port).ServeHTTP(w, httptest.NewRequest(tc.method, tc.target, nil))
+			assert.Equal(t, tc.code, w.Code)
+			assert.Equal(t, tc.location, w.Header().Get("Location"))
+		})
+	}
+}
@@ -0,0 +1,263 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sse provides the server-sent events that the controller methods stream,
+// a method either returns <-chan sse.Event or takes the injected sse.Writer, e.g.
+//
+//	// GetTicks GET /ticks
+//	func (c *tickController) GetTicks(ctx context.Context) <-chan sse.Event {
+//		events := make(chan sse.Event)
+//		go func() {
+//			defer close(events)
+//			for i := 0; i < 10; i++ {
+//				select {
+//				case events <- sse.Event{ID: strconv.Itoa(i), Data: i}:
+//				case <-ctx.Request().Context().Done():
+//					return
+//				}
+//			}
+//		}()
+//		return events
+//	}
+//
+//	// GetNews GET /news
+//	func (c *newsController) GetNews(writer sse.Writer) {
+//		for {
+//			select {
+//			case news := <-c.news:
+//				writer.Send(sse.Event{Name: "news", Data: news})
+//			case <-writer.Done():
+//				return
+//			}
+//		}
+//	}
+package sse
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ContentType is the content type of the event stream
+	ContentType = "text/event-stream"
+	// LastEventIDHeader is the header that the client resumes the stream with
+	LastEventIDHeader = "Last-Event-ID"
+)
+
+var (
+	// KeepAliveInterval is the interval of the keepalive comments, it keeps the idle stream open through the proxies
+	KeepAliveInterval = 15 * time.Second
+
+	// ErrStreamingNotSupported the response writer is not able to flush
+	ErrStreamingNotSupported = errors.New("[sse] streaming is not supported")
+
+	// ErrStreamClosed the client is disconnected or the stream is closed
+	ErrStreamClosed = errors.New("[sse] stream is closed")
+)
+
+// Event is the server-sent event
+type Event struct {
+	// ID is the id of the event, the client sends the id of the last received event by Last-Event-ID when it reconnects
+	ID string
+	// Name is the type of the event, the client receives it as message if it is empty
+	Name string
+	// Data is the payload, string and []byte are sent as is, the others are encoded as json
+	Data interface{}
+	// Retry is the reconnection time of the client
+	Retry time.Duration
+}
+
+// Writer is the event writer that is injected into the controller methods
+type Writer interface {
+	// Send writes and flushes the event
+	Send(event Event) error
+	// LastEventID returns the id of the last event that the client received before it reconnects
+	LastEventID() string
+	// Done is closed when the client disconnects
+	Done() <-chan struct{}
+}
+
+// Stream is the event stream of a request
+type Stream struct {
+	mu          sync.Mutex
+	writer      http.ResponseWriter
+	flusher     http.Flusher
+	lastEventID string
+	done        <-chan struct{}
+	closed      chan struct{}
+	once        sync.Once
+}
+
+// NewStream starts the event stream of the request, it writes the headers and sends the keepalive comments until it is closed
+func NewStream(w http.ResponseWriter, r *http.Request) (s *Stream, err error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, ErrStreamingNotSupported
+	}
+	s = &Stream{
+		writer:      w,
+		flusher:     flusher,
+		lastEventID: r.Header.Get(LastEventIDHeader),
+		done:        r.Context().Done(),
+		closed:      make(chan struct{}),
+	}
+	header := w.Header()
+	header.Set("Content-Type", ContentType)
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// disable the response buffering of nginx
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	go s.keepAlive()
+	return
+}
+
+// keepAlive sends the keepalive comments, and closes the stream when the client disconnects
+func (s *Stream) keepAlive() {
+	ticker := time.NewTicker(KeepAliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.write([]byte(": keepalive\n\n"))
+		case <-s.done:
+			s.Close()
+			return
+		case <-s.closed:
+			return
+		}
+	}
+}
+
+// LastEventID implements Writer
+func (s *Stream) LastEventID() string {
+	return s.lastEventID
+}
+
+// Done implements Writer, it is closed when the client disconnects or the stream is closed
+func (s *Stream) Done() <-chan struct{} {
+	return s.closed
+}
+
+// Send implements Writer
+func (s *Stream) Send(event Event) error {
+	data, err := Encode(event)
+	if err != nil {
+		return err
+	}
+	return s.write(data)
+}
+
+// write writes and flushes the data unless the stream is closed
+func (s *Stream) write(data []byte) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.closed:
+		return ErrStreamClosed
+	case <-s.done:
+		return ErrStreamClosed
+	default:
+	}
+	if _, err = s.writer.Write(data); err == nil {
+		s.flusher.Flush()
+	}
+	return
+}
+
+// Forward sends the events until the channel is closed or the client disconnects,
+// the rest of the events are drained so that the producer is not blocked forever
+func (s *Stream) Forward(events <-chan Event) {
+	if events == nil {
+		return
+	}
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := s.Send(event); err != nil {
+				go drain(events)
+				return
+			}
+		case <-s.closed:
+			go drain(events)
+			return
+		}
+	}
+}
+
+func drain(events <-chan Event) {
+	for range events {
+	}
+}
+
+// Close stops the keepalive comments, the events are not able to be sent after the stream is closed,
+// it waits for the write in progress so that nothing is written after the handler returns
+func (s *Stream) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.once.Do(func() {
+		close(s.closed)
+	})
+}
+
+// Encode encodes the event in the event stream format
+func Encode(event Event) ([]byte, error) {
+	var data string
+	switch d := event.Data.(type) {
+	case nil:
+	case string:
+		data = d
+	case []byte:
+		data = string(d)
+	default:
+		b, err := json.Marshal(d)
+		if err != nil {
+			return nil, err
+		}
+		data = string(b)
+	}
+
+	buf := new(bytes.Buffer)
+	if event.ID != "" {
+		fmt.Fprintf(buf, "id: %s\n", singleLine(event.ID))
+	}
+	if event.Name != "" {
+		fmt.Fprintf(buf, "event: %s\n", singleLine(event.Name))
+	}
+	if event.Retry > 0 {
+		fmt.Fprintf(buf, "retry: %d\n", event.Retry/time.Millisecond)
+	}
+	for _, line := range strings.Split(strings.Replace(data, "\r\n", "\n", -1), "\n") {
+		fmt.Fprintf(buf, "data: %s\n", line)
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// singleLine removes the line breaks that would end the field
+func singleLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
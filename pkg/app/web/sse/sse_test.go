@@ -0,0 +1,195 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sse
+
+import (
+	"bufio"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// plainWriter is the response writer that is not able to flush
+type plainWriter struct {
+	http.ResponseWriter
+}
+
+// finishedWriter records the writes after the handler is finished
+type finishedWriter struct {
+	*httptest.ResponseRecorder
+	mu       sync.Mutex
+	finished bool
+	late     int
+}
+
+func (w *finishedWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	if w.finished {
+		w.late++
+	}
+	w.mu.Unlock()
+	return w.ResponseRecorder.Write(data)
+}
+
+func (w *finishedWriter) finish() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.finished = true
+}
+
+func (w *finishedWriter) lateWrites() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.late
+}
+
+func TestEncode(t *testing.T) {
+	t.Run("should encode the string data", func(t *testing.T) {
+		data, err := Encode(Event{ID: "1", Name: "greeting", Data: "hello\nworld"})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "id: 1\nevent: greeting\ndata: hello\ndata: world\n\n", string(data))
+	})
+
+	t.Run("should encode the json data and retry", func(t *testing.T) {
+		data, err := Encode(Event{Data: map[string]int{"count": 1}, Retry: 3 * time.Second})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "retry: 3000\ndata: {\"count\":1}\n\n", string(data))
+	})
+
+	t.Run("should not break the fields by the line breaks", func(t *testing.T) {
+		data, err := Encode(Event{ID: "1\n2", Data: []byte("a\r\nb")})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "id: 12\ndata: a\ndata: b\n\n", string(data))
+	})
+
+	t.Run("should not encode the invalid data", func(t *testing.T) {
+		_, err := Encode(Event{Data: make(chan int)})
+		assert.NotEqual(t, nil, err)
+	})
+}
+
+func TestStream(t *testing.T) {
+	KeepAliveInterval = 10 * time.Millisecond
+	returned := make(chan string, 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		s, err := NewStream(w, r)
+		assert.Equal(t, nil, err)
+		defer s.Close()
+		events := make(chan Event, 2)
+		events <- Event{ID: "1", Data: "first"}
+		events <- Event{ID: "2", Data: "resumed after " + s.LastEventID()}
+		close(events)
+		s.Forward(events)
+	})
+	mux.HandleFunc("/endless", func(w http.ResponseWriter, r *http.Request) {
+		s, err := NewStream(w, r)
+		assert.Equal(t, nil, err)
+		defer s.Close()
+		events := make(chan Event)
+		quit := make(chan struct{})
+		go func() {
+			defer close(events)
+			for {
+				select {
+				case events <- Event{Data: "tick"}:
+				case <-quit:
+					return
+				}
+			}
+		}()
+		s.Forward(events)
+		// the producer is not blocked after the client is gone
+		events <- Event{}
+		close(quit)
+		returned <- "endless"
+	})
+	mux.HandleFunc("/writer", func(w http.ResponseWriter, r *http.Request) {
+		s, err := NewStream(w, r)
+		assert.Equal(t, nil, err)
+		var writer Writer = s
+		<-writer.Done()
+		assert.Equal(t, ErrStreamClosed, writer.Send(Event{Data: "gone"}))
+		returned <- "writer"
+	})
+	mux.HandleFunc("/unsupported", func(w http.ResponseWriter, r *http.Request) {
+		_, err := NewStream(&plainWriter{w}, r)
+		assert.Equal(t, ErrStreamingNotSupported, err)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	t.Run("should forward the events and resume with the last event id", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/events", nil)
+		req.Header.Set(LastEventIDHeader, "42")
+		res, err := http.DefaultClient.Do(req)
+		assert.Equal(t, nil, err)
+		defer res.Body.Close()
+		assert.Equal(t, ContentType, res.Header.Get("Content-Type"))
+		assert.Equal(t, "no-cache", res.Header.Get("Cache-Control"))
+		body, err := ioutil.ReadAll(res.Body)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "id: 1\ndata: first\n\nid: 2\ndata: resumed after 42\n\n", string(body))
+	})
+
+	t.Run("should stop forwarding when the client disconnects", func(t *testing.T) {
+		res, err := http.Get(server.URL + "/endless")
+		assert.Equal(t, nil, err)
+		line, err := bufio.NewReader(res.Body).ReadString('\n')
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "data: tick\n", line)
+		res.Body.Close()
+		assert.Equal(t, "endless", <-returned)
+	})
+
+	t.Run("should send the keepalive comments until the client disconnects", func(t *testing.T) {
+		res, err := http.Get(server.URL + "/writer")
+		assert.Equal(t, nil, err)
+		reader := bufio.NewReader(res.Body)
+		for {
+			line, err := reader.ReadString('\n')
+			assert.Equal(t, nil, err)
+			if strings.HasPrefix(line, ": keepalive") {
+				break
+			}
+		}
+		res.Body.Close()
+		assert.Equal(t, "writer", <-returned)
+	})
+
+	t.Run("should not stream if the response writer is not able to flush", func(t *testing.T) {
+		res, err := http.Get(server.URL + "/unsupported")
+		assert.Equal(t, nil, err)
+		res.Body.Close()
+	})
+
+	t.Run("should not write after the stream is closed", func(t *testing.T) {
+		w := &finishedWriter{ResponseRecorder: httptest.NewRecorder()}
+		s, err := NewStream(w, httptest.NewRequest(http.MethodGet, "/events", nil))
+		assert.Equal(t, nil, err)
+		time.Sleep(3 * KeepAliveInterval)
+		s.Close()
+		w.finish()
+		time.Sleep(3 * KeepAliveInterval)
+		assert.Equal(t, 0, w.lateWrites())
+		assert.Equal(t, ErrStreamClosed, s.Send(Event{Data: "late"}))
+	})
+}
@@ -0,0 +1,192 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package upload binds the multipart files of the request to the struct that embeds at.RequestFile, e.g.
+//
+//	type avatarRequest struct {
+//		at.RequestFile
+//		Name   string                  `form:"name" validate:"required"`
+//		Avatar *multipart.FileHeader   `form:"avatar" accept:"image/*" validate:"required"`
+//		Photos []*multipart.FileHeader `form:"photos"`
+//	}
+//
+// The files that are larger than max_memory are streamed to the temp dir of the os (TMPDIR) instead of being held in memory,
+// they are removed by RemoveAll once the request is handled, as the server does not remove the files of the form
+// that is parsed on the request that the middlewares replaced, e.g. by WithContext.
+package upload
+
+import (
+	"errors"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+const (
+	// sniffLen is the number of bytes that the content type is detected by
+	sniffLen = 512
+)
+
+var (
+	// ErrTooLarge the request body is larger than the max size
+	ErrTooLarge = errors.New("[upload] request body is too large")
+
+	// ErrTypeNotAllowed the type of the file is not allowed
+	ErrTypeNotAllowed = errors.New("[upload] file type is not allowed")
+
+	fileHeaderType  = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeadersType = reflect.TypeOf([]*multipart.FileHeader(nil))
+)
+
+// Properties is the properties of the multipart uploads
+type Properties struct {
+	// MaxSize is the max size in bytes of the request body, 0 means unlimited
+	MaxSize int64 `json:"max_size" default:"33554432"`
+	// MaxMemory is the max size in bytes of the files that are held in memory, the larger files are streamed to the temp dir
+	MaxMemory int64 `json:"max_memory" default:"10485760"`
+	// AllowedTypes are the mime types of the files that are allowed, e.g. image/png or image/*, empty means any type
+	AllowedTypes []string `json:"allowed_types"`
+}
+
+// Parse parses the multipart form of the request within the max size, the form is parsed only once,
+// so the middlewares that read the form fields should parse it by Parse as well
+func Parse(w http.ResponseWriter, r *http.Request, p *Properties) (err error) {
+	if r.MultipartForm != nil {
+		return
+	}
+	if p.MaxSize > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, p.MaxSize)
+	}
+	if err = r.ParseMultipartForm(p.MaxMemory); err != nil {
+		if strings.Contains(err.Error(), "request body too large") {
+			err = ErrTooLarge
+		}
+	}
+	return
+}
+
+// RemoveAll removes the temp files of the multipart form of the request if it is parsed
+func RemoveAll(r *http.Request) {
+	if r.MultipartForm != nil {
+		r.MultipartForm.RemoveAll()
+	}
+}
+
+// Bind parses the multipart form of the request and sets the *multipart.FileHeader and []*multipart.FileHeader fields of data,
+// the files are looked up by the form tag or the field name, the accept tag overrides the allowed types of the field
+func Bind(w http.ResponseWriter, r *http.Request, p *Properties, data interface{}) (err error) {
+	if err = Parse(w, r, p); err != nil {
+		return
+	}
+
+	val := reflect.Indirect(reflect.ValueOf(data))
+	if val.Kind() != reflect.Struct {
+		return
+	}
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.Type != fileHeaderType && field.Type != fileHeadersType {
+			continue
+		}
+		name := field.Tag.Get("form")
+		if name == "" {
+			name = field.Name
+		}
+		files := lookup(r.MultipartForm, name)
+		if len(files) == 0 {
+			continue
+		}
+		allowedTypes := p.AllowedTypes
+		if accept, ok := field.Tag.Lookup("accept"); ok {
+			allowedTypes = strings.Split(accept, ",")
+		}
+		for _, file := range files {
+			if err = checkType(file, allowedTypes); err != nil {
+				return
+			}
+		}
+		if field.Type == fileHeaderType {
+			val.Field(i).Set(reflect.ValueOf(files[0]))
+		} else {
+			val.Field(i).Set(reflect.ValueOf(files))
+		}
+	}
+	return
+}
+
+// lookup returns the files of the name, the name is matched case-insensitively if it is not found
+func lookup(form *multipart.Form, name string) []*multipart.FileHeader {
+	if files, ok := form.File[name]; ok {
+		return files
+	}
+	for key, files := range form.File {
+		if strings.EqualFold(key, name) {
+			return files
+		}
+	}
+	return nil
+}
+
+// checkType checks the mime type of the file
+func checkType(file *multipart.FileHeader, allowedTypes []string) (err error) {
+	if len(allowedTypes) == 0 {
+		return
+	}
+	mimeType, err := ContentType(file)
+	if err != nil {
+		return
+	}
+	for _, allowed := range allowedTypes {
+		allowed = strings.TrimSpace(strings.ToLower(allowed))
+		if allowed == mimeType || allowed == "*/*" ||
+			(strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mimeType, allowed[:len(allowed)-1])) {
+			return nil
+		}
+	}
+	return ErrTypeNotAllowed
+}
+
+// ContentType returns the mime type of the file without the parameters, e.g. image/png,
+// it is detected by the content of the file, the declared content type is used only if the content is not recognized,
+// so that a text file is not able to be uploaded as an image
+func ContentType(file *multipart.FileHeader) (mimeType string, err error) {
+	f, err := file.Open()
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	buf := make([]byte, sniffLen)
+	n, _ := f.Read(buf)
+	mimeType = mediaType(http.DetectContentType(buf[:n]))
+	declared := mediaType(file.Header.Get("Content-Type"))
+	switch {
+	case declared == "":
+	case mimeType == "application/octet-stream":
+		mimeType = declared
+	case mimeType == "text/plain" && (strings.HasPrefix(declared, "text/") || strings.HasPrefix(declared, "application/")):
+		mimeType = declared
+	}
+	return
+}
+
+func mediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(mediaType)
+}
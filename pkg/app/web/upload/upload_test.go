@@ -0,0 +1,131 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package upload
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+)
+
+var png = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+type part struct {
+	field       string
+	filename    string
+	contentType string
+	content     []byte
+}
+
+func newRequest(t *testing.T, fields map[string]string, parts ...part) *http.Request {
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	for name, value := range fields {
+		assert.Equal(t, nil, writer.WriteField(name, value))
+	}
+	for _, p := range parts {
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", `form-data; name="`+p.field+`"; filename="`+p.filename+`"`)
+		header.Set("Content-Type", p.contentType)
+		w, err := writer.CreatePart(header)
+		assert.Equal(t, nil, err)
+		w.Write(p.content)
+	}
+	assert.Equal(t, nil, writer.Close())
+	r := httptest.NewRequest(http.MethodPost, "/upload", body)
+	r.Header.Set("Content-Type", writer.FormDataContentType())
+	return r
+}
+
+type avatarRequest struct {
+	Name   string
+	Avatar *multipart.FileHeader   `form:"avatar" accept:"image/*"`
+	Photos []*multipart.FileHeader `form:"photos"`
+	Report *multipart.FileHeader
+}
+
+func TestBind(t *testing.T) {
+	p := &Properties{MaxSize: 1024, MaxMemory: 16, AllowedTypes: []string{"image/png", "text/csv"}}
+
+	t.Run("should bind the files", func(t *testing.T) {
+		r := newRequest(t, map[string]string{"name": "johnd"},
+			part{"avatar", "me.png", "image/png", png},
+			part{"photos", "a.png", "image/png", png},
+			part{"photos", "b.png", "application/octet-stream", png},
+			part{"Report", "report.csv", "text/csv", []byte("a,b\n1,2\n")},
+		)
+		req := new(avatarRequest)
+		err := Bind(httptest.NewRecorder(), r, p, req)
+		assert.Equal(t, nil, err)
+		defer r.MultipartForm.RemoveAll()
+		assert.Equal(t, "me.png", req.Avatar.Filename)
+		assert.Equal(t, 2, len(req.Photos))
+		assert.Equal(t, "b.png", req.Photos[1].Filename)
+		assert.Equal(t, "report.csv", req.Report.Filename)
+
+		f, err := req.Avatar.Open()
+		assert.Equal(t, nil, err)
+		defer f.Close()
+		buf := make([]byte, len(png))
+		f.Read(buf)
+		assert.Equal(t, png, buf)
+	})
+
+	t.Run("should not bind the missing files", func(t *testing.T) {
+		r := newRequest(t, map[string]string{"name": "johnd"})
+		req := new(avatarRequest)
+		err := Bind(httptest.NewRecorder(), r, p, req)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, (*multipart.FileHeader)(nil), req.Avatar)
+		assert.Equal(t, 0, len(req.Photos))
+	})
+
+	t.Run("should reject the file that is disguised as an image", func(t *testing.T) {
+		r := newRequest(t, nil, part{"avatar", "me.png", "image/png", []byte("<script>alert(1)</script>")})
+		err := Bind(httptest.NewRecorder(), r, p, new(avatarRequest))
+		assert.Equal(t, ErrTypeNotAllowed, err)
+	})
+
+	t.Run("should reject the type that is not allowed", func(t *testing.T) {
+		r := newRequest(t, nil, part{"photos", "a.gif", "image/gif", []byte("GIF89a......")})
+		err := Bind(httptest.NewRecorder(), r, p, new(avatarRequest))
+		assert.Equal(t, ErrTypeNotAllowed, err)
+	})
+
+	t.Run("should reject the request that is too large", func(t *testing.T) {
+		r := newRequest(t, nil, part{"photos", "a.png", "image/png", append(png, make([]byte, 2048)...)})
+		err := Bind(httptest.NewRecorder(), r, p, new(avatarRequest))
+		assert.Equal(t, ErrTooLarge, err)
+	})
+
+	t.Run("should allow any type by default", func(t *testing.T) {
+		r := newRequest(t, nil, part{"Report", "a.bin", "application/zip", []byte("PK\x03\x04")})
+		req := new(avatarRequest)
+		err := Bind(httptest.NewRecorder(), r, &Properties{MaxMemory: 16}, req)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "a.bin", req.Report.Filename)
+	})
+
+	t.Run("should not bind the request that is not multipart", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/upload", bytes.NewBufferString("{}"))
+		r.Header.Set("Content-Type", "application/json")
+		err := Bind(httptest.NewRecorder(), r, p, new(avatarRequest))
+		assert.Equal(t, http.ErrNotMultipart, err)
+	})
+}
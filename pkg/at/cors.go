@@ -0,0 +1,23 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package at
+
+// Cors is the annotation that overrides the global cors policy for a controller, e.g.
+//	type fooController struct {
+//		at.RestController
+//		at.Cors `allowed_origins:"https://*.example.com" allowed_methods:"GET,POST" allow_credentials:"true" max_age:"600"`
+//	}
+// the supported tags are allowed_origins, allowed_methods, allowed_headers, exposed_headers, allow_credentials and max_age
+type Cors interface{}
@@ -0,0 +1,24 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package at
+
+// GrpcInterceptor is the annotation for the grpc interceptor component, the component implements one or more of
+// grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor, grpc.UnaryClientInterceptor and grpc.StreamClientInterceptor
+// interfaces that defined in grpc starter, e.g.
+//	type authInterceptor struct {
+//		at.GrpcInterceptor `order:"1"`
+//	}
+// the interceptors are chained in ascending order, the ones with the same order are chained in declared order
+type GrpcInterceptor interface{}
@@ -6,5 +6,8 @@ type RequestBody interface{}
 // RequestForm the annotation RequestForm
 type RequestForm struct{}
 
+// RequestFile the annotation RequestFile, the *multipart.FileHeader and []*multipart.FileHeader fields are bound to the uploaded files
+type RequestFile struct{}
+
 // RequestParams the annotation RequestParams
 type RequestParams interface{}
@@ -0,0 +1,154 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"context"
+	"github.com/kataras/golog"
+	"strings"
+)
+
+const (
+	// RequestIDKey is the well-known context key of the request id, it is also used as
+	// the http header and the grpc metadata that propagate the request id
+	RequestIDKey = "x-request-id"
+)
+
+type contextKey struct{}
+
+type contextEntry struct {
+	key   string
+	value string
+}
+
+// contextEntries return the diagnostic context values that carried by ctx
+func contextEntries(ctx context.Context) (entries []contextEntry) {
+	if ctx != nil {
+		entries, _ = ctx.Value(contextKey{}).([]contextEntry)
+	}
+	return
+}
+
+// NewContext returns a copy of ctx that carries the diagnostic context value, e.g. the request id,
+// the values are prefixed to the log messages emitted by the logger that returned by WithContext
+func NewContext(ctx context.Context, key, value string) context.Context {
+	entries := contextEntries(ctx)
+	// the entries of the parent context are copied as they may be shared by other requests
+	newEntries := make([]contextEntry, 0, len(entries)+1)
+	found := false
+	for _, e := range entries {
+		if e.key == key {
+			e.value = value
+			found = true
+		}
+		newEntries = append(newEntries, e)
+	}
+	if !found {
+		newEntries = append(newEntries, contextEntry{key: key, value: value})
+	}
+	return context.WithValue(ctx, contextKey{}, newEntries)
+}
+
+// FromContext get the diagnostic context value that carried by ctx
+func FromContext(ctx context.Context, key string) (value string) {
+	for _, e := range contextEntries(ctx) {
+		if e.key == key {
+			value = e.value
+			break
+		}
+	}
+	return
+}
+
+// ContextItems return all the diagnostic context values that carried by ctx
+func ContextItems(ctx context.Context) (items map[string]string) {
+	items = make(map[string]string)
+	for _, e := range contextEntries(ctx) {
+		items[e.key] = e.value
+	}
+	return
+}
+
+// Logger prefixes the log messages with the diagnostic context values, e.g. [f81d4fae]
+type Logger struct {
+	prefix string
+}
+
+// WithContext returns the logger that prefixes the log messages with the diagnostic context values of ctx
+func WithContext(ctx context.Context) *Logger {
+	var sb strings.Builder
+	for _, e := range contextEntries(ctx) {
+		sb.WriteString("[")
+		sb.WriteString(e.value)
+		sb.WriteString("] ")
+	}
+	return &Logger{prefix: sb.String()}
+}
+
+// withContext prepend the context prefix to the log message
+func (l *Logger) withContext(v []interface{}) []interface{} {
+	if l.prefix != "" {
+		v = append([]interface{}{l.prefix}, v...)
+	}
+	return v
+}
+
+// withContextf prepend the context prefix to the log format
+func (l *Logger) withContextf(format string) string {
+	if l.prefix != "" {
+		format = strings.Replace(l.prefix, "%", "%%", -1) + format
+	}
+	return format
+}
+
+// Error will print only when logger's Level is error, warn, info or debug.
+func (l *Logger) Error(v ...interface{}) {
+	withCaller(golog.Error, l.withContext(v)...)
+}
+
+// Errorf will print only when logger's Level is error, warn, info or debug.
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	withCallerf(golog.Errorf, l.withContextf(format), args...)
+}
+
+// Warn will print when logger's Level is warn, info or debug.
+func (l *Logger) Warn(v ...interface{}) {
+	golog.Warn(l.withContext(v)...)
+}
+
+// Warnf will print when logger's Level is warn, info or debug.
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	golog.Warnf(l.withContextf(format), args...)
+}
+
+// Info will print when logger's Level is info or debug.
+func (l *Logger) Info(v ...interface{}) {
+	golog.Info(l.withContext(v)...)
+}
+
+// Infof will print when logger's Level is info or debug.
+func (l *Logger) Infof(format string, args ...interface{}) {
+	golog.Infof(l.withContextf(format), args...)
+}
+
+// Debug will print when logger's Level is debug.
+func (l *Logger) Debug(v ...interface{}) {
+	withCaller(golog.Debug, l.withContext(v)...)
+}
+
+// Debugf will print when logger's Level is debug.
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	withCallerf(golog.Debugf, l.withContextf(format), args...)
+}
@@ -0,0 +1,66 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestContext(t *testing.T) {
+	t.Run("should put and get context value", func(t *testing.T) {
+		ctx := NewContext(context.Background(), RequestIDKey, "foo")
+		assert.Equal(t, "foo", FromContext(ctx, RequestIDKey))
+
+		ctx = NewContext(ctx, RequestIDKey, "bar")
+		assert.Equal(t, "bar", FromContext(ctx, RequestIDKey))
+		assert.Equal(t, map[string]string{RequestIDKey: "bar"}, ContextItems(ctx))
+	})
+
+	t.Run("should not change the parent context", func(t *testing.T) {
+		parent := NewContext(context.Background(), RequestIDKey, "foo")
+		child := NewContext(parent, "user", "bar")
+		assert.Equal(t, map[string]string{RequestIDKey: "foo"}, ContextItems(parent))
+		assert.Equal(t, map[string]string{RequestIDKey: "foo", "user": "bar"}, ContextItems(child))
+	})
+
+	t.Run("should return empty value without context", func(t *testing.T) {
+		assert.Equal(t, "", FromContext(context.Background(), RequestIDKey))
+		assert.Equal(t, "", FromContext(nil, RequestIDKey))
+		assert.Equal(t, "", WithContext(nil).prefix)
+	})
+
+	t.Run("should prefix log message with context value", func(t *testing.T) {
+		var buf bytes.Buffer
+		SetOutput(&buf)
+		defer SetOutput(os.Stdout)
+
+		logger := WithContext(NewContext(context.Background(), RequestIDKey, "f81d4fae"))
+		logger.Info("hello")
+		logger.Infof("hello %v%%", 100)
+		Info("hello world")
+		assert.Contains(t, buf.String(), "[f81d4fae] hello")
+		assert.Contains(t, buf.String(), "[f81d4fae] hello 100%")
+		assert.NotContains(t, buf.String(), "[f81d4fae] hello world")
+	})
+
+	t.Run("should escape percent sign in context value", func(t *testing.T) {
+		logger := WithContext(NewContext(context.Background(), RequestIDKey, "100%"))
+		assert.Equal(t, "[100%%] %v", logger.withContextf("%v"))
+	})
+}
@@ -16,8 +16,11 @@
 package actuator
 
 import (
+	"context"
 	"hidevops.io/hiboot/pkg/app"
 	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/log"
+	"time"
 )
 
 const (
@@ -25,19 +28,41 @@ const (
 	Profile = "actuator"
 )
 
-type Properties struct {
-}
-
 type configuration struct {
 	at.AutoConfiguration
 
-	Properties Properties `mapstructure:"actuator"`
+	Properties         Properties `mapstructure:"actuator"`
+	applicationContext app.ApplicationContext
 }
 
-func newConfiguration() *configuration {
-	return &configuration{}
+func newConfiguration(applicationContext app.ApplicationContext) *configuration {
+	return &configuration{
+		applicationContext: applicationContext,
+	}
 }
 
 func init() {
 	app.Register(newConfiguration)
 }
+
+// ManagementServer create the management server, it is only started when actuator.management.enabled is true,
+// and it is shut down with the application
+func (c *configuration) ManagementServer() *ManagementServer {
+	s := newManagementServer(&c.Properties.Management)
+	if !c.Properties.Management.Enabled {
+		return s
+	}
+	if err := s.Start(); err != nil {
+		log.Errorf("actuator: failed to start management server on %v:%v: %v",
+			c.Properties.Management.Host, c.Properties.Management.Port, err)
+		return s
+	}
+	c.applicationContext.RegisterShutdownHook(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.Shutdown(ctx); err != nil {
+			log.Warnf("actuator: failed to shut down management server: %v", err)
+		}
+	})
+	return s
+}
@@ -2,10 +2,45 @@ package actuator
 
 import (
 	"github.com/stretchr/testify/assert"
+	"hidevops.io/hiboot/pkg/app/fake"
 	"testing"
 )
 
 func TestConfiguration(t *testing.T) {
-	c := newConfiguration()
+	c := newConfiguration(new(fake.ApplicationContext))
 	assert.NotEqual(t, nil, c)
+
+	t.Run("should not start management server by default", func(t *testing.T) {
+		s := c.ManagementServer()
+		assert.NotEqual(t, nil, s)
+		assert.Equal(t, nil, s.Addr())
+	})
+
+	t.Run("should start management server and shut it down with the application", func(t *testing.T) {
+		ac := &shutdownRecorder{}
+		c := newConfiguration(ac)
+		c.Properties.Management = management{Enabled: true, Network: "tcp", Host: "127.0.0.1", Port: "0"}
+		s := c.ManagementServer()
+		assert.NotEqual(t, nil, s.Addr())
+		assert.Equal(t, 1, len(ac.hooks))
+		ac.hooks[0]()
+	})
+
+	t.Run("should not register the shutdown hook if it fails to start", func(t *testing.T) {
+		ac := &shutdownRecorder{}
+		c := newConfiguration(ac)
+		c.Properties.Management = management{Enabled: true, Network: "invalid", Host: "127.0.0.1", Port: "0"}
+		s := c.ManagementServer()
+		assert.Equal(t, nil, s.Addr())
+		assert.Equal(t, 0, len(ac.hooks))
+	})
+}
+
+type shutdownRecorder struct {
+	fake.ApplicationContext
+	hooks []func()
+}
+
+func (a *shutdownRecorder) RegisterShutdownHook(hook func()) {
+	a.hooks = append(a.hooks, hook)
 }
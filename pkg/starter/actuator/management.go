@@ -0,0 +1,141 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actuator
+
+import (
+	"context"
+	"encoding/json"
+	"hidevops.io/hiboot/pkg/log"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	rtpprof "runtime/pprof"
+	"strings"
+)
+
+// HeapSummary is the summary of the heap memory statistics
+type HeapSummary struct {
+	Alloc        uint64 `json:"alloc"`
+	TotalAlloc   uint64 `json:"total_alloc"`
+	Sys          uint64 `json:"sys"`
+	HeapAlloc    uint64 `json:"heap_alloc"`
+	HeapSys      uint64 `json:"heap_sys"`
+	HeapIdle     uint64 `json:"heap_idle"`
+	HeapInuse    uint64 `json:"heap_inuse"`
+	HeapReleased uint64 `json:"heap_released"`
+	HeapObjects  uint64 `json:"heap_objects"`
+	NumGC        uint32 `json:"num_gc"`
+	NumGoroutine int    `json:"num_goroutine"`
+}
+
+// ManagementServer serves the management endpoints (pprof, goroutine dump and heap summary)
+// on a separate listener, so that they are not exposed on the public api port
+type ManagementServer struct {
+	properties *management
+	mux        *http.ServeMux
+	server     *http.Server
+	listener   net.Listener
+}
+
+func newManagementServer(properties *management) *ManagementServer {
+	s := &ManagementServer{
+		properties: properties,
+		mux:        http.NewServeMux(),
+	}
+
+	contextPath := strings.TrimSuffix(properties.ContextPath, "/")
+	if properties.Pprof {
+		s.mux.Handle(contextPath+"/pprof/", s.pprofHandler(contextPath+"/pprof/"))
+		s.mux.HandleFunc(contextPath+"/pprof/cmdline", pprof.Cmdline)
+		s.mux.HandleFunc(contextPath+"/pprof/profile", pprof.Profile)
+		s.mux.HandleFunc(contextPath+"/pprof/symbol", pprof.Symbol)
+		s.mux.HandleFunc(contextPath+"/pprof/trace", pprof.Trace)
+	}
+	s.mux.HandleFunc(contextPath+"/goroutines", s.goroutines)
+	s.mux.HandleFunc(contextPath+"/heap", s.heap)
+
+	s.server = &http.Server{Handler: s.mux}
+	return s
+}
+
+// pprofHandler serves the named profiles, pprof.Index only recognize the hardcoded /debug/pprof/ prefix
+func (s *ManagementServer) pprofHandler(prefix string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, prefix)
+		if name != "" {
+			pprof.Handler(name).ServeHTTP(w, r)
+			return
+		}
+		pprof.Index(w, r)
+	})
+}
+
+// goroutines dump the stack traces of all current goroutines
+func (s *ManagementServer) goroutines(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	rtpprof.Lookup("goroutine").WriteTo(w, 2)
+}
+
+// heap write the heap summary as json
+func (s *ManagementServer) heap(w http.ResponseWriter, r *http.Request) {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	summary := &HeapSummary{
+		Alloc:        m.Alloc,
+		TotalAlloc:   m.TotalAlloc,
+		Sys:          m.Sys,
+		HeapAlloc:    m.HeapAlloc,
+		HeapSys:      m.HeapSys,
+		HeapIdle:     m.HeapIdle,
+		HeapInuse:    m.HeapInuse,
+		HeapReleased: m.HeapReleased,
+		HeapObjects:  m.HeapObjects,
+		NumGC:        m.NumGC,
+		NumGoroutine: runtime.NumGoroutine(),
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(summary)
+}
+
+// ServeHTTP implements http.Handler
+func (s *ManagementServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Start start listening on the management address
+func (s *ManagementServer) Start() (err error) {
+	address := s.properties.Host + ":" + s.properties.Port
+	s.listener, err = net.Listen(s.properties.Network, address)
+	if err != nil {
+		return
+	}
+	go s.server.Serve(s.listener)
+	log.Infof("Management server listening on: %v", s.listener.Addr())
+	return
+}
+
+// Addr return the address that the management server is listening on, it is nil before Start
+func (s *ManagementServer) Addr() (addr net.Addr) {
+	if s.listener != nil {
+		addr = s.listener.Addr()
+	}
+	return
+}
+
+// Shutdown gracefully shuts down the management server
+func (s *ManagementServer) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
@@ -0,0 +1,84 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actuator
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestManagementServer(t *testing.T) {
+	prop := &management{
+		Network:     "tcp",
+		Host:        "127.0.0.1",
+		Port:        "0",
+		ContextPath: "/debug",
+		Pprof:       true,
+	}
+	s := newManagementServer(prop)
+
+	t.Run("should serve pprof index", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("should serve named profile", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/pprof/heap?debug=1", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("should dump goroutines", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/goroutines", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "goroutine")
+	})
+
+	t.Run("should get heap summary", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/heap", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "heap_alloc")
+	})
+
+	t.Run("should serve on the management listener", func(t *testing.T) {
+		err := s.Start()
+		assert.Equal(t, nil, err)
+		defer s.Shutdown(context.Background())
+
+		resp, err := http.Get(fmt.Sprintf("http://%v/debug/heap", s.Addr()))
+		assert.Equal(t, nil, err)
+		if err == nil {
+			defer resp.Body.Close()
+			body, _ := ioutil.ReadAll(resp.Body)
+			assert.Equal(t, true, strings.Contains(string(body), "num_goroutine"))
+		}
+	})
+
+	t.Run("should not mount pprof if it is disabled", func(t *testing.T) {
+		s := newManagementServer(&management{ContextPath: "/debug"})
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
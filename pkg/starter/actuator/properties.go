@@ -0,0 +1,36 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actuator
+
+type management struct {
+	// Enabled set to true to start the management server
+	Enabled bool `json:"enabled" default:"false"`
+	// The network must be "tcp", "tcp4", "tcp6" or "unix"
+	Network string `json:"network" default:"tcp"`
+	// Host is the host that management server bind to, e.g. 127.0.0.1
+	Host string `json:"host" default:"127.0.0.1"`
+	// Port is the management server port, it should be different from server.port
+	Port string `json:"port" default:"7070"`
+	// ContextPath is the context path of all management endpoints
+	ContextPath string `json:"context_path" default:"/debug"`
+	// Pprof set to true to mount the net/http/pprof handlers
+	Pprof bool `json:"pprof" default:"true"`
+}
+
+// Properties the actuator properties
+type Properties struct {
+	// Management is the properties of the management server
+	Management management `json:"management"`
+}
@@ -0,0 +1,90 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compress_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"hidevops.io/hiboot/pkg/app/web"
+	"hidevops.io/hiboot/pkg/app/web/sse"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/model"
+	_ "hidevops.io/hiboot/pkg/starter/compress"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type fooController struct {
+	at.RestController
+}
+
+func newFooController() *fooController {
+	return &fooController{}
+}
+
+// GetLarge GET /foo/large
+func (c *fooController) GetLarge() (response model.Response) {
+	response = new(model.BaseResponse)
+	response.SetData(strings.Repeat("hello ", 1000))
+	return
+}
+
+// GetSmall GET /foo/small
+func (c *fooController) GetSmall() string {
+	return "small"
+}
+
+// GetEvents GET /foo/events
+func (c *fooController) GetEvents() <-chan sse.Event {
+	events := make(chan sse.Event, 1)
+	events <- sse.Event{Data: strings.Repeat("hello ", 1000)}
+	close(events)
+	return events
+}
+
+func TestCompress(t *testing.T) {
+	testApp := web.NewTestApp(newFooController).Run(t)
+
+	t.Run("should compress the json response", func(t *testing.T) {
+		resp := testApp.Get("/foo/large").
+			WithHeader("Accept-Encoding", "gzip").
+			Expect().Status(http.StatusOK)
+		resp.Header("Content-Encoding").Equal("gzip")
+		resp.Header("Vary").Equal("Accept-Encoding")
+		assert.Equal(t, true, len(resp.Body().Raw()) < 6000)
+	})
+
+	t.Run("should not compress without accepted encoding", func(t *testing.T) {
+		testApp.Get("/foo/large").
+			Expect().Status(http.StatusOK).
+			Header("Content-Encoding").Empty()
+	})
+
+	t.Run("should not compress the small response", func(t *testing.T) {
+		testApp.Get("/foo/small").
+			WithHeader("Accept-Encoding", "gzip").
+			Expect().Status(http.StatusOK).
+			Header("Content-Encoding").Empty()
+	})
+
+	t.Run("should not compress the event stream", func(t *testing.T) {
+		resp := testApp.Get("/foo/events").
+			WithHeader("Accept-Encoding", "gzip").
+			Expect().Status(http.StatusOK)
+		resp.Header("Content-Encoding").Empty()
+		resp.Header("Content-Type").Contains(sse.ContentType)
+		resp.Body().Contains("data: hello hello")
+	})
+}
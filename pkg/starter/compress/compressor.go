@@ -0,0 +1,162 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compress
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"github.com/andybalholm/brotli"
+	"hidevops.io/hiboot/pkg/utils/str"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Gzip is the gzip encoding
+	Gzip = "gzip"
+	// Deflate is the deflate encoding
+	Deflate = "deflate"
+	// Brotli is the brotli encoding
+	Brotli = "br"
+
+	eventStream = "text/event-stream"
+)
+
+// encoders create the writers of the encodings with the level
+var encoders = map[string]func(w io.Writer, level int) (io.WriteCloser, error){
+	Gzip: func(w io.Writer, level int) (io.WriteCloser, error) {
+		return gzip.NewWriterLevel(w, level)
+	},
+	Deflate: func(w io.Writer, level int) (io.WriteCloser, error) {
+		return flate.NewWriter(w, level)
+	},
+	Brotli: func(w io.Writer, level int) (io.WriteCloser, error) {
+		return brotli.NewWriterLevel(w, level), nil
+	},
+}
+
+// Compressor negotiates the encoding of the request and compresses the response body
+type Compressor struct {
+	properties *Properties
+}
+
+// NewCompressor create the compressor
+func NewCompressor(p *Properties) *Compressor {
+	return &Compressor{properties: p}
+}
+
+// Negotiate returns the encoding that the client accepts with the highest quality, it is empty if the response must not be compressed,
+// e.g. the websocket upgrades, the server-sent events are decided by the response as they are streamed or of the event stream type
+func (c *Compressor) Negotiate(r *http.Request) (encoding string) {
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") ||
+		c.excluded(r.URL.Path) {
+		return
+	}
+
+	accepted := make(map[string]float64)
+	for _, value := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, q := parseQuality(value)
+		accepted[name] = q
+	}
+	// the preferred encoding of the server wins if the client accepts more than one with the same quality
+	quality := 0.0
+	for _, supported := range c.properties.Encodings {
+		q, ok := accepted[supported]
+		if !ok {
+			q, ok = accepted["*"]
+		}
+		if ok && q > quality {
+			encoding, quality = supported, q
+		}
+	}
+	return
+}
+
+// parseQuality parses the encoding and its quality, e.g. gzip;q=0.8
+func parseQuality(accepted string) (name string, q float64) {
+	parts := strings.Split(accepted, ";")
+	name = strings.ToLower(strings.TrimSpace(parts[0]))
+	q = 1
+	for _, param := range parts[1:] {
+		param = strings.TrimSpace(param)
+		if strings.HasPrefix(param, "q=") {
+			if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+				q = v
+			}
+		}
+	}
+	return
+}
+
+func (c *Compressor) excluded(path string) bool {
+	for _, excluded := range c.properties.ExcludedPaths {
+		if str.MatchPath(excluded, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Compress compresses the body with the negotiated encoding and sets the headers,
+// the body is not compressed if it is smaller than the min size, its content type is not allowed, or it is already encoded
+func (c *Compressor) Compress(header http.Header, status int, body []byte, encoding string) (compressed []byte, ok bool, err error) {
+	encoder, supported := encoders[encoding]
+	if !supported ||
+		status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified ||
+		len(body) < c.properties.MinSize ||
+		header.Get("Content-Encoding") != "" ||
+		!c.allowed(header.Get("Content-Type")) {
+		return body, false, nil
+	}
+
+	buf := new(bytes.Buffer)
+	w, err := encoder(buf, c.properties.Level)
+	if err == nil {
+		if _, err = w.Write(body); err == nil {
+			err = w.Close()
+		}
+	}
+	if err != nil {
+		return body, false, err
+	}
+
+	header.Set("Content-Encoding", encoding)
+	header.Add("Vary", "Accept-Encoding")
+	header.Del("Content-Length")
+	// the strong etag of the identity body does not match the encoded body
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		header.Set("ETag", "W/"+etag)
+	}
+	return buf.Bytes(), true, nil
+}
+
+// allowed checks if the content type is allowed to be compressed
+func (c *Compressor) allowed(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == eventStream {
+		return false
+	}
+	for _, allowed := range c.properties.MimeTypes {
+		allowed = strings.TrimSpace(allowed)
+		if allowed == mediaType || (strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mediaType, allowed[:len(allowed)-1])) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compress
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newProperties() *Properties {
+	return &Properties{
+		Encodings:     []string{Brotli, Gzip, Deflate},
+		Level:         6,
+		MinSize:       16,
+		MimeTypes:     []string{"text/*", "application/json"},
+		ExcludedPaths: []string{"/download/*"},
+	}
+}
+
+func negotiate(c *Compressor, path string, headers ...string) string {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	for i := 0; i+1 < len(headers); i += 2 {
+		r.Header.Set(headers[i], headers[i+1])
+	}
+	return c.Negotiate(r)
+}
+
+func TestNegotiate(t *testing.T) {
+	c := NewCompressor(newProperties())
+
+	t.Run("should prefer the encoding of the server", func(t *testing.T) {
+		assert.Equal(t, Brotli, negotiate(c, "/", "Accept-Encoding", "gzip, deflate, br"))
+	})
+
+	t.Run("should negotiate the encoding with the highest quality", func(t *testing.T) {
+		assert.Equal(t, Gzip, negotiate(c, "/", "Accept-Encoding", "br;q=0.5, gzip;q=0.8, deflate;q=0.2"))
+		assert.Equal(t, Deflate, negotiate(c, "/", "Accept-Encoding", "br;q=0, gzip;q=0, deflate"))
+	})
+
+	t.Run("should negotiate the wildcard", func(t *testing.T) {
+		assert.Equal(t, Brotli, negotiate(c, "/", "Accept-Encoding", "*"))
+		assert.Equal(t, Gzip, negotiate(c, "/", "Accept-Encoding", "br;q=0, *"))
+	})
+
+	t.Run("should not compress without accepted encoding", func(t *testing.T) {
+		assert.Equal(t, "", negotiate(c, "/"))
+		assert.Equal(t, "", negotiate(c, "/", "Accept-Encoding", "identity"))
+	})
+
+	t.Run("should not compress the websocket upgrades", func(t *testing.T) {
+		assert.Equal(t, "", negotiate(c, "/ws", "Accept-Encoding", "gzip", "Upgrade", "websocket"))
+	})
+
+	t.Run("should not compress the excluded paths", func(t *testing.T) {
+		assert.Equal(t, "", negotiate(c, "/download/file.zip", "Accept-Encoding", "gzip"))
+	})
+}
+
+func decode(t *testing.T, encoding string, data []byte) string {
+	var r io.Reader
+	var err error
+	switch encoding {
+	case Gzip:
+		r, err = gzip.NewReader(bytes.NewReader(data))
+	case Deflate:
+		r = flate.NewReader(bytes.NewReader(data))
+	case Brotli:
+		r = brotli.NewReader(bytes.NewReader(data))
+	}
+	assert.Equal(t, nil, err)
+	decoded, err := ioutil.ReadAll(r)
+	assert.Equal(t, nil, err)
+	return string(decoded)
+}
+
+func TestCompress(t *testing.T) {
+	c := NewCompressor(newProperties())
+	body := []byte(`{"message":"` + strings.Repeat("hello ", 100) + `"}`)
+
+	for _, encoding := range []string{Brotli, Gzip, Deflate} {
+		t.Run("should compress the body with "+encoding, func(t *testing.T) {
+			header := http.Header{"Content-Type": {"application/json; charset=UTF-8"}, "Content-Length": {"607"}, "Etag": {`"v1"`}}
+			compressed, ok, err := c.Compress(header, http.StatusOK, body, encoding)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, true, ok)
+			assert.Equal(t, true, len(compressed) < len(body))
+			assert.Equal(t, string(body), decode(t, encoding, compressed))
+			assert.Equal(t, encoding, header.Get("Content-Encoding"))
+			assert.Equal(t, "Accept-Encoding", header.Get("Vary"))
+			assert.Equal(t, "", header.Get("Content-Length"))
+			assert.Equal(t, `W/"v1"`, header.Get("ETag"))
+		})
+	}
+
+	t.Run("should not compress the small body", func(t *testing.T) {
+		header := http.Header{"Content-Type": {"text/plain"}}
+		_, ok, _ := c.Compress(header, http.StatusOK, []byte("hi"), Gzip)
+		assert.Equal(t, false, ok)
+		assert.Equal(t, "", header.Get("Content-Encoding"))
+	})
+
+	t.Run("should not compress the content type that is not allowed", func(t *testing.T) {
+		_, ok, _ := c.Compress(http.Header{"Content-Type": {"image/png"}}, http.StatusOK, body, Gzip)
+		assert.Equal(t, false, ok)
+		_, ok, _ = c.Compress(http.Header{"Content-Type": {"text/event-stream"}}, http.StatusOK, body, Gzip)
+		assert.Equal(t, false, ok)
+		_, ok, _ = c.Compress(http.Header{}, http.StatusOK, body, Gzip)
+		assert.Equal(t, false, ok)
+	})
+
+	t.Run("should not compress the encoded body", func(t *testing.T) {
+		_, ok, _ := c.Compress(http.Header{"Content-Type": {"text/plain"}, "Content-Encoding": {"gzip"}}, http.StatusOK, body, Gzip)
+		assert.Equal(t, false, ok)
+	})
+
+	t.Run("should not compress the response without body", func(t *testing.T) {
+		_, ok, _ := c.Compress(http.Header{"Content-Type": {"text/plain"}}, http.StatusNotModified, body, Gzip)
+		assert.Equal(t, false, ok)
+	})
+
+	t.Run("should not compress with the unknown encoding", func(t *testing.T) {
+		_, ok, _ := c.Compress(http.Header{"Content-Type": {"text/plain"}}, http.StatusOK, body, "zstd")
+		assert.Equal(t, false, ok)
+	})
+
+	t.Run("should report the invalid level", func(t *testing.T) {
+		p := newProperties()
+		p.Level = 42
+		_, ok, err := NewCompressor(p).Compress(http.Header{"Content-Type": {"text/plain"}}, http.StatusOK, body, Gzip)
+		assert.Equal(t, false, ok)
+		assert.NotEqual(t, nil, err)
+	})
+}
@@ -0,0 +1,56 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compress
+
+import (
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/log"
+)
+
+// Middleware compresses the response bodies, the response is recorded until the handlers return
+type Middleware struct {
+	compressor *Compressor
+}
+
+// NewMiddleware create the compress middleware
+func NewMiddleware(p *Properties) *Middleware {
+	return &Middleware{compressor: NewCompressor(p)}
+}
+
+// Serve the middleware's action
+func (m *Middleware) Serve(ctx context.Context) {
+	encoding := m.compressor.Negotiate(ctx.Request())
+	if encoding == "" {
+		ctx.Next()
+		return
+	}
+
+	ctx.Record()
+	ctx.Next()
+
+	recorder, ok := ctx.IsRecording()
+	if !ok {
+		// the handler streamed the response to the client, e.g. the server-sent events
+		return
+	}
+	body, ok, err := m.compressor.Compress(recorder.Header(), recorder.StatusCode(), recorder.Body(), encoding)
+	if err != nil {
+		log.Warnf("compress: failed to compress the response of %v: %v", ctx.Path(), err)
+		return
+	}
+	if ok {
+		recorder.SetBody(body)
+	}
+}
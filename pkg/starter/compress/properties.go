@@ -0,0 +1,29 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compress
+
+// Properties the compress properties
+type Properties struct {
+	// Encodings are the supported encodings in the order of preference if the client accepts them equally, br, gzip or deflate
+	Encodings []string `json:"encodings" default:"br,gzip,deflate"`
+	// Level is the compression level, 1 (best speed) to 9 (best compression) for gzip and deflate, 0 to 11 for br
+	Level int `json:"level" default:"6"`
+	// MinSize is the min size in bytes of the response body that is compressed
+	MinSize int `json:"min_size" default:"1024"`
+	// MimeTypes are the content types of the responses that are compressed, e.g. text/* or application/json
+	MimeTypes []string `json:"mime_types" default:"text/*,application/json,application/javascript,application/xml,image/svg+xml"`
+	// ExcludedPaths are the paths that are not compressed, the trailing * matches any path with the prefix, e.g. /download/*
+	ExcludedPaths []string `json:"excluded_paths"`
+}
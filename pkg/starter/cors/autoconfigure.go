@@ -0,0 +1,57 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cors provides the hiboot starter for injectable cors (Cross-Origin Resource Sharing) middleware
+package cors
+
+import (
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/app/web"
+	"hidevops.io/hiboot/pkg/at"
+)
+
+const (
+	// Profile is the profile of cors, it should be as same as the package name
+	Profile = "cors"
+)
+
+type configuration struct {
+	at.AutoConfiguration
+
+	Properties         Properties `mapstructure:"cors"`
+	applicationContext app.ApplicationContext
+}
+
+func init() {
+	app.Register(newConfiguration)
+}
+
+func newConfiguration(applicationContext app.ApplicationContext) *configuration {
+	return &configuration{
+		applicationContext: applicationContext,
+	}
+}
+
+// Middleware answers the preflight requests and writes the cors headers, it is used ahead of the controllers
+// so that it takes effect before the jwt middleware, the OPTIONS routes are mapped as the controllers are registered
+func (c *configuration) Middleware(dispatcher *web.Dispatcher) *Middleware {
+	mw := NewMiddleware(&c.Properties)
+
+	c.applicationContext.Use(mw.Serve)
+	dispatcher.AddRouteListener(func(routes []web.Route) {
+		mw.AddRoutes(routes, c.applicationContext.Handle)
+	})
+
+	return mw
+}
@@ -0,0 +1,124 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cors_test
+
+import (
+	"hidevops.io/hiboot/pkg/app/web"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/log"
+	_ "hidevops.io/hiboot/pkg/starter/cors"
+	_ "hidevops.io/hiboot/pkg/starter/jwt"
+	"net/http"
+	"testing"
+)
+
+type fooController struct {
+	at.RestController
+}
+
+func newFooController() *fooController {
+	return &fooController{}
+}
+
+func (c *fooController) Get() string {
+	return "foo"
+}
+
+type barController struct {
+	at.JwtRestController
+	at.Cors `allowed_origins:"https://*.example.com" allowed_methods:"GET" allow_credentials:"true" max_age:"600"`
+}
+
+func newBarController() *barController {
+	return &barController{}
+}
+
+func (c *barController) Get() string {
+	return "bar"
+}
+
+func (c *barController) AnyPing() string {
+	return "pong"
+}
+
+func init() {
+	log.SetLevel(log.DebugLevel)
+}
+
+func TestCorsMiddleware(t *testing.T) {
+	testApp := web.RunTestApplication(t, newFooController, newBarController)
+
+	t.Run("should not set cors headers for same origin request", func(t *testing.T) {
+		testApp.Get("/foo").
+			Expect().Status(http.StatusOK).
+			Header("Access-Control-Allow-Origin").Empty()
+	})
+
+	t.Run("should set cors headers with global policy", func(t *testing.T) {
+		testApp.Get("/foo").
+			WithHeader("Origin", "https://foo.com").
+			Expect().Status(http.StatusOK).
+			Header("Access-Control-Allow-Origin").Equal("*")
+	})
+
+	t.Run("should pass preflight request with global policy", func(t *testing.T) {
+		testApp.Options("/foo").
+			WithHeader("Origin", "https://foo.com").
+			WithHeader("Access-Control-Request-Method", http.MethodPost).
+			Expect().Status(http.StatusNoContent).
+			Header("Access-Control-Allow-Methods").Equal(http.MethodPost)
+	})
+
+	t.Run("should pass preflight request on jwt controller without token", func(t *testing.T) {
+		testApp.Options("/bar").
+			WithHeader("Origin", "https://api.example.com").
+			WithHeader("Access-Control-Request-Method", http.MethodGet).
+			WithHeader("Access-Control-Request-Headers", "Authorization").
+			Expect().Status(http.StatusNoContent).
+			Header("Access-Control-Max-Age").Equal("600")
+	})
+
+	t.Run("should reject preflight request with the policy of controller", func(t *testing.T) {
+		testApp.Options("/bar").
+			WithHeader("Origin", "https://foo.com").
+			WithHeader("Access-Control-Request-Method", http.MethodGet).
+			Expect().Status(http.StatusForbidden)
+
+		testApp.Options("/bar").
+			WithHeader("Origin", "https://api.example.com").
+			WithHeader("Access-Control-Request-Method", http.MethodDelete).
+			Expect().Status(http.StatusForbidden)
+	})
+
+	t.Run("should map OPTIONS onto the path that does not have Options method", func(t *testing.T) {
+		testApp.Options("/foo").
+			Expect().Status(http.StatusOK).
+			Header("Allow").Contains(http.MethodGet)
+	})
+
+	t.Run("should apply the policy of controller to the route mapped by Any", func(t *testing.T) {
+		testApp.Options("/bar/ping").
+			WithHeader("Origin", "https://foo.com").
+			WithHeader("Access-Control-Request-Method", http.MethodGet).
+			Expect().Status(http.StatusForbidden)
+	})
+
+	t.Run("should set cors headers on unauthorized response", func(t *testing.T) {
+		testApp.Get("/bar").
+			WithHeader("Origin", "https://api.example.com").
+			Expect().Status(http.StatusUnauthorized).
+			Header("Access-Control-Allow-Origin").Equal("https://api.example.com")
+	})
+}
@@ -0,0 +1,149 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cors
+
+import (
+	"hidevops.io/hiboot/pkg/app/web"
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/utils/reflector"
+	"hidevops.io/hiboot/pkg/utils/str"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// Middleware is the cors middleware, it applies the global policy or the policy of the controller
+// that annotated by at.Cors
+type Middleware struct {
+	properties  *Properties
+	policy      *policy
+	controllers map[string]*policy
+	// paths is the controller name of each route path, the policy of the controller is found by the path,
+	// so that it applies to the routes mapped by Any and the OPTIONS routes as well
+	paths map[string]string
+}
+
+// NewMiddleware is the constructor of cors middleware
+func NewMiddleware(properties *Properties) *Middleware {
+	return &Middleware{
+		properties:  properties,
+		policy:      newPolicy(properties),
+		controllers: make(map[string]*policy),
+		paths:       make(map[string]string),
+	}
+}
+
+// AddRoutes record the controller of the routes and map OPTIONS onto the paths that do not have it,
+// so that the preflight requests are able to pass through the middleware, e.g. before the jwt middleware
+func (m *Middleware) AddRoutes(routes []web.Route, handle func(method, path string, handlers ...context.Handler)) {
+	var paths []string
+	methods := make(map[string][]string)
+	for _, route := range routes {
+		if _, ok := methods[route.Path]; !ok {
+			paths = append(paths, route.Path)
+		}
+		methods[route.Path] = append(methods[route.Path], route.Method)
+		m.paths[route.Path] = route.Controller
+	}
+
+	for _, path := range paths {
+		if str.InSlice(http.MethodOptions, methods[path]) {
+			continue
+		}
+		allow := strings.Join(append(methods[path], http.MethodOptions), ", ")
+		handle(http.MethodOptions, path, func(ctx context.Context) {
+			ctx.Header("Allow", allow)
+			ctx.StatusCode(http.StatusOK)
+		})
+	}
+}
+
+// controllerName return the full name of the controller that is same as web.Route.Controller
+func controllerName(controller interface{}) string {
+	typ := reflector.IndirectType(reflect.TypeOf(controller))
+	return typ.PkgPath() + "/" + typ.Name()
+}
+
+// AddController override the global policy with the tags of controller's embedded at.Cors
+func (m *Middleware) AddController(controller interface{}) {
+	p := *m.properties
+	if tag, ok := reflector.FindEmbeddedFieldTag(controller, "Cors", "allowed_origins"); ok {
+		p.AllowedOrigins = str.Convert(tag, reflect.Slice).([]string)
+	}
+	if tag, ok := reflector.FindEmbeddedFieldTag(controller, "Cors", "allowed_methods"); ok {
+		p.AllowedMethods = str.Convert(tag, reflect.Slice).([]string)
+	}
+	if tag, ok := reflector.FindEmbeddedFieldTag(controller, "Cors", "allowed_headers"); ok {
+		p.AllowedHeaders = str.Convert(tag, reflect.Slice).([]string)
+	}
+	if tag, ok := reflector.FindEmbeddedFieldTag(controller, "Cors", "exposed_headers"); ok {
+		p.ExposedHeaders = str.Convert(tag, reflect.Slice).([]string)
+	}
+	if tag, ok := reflector.FindEmbeddedFieldTag(controller, "Cors", "allow_credentials"); ok {
+		p.AllowCredentials = str.Convert(tag, reflect.Bool).(bool)
+	}
+	if tag, ok := reflector.FindEmbeddedFieldTag(controller, "Cors", "max_age"); ok {
+		p.MaxAge = str.Convert(tag, reflect.Int).(int)
+	}
+	m.controllers[controllerName(controller)] = newPolicy(&p)
+}
+
+// find the policy of current route
+func (m *Middleware) find(ctx context.Context) (p *policy) {
+	p = m.policy
+	route := ctx.GetCurrentRoute()
+	if route != nil && len(m.controllers) != 0 {
+		if cp, ok := m.controllers[m.paths[route.Path()]]; ok {
+			p = cp
+		}
+	}
+	return
+}
+
+func setHeaders(ctx context.Context, headers http.Header) {
+	for name := range headers {
+		ctx.Header(name, headers.Get(name))
+	}
+}
+
+// Serve the middleware's action
+func (m *Middleware) Serve(ctx context.Context) {
+	origin := ctx.GetHeader(headerOrigin)
+	// not a cross-domain request
+	if origin == "" {
+		ctx.Next()
+		return
+	}
+
+	p := m.find(ctx)
+	requestMethod := ctx.GetHeader(headerRequestMethod)
+	if ctx.Method() == http.MethodOptions && requestMethod != "" {
+		// the preflight request is terminated here, it never reaches the jwt middleware or the controller
+		headers, ok := p.preflight(origin, requestMethod, ctx.GetHeader(headerRequestHeaders))
+		if ok {
+			setHeaders(ctx, headers)
+			ctx.StatusCode(http.StatusNoContent)
+		} else {
+			ctx.StatusCode(http.StatusForbidden)
+		}
+		ctx.StopExecution()
+		return
+	}
+
+	if headers, ok := p.actual(origin); ok {
+		setHeaders(ctx, headers)
+	}
+	ctx.Next()
+}
@@ -0,0 +1,170 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cors
+
+import (
+	"hidevops.io/hiboot/pkg/log"
+	"hidevops.io/hiboot/pkg/utils/str"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	headerOrigin           = "Origin"
+	headerVary             = "Vary"
+	headerRequestMethod    = "Access-Control-Request-Method"
+	headerRequestHeaders   = "Access-Control-Request-Headers"
+	headerAllowOrigin      = "Access-Control-Allow-Origin"
+	headerAllowMethods     = "Access-Control-Allow-Methods"
+	headerAllowHeaders     = "Access-Control-Allow-Headers"
+	headerAllowCredentials = "Access-Control-Allow-Credentials"
+	headerExposeHeaders    = "Access-Control-Expose-Headers"
+	headerMaxAge           = "Access-Control-Max-Age"
+
+	wildcard = "*"
+)
+
+// policy is the compiled cors properties
+type policy struct {
+	allowedOrigins   []string
+	allowAllOrigins  bool
+	allowedMethods   []string
+	allowedHeaders   []string
+	allowAllHeaders  bool
+	exposedHeaders   []string
+	allowCredentials bool
+	maxAge           int
+}
+
+func newPolicy(p *Properties) *policy {
+	pl := &policy{
+		allowCredentials: p.AllowCredentials,
+		maxAge:           p.MaxAge,
+		exposedHeaders:   p.ExposedHeaders,
+	}
+
+	for _, origin := range p.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+		if origin == wildcard {
+			pl.allowAllOrigins = true
+		} else if origin != "" {
+			pl.allowedOrigins = append(pl.allowedOrigins, origin)
+		}
+	}
+	// the browsers reject the wildcard origin with credentials, reflecting any origin instead would allow all the sites
+	// to send the credentialed requests, so the credentials are disabled
+	if pl.allowAllOrigins && pl.allowCredentials {
+		log.Errorf("cors: allow_credentials is disabled as it is not allowed with the wildcard origin *, list the allowed origins instead")
+		pl.allowCredentials = false
+	}
+
+	for _, method := range p.AllowedMethods {
+		method = strings.ToUpper(strings.TrimSpace(method))
+		if method != "" {
+			pl.allowedMethods = append(pl.allowedMethods, method)
+		}
+	}
+
+	for _, header := range p.AllowedHeaders {
+		header = strings.TrimSpace(header)
+		if header == wildcard {
+			pl.allowAllHeaders = true
+		} else if header != "" {
+			pl.allowedHeaders = append(pl.allowedHeaders, http.CanonicalHeaderKey(header))
+		}
+	}
+	return pl
+}
+
+func (p *policy) isOriginAllowed(origin string) bool {
+	if p.allowAllOrigins {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	for _, pattern := range p.allowedOrigins {
+		if str.MatchOrigin(pattern, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *policy) isMethodAllowed(method string) bool {
+	method = strings.ToUpper(method)
+	// preflight request is always allowed
+	return method == http.MethodOptions || str.InSlice(method, p.allowedMethods)
+}
+
+func (p *policy) areHeadersAllowed(requestHeaders string) bool {
+	if p.allowAllHeaders || requestHeaders == "" {
+		return true
+	}
+	for _, header := range strings.Split(requestHeaders, ",") {
+		header = http.CanonicalHeaderKey(strings.TrimSpace(header))
+		if header != "" && !str.InSlice(header, p.allowedHeaders) {
+			return false
+		}
+	}
+	return true
+}
+
+// allowOrigin return the value of Access-Control-Allow-Origin
+func (p *policy) allowOrigin(origin string) string {
+	if p.allowAllOrigins {
+		return wildcard
+	}
+	return origin
+}
+
+// preflight return the response headers of the preflight request, ok is false if the request is not allowed
+func (p *policy) preflight(origin, method, requestHeaders string) (headers http.Header, ok bool) {
+	if !p.isOriginAllowed(origin) || !p.isMethodAllowed(method) || !p.areHeadersAllowed(requestHeaders) {
+		return
+	}
+	ok = true
+	headers = make(http.Header)
+	headers.Set(headerVary, strings.Join([]string{headerOrigin, headerRequestMethod, headerRequestHeaders}, ", "))
+	headers.Set(headerAllowOrigin, p.allowOrigin(origin))
+	headers.Set(headerAllowMethods, strings.ToUpper(method))
+	if requestHeaders != "" {
+		headers.Set(headerAllowHeaders, requestHeaders)
+	}
+	if p.allowCredentials {
+		headers.Set(headerAllowCredentials, "true")
+	}
+	if p.maxAge > 0 {
+		headers.Set(headerMaxAge, strconv.Itoa(p.maxAge))
+	}
+	return
+}
+
+// actual return the response headers of the actual request, ok is false if the origin is not allowed
+func (p *policy) actual(origin string) (headers http.Header, ok bool) {
+	if !p.isOriginAllowed(origin) {
+		return
+	}
+	ok = true
+	headers = make(http.Header)
+	headers.Set(headerVary, headerOrigin)
+	headers.Set(headerAllowOrigin, p.allowOrigin(origin))
+	if p.allowCredentials {
+		headers.Set(headerAllowCredentials, "true")
+	}
+	if len(p.exposedHeaders) > 0 {
+		headers.Set(headerExposeHeaders, strings.Join(p.exposedHeaders, ", "))
+	}
+	return
+}
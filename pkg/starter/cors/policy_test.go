@@ -0,0 +1,89 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cors
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestPolicy(t *testing.T) {
+	p := newPolicy(&Properties{
+		AllowedOrigins:   []string{"https://*.example.com", "http://localhost:8080"},
+		AllowedMethods:   []string{"get", "POST"},
+		AllowedHeaders:   []string{"content-type", "Authorization"},
+		ExposedHeaders:   []string{"X-Total-Count"},
+		AllowCredentials: true,
+		MaxAge:           600,
+	})
+
+	t.Run("should allow origins", func(t *testing.T) {
+		assert.Equal(t, true, p.isOriginAllowed("https://api.example.com"))
+		assert.Equal(t, true, p.isOriginAllowed("HTTP://localhost:8080"))
+		assert.Equal(t, false, p.isOriginAllowed("https://example.org"))
+	})
+
+	t.Run("should pass the preflight request", func(t *testing.T) {
+		headers, ok := p.preflight("https://api.example.com", http.MethodPost, "Content-Type, authorization")
+		assert.Equal(t, true, ok)
+		assert.Equal(t, "https://api.example.com", headers.Get(headerAllowOrigin))
+		assert.Equal(t, http.MethodPost, headers.Get(headerAllowMethods))
+		assert.Equal(t, "Content-Type, authorization", headers.Get(headerAllowHeaders))
+		assert.Equal(t, "true", headers.Get(headerAllowCredentials))
+		assert.Equal(t, "600", headers.Get(headerMaxAge))
+	})
+
+	t.Run("should reject the preflight request with disallowed method", func(t *testing.T) {
+		_, ok := p.preflight("https://api.example.com", http.MethodDelete, "")
+		assert.Equal(t, false, ok)
+	})
+
+	t.Run("should reject the preflight request with disallowed header", func(t *testing.T) {
+		_, ok := p.preflight("https://api.example.com", http.MethodGet, "X-Foo")
+		assert.Equal(t, false, ok)
+	})
+
+	t.Run("should set the headers of actual request", func(t *testing.T) {
+		headers, ok := p.actual("http://localhost:8080")
+		assert.Equal(t, true, ok)
+		assert.Equal(t, "http://localhost:8080", headers.Get(headerAllowOrigin))
+		assert.Equal(t, "X-Total-Count", headers.Get(headerExposeHeaders))
+		assert.Equal(t, headerOrigin, headers.Get(headerVary))
+	})
+
+	t.Run("should reject the actual request with disallowed origin", func(t *testing.T) {
+		_, ok := p.actual("https://example.org")
+		assert.Equal(t, false, ok)
+	})
+
+	t.Run("should use wildcard origin without credentials", func(t *testing.T) {
+		p := newPolicy(&Properties{AllowedOrigins: []string{"*"}, AllowedHeaders: []string{"*"}})
+		headers, ok := p.actual("https://example.org")
+		assert.Equal(t, true, ok)
+		assert.Equal(t, "*", headers.Get(headerAllowOrigin))
+
+		_, ok = p.preflight("https://example.org", http.MethodOptions, "X-Foo")
+		assert.Equal(t, true, ok)
+	})
+
+	t.Run("should disable the credentials with wildcard origin", func(t *testing.T) {
+		p := newPolicy(&Properties{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+		headers, ok := p.actual("https://evil.example.org")
+		assert.Equal(t, true, ok)
+		assert.Equal(t, "*", headers.Get(headerAllowOrigin))
+		assert.Equal(t, "", headers.Get(headerAllowCredentials))
+	})
+}
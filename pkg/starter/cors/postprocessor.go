@@ -0,0 +1,49 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cors
+
+import (
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/factory"
+)
+
+type postProcessor struct {
+	configurableFactory factory.ConfigurableFactory
+	middleware          *Middleware
+}
+
+func init() {
+	// register postProcessor
+	app.RegisterPostProcessor(newPostProcessor)
+}
+
+func newPostProcessor(configurableFactory factory.ConfigurableFactory, middleware *Middleware) *postProcessor {
+	return &postProcessor{
+		configurableFactory: configurableFactory,
+		middleware:          middleware,
+	}
+}
+
+// AfterInitialization register the cors policies of the controllers that annotated by at.Cors
+func (p *postProcessor) AfterInitialization() {
+	controllers := p.configurableFactory.GetInstances(new(at.Cors))
+	for _, md := range controllers {
+		metaData := factory.CastMetaData(md)
+		if metaData.Instance != nil {
+			p.middleware.AddController(metaData.Instance)
+		}
+	}
+}
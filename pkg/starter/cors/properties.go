@@ -0,0 +1,33 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cors
+
+// Properties the cors properties
+type Properties struct {
+	// AllowedOrigins is the list of origins a cross-domain request can be executed from,
+	// an origin may contain a wildcard (*), e.g. https://*.example.com, use "*" to allow all origins
+	AllowedOrigins []string `json:"allowed_origins" default:"*"`
+	// AllowedMethods is the list of methods the client is allowed to use with cross-domain requests
+	AllowedMethods []string `json:"allowed_methods" default:"GET,POST,PUT,PATCH,DELETE,HEAD,OPTIONS"`
+	// AllowedHeaders is the list of non simple headers the client is allowed to use with cross-domain requests, "*" allows all headers
+	AllowedHeaders []string `json:"allowed_headers" default:"Origin,Accept,Content-Type,X-Requested-With,Authorization"`
+	// ExposedHeaders indicates which headers are safe to expose to the API of a CORS API specification
+	ExposedHeaders []string `json:"exposed_headers"`
+	// AllowCredentials indicates whether the request can include user credentials like cookies or HTTP authentication,
+	// it is not allowed with the wildcard origin "*", list the allowed origins instead
+	AllowCredentials bool `json:"allow_credentials" default:"false"`
+	// MaxAge indicates how long (in seconds) the results of a preflight request can be cached, 0 means no max age
+	MaxAge int `json:"max_age" default:"0"`
+}
@@ -16,14 +16,18 @@
 package grpc
 
 import (
+	"crypto/rsa"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health"
 	pb "google.golang.org/grpc/health/grpc_health_v1"
 	"hidevops.io/hiboot/pkg/app"
 	"hidevops.io/hiboot/pkg/factory"
+	"hidevops.io/hiboot/pkg/log"
 	"hidevops.io/hiboot/pkg/utils/cmap"
 	"hidevops.io/hiboot/pkg/utils/reflector"
+	"net"
 	"reflect"
+	"time"
 )
 
 const (
@@ -36,6 +40,8 @@ type configuration struct {
 	Properties properties `mapstructure:"grpc"`
 
 	instantiateFactory factory.InstantiateFactory
+	applicationContext app.ApplicationContext
+	inProcess          inProcessListener
 }
 
 type grpcService struct {
@@ -119,9 +125,10 @@ func init() {
 	app.Register(newConfiguration)
 }
 
-func newConfiguration(instantiateFactory factory.InstantiateFactory) *configuration {
+func newConfiguration(instantiateFactory factory.InstantiateFactory, applicationContext app.ApplicationContext) *configuration {
 	c := &configuration{
 		instantiateFactory: instantiateFactory,
+		applicationContext: applicationContext,
 	}
 
 	// we need to specify dependencies for runtime dependency injection
@@ -136,10 +143,19 @@ func newConfiguration(instantiateFactory factory.InstantiateFactory) *configurat
 	return c
 }
 
+// Interceptors holds the interceptor components that annotated by at.GrpcInterceptor
+func (c *configuration) Interceptors() *Interceptors {
+	return newInterceptors()
+}
+
 // ClientConnector is the interface that connect to grpc client
 // it can be injected to struct at runtime
-func (c *configuration) ClientConnector() ClientConnector {
-	return newClientConnector(c.instantiateFactory)
+func (c *configuration) ClientConnector(interceptors *Interceptors) ClientConnector {
+	var dialer func(string, time.Duration) (net.Conn, error)
+	if c.Properties.Server.Network == NetworkInProcess {
+		dialer = c.inProcess.dial
+	}
+	return newClientConnector(c.instantiateFactory, interceptors, dialer)
 }
 
 // GrpcClientFactory create gRPC Clients that registered by application
@@ -147,11 +163,52 @@ func (c *configuration) ClientFactory(cc ClientConnector) ClientFactory {
 	return newClientFactory(c.instantiateFactory, c.Properties, cc)
 }
 
-// GrpcServer create new gRpc Server
-func (c *configuration) Server() (grpcServer *grpc.Server) {
+// verifyKey look up the verify key of the jwt token, it is nil unless the jwt starter is imported
+func (c *configuration) verifyKey() (key *rsa.PublicKey) {
+	if c.applicationContext != nil {
+		if token, ok := c.applicationContext.GetInstance("jwt.token").(verifyKeyGetter); ok {
+			key = token.VerifyKey()
+		}
+	}
+	return
+}
+
+// GrpcServer create new gRpc Server, the application fails to run if the tls credentials are not able to be loaded
+func (c *configuration) Server(interceptors *Interceptors) (grpcServer *grpc.Server) {
 	// just return if grpc server is not enabled
 	if c.Properties.Server.Enabled {
-		grpcServer = grpc.NewServer()
+		opts := serverOptions(&c.Properties.Server)
+		if c.Properties.Server.TLS.Enabled {
+			creds, err := newServerCredentials(&c.Properties.Server.TLS)
+			if err != nil {
+				log.Errorf("failed to create gRPC server tls credentials: %v", err)
+				if c.applicationContext != nil {
+					c.applicationContext.Fail(err)
+				}
+				return
+			}
+			opts = append(opts, grpc.Creds(creds))
+		}
+		unaryInterceptors := []grpc.UnaryServerInterceptor{
+			requestIDUnaryServerInterceptor,
+		}
+		streamInterceptors := []grpc.StreamServerInterceptor{
+			requestIDStreamServerInterceptor,
+		}
+		if c.Properties.Server.Jwt.Enabled {
+			verifier := newJwtVerifier(c.verifyKey, c.Properties.Server.Jwt.Excludes)
+			unaryInterceptors = append(unaryInterceptors, verifier.UnaryServer)
+			streamInterceptors = append(streamInterceptors, verifier.StreamServer)
+		}
+		opts = append(opts,
+			grpc.UnaryInterceptor(chainUnaryServerInterceptors(
+				append(unaryInterceptors, interceptors.UnaryServer)...,
+			)),
+			grpc.StreamInterceptor(chainStreamServerInterceptors(
+				append(streamInterceptors, interceptors.StreamServer)...,
+			)),
+		)
+		grpcServer = grpc.NewServer(opts...)
 	}
 	return
 }
@@ -159,5 +216,34 @@ func (c *configuration) Server() (grpcServer *grpc.Server) {
 // GrpcServerFactory create gRPC servers that registered by application
 // go:depends
 func (c *configuration) ServerFactory(grpcServer *grpc.Server) ServerFactory {
-	return newServerFactory(c.instantiateFactory, c.Properties, grpcServer)
+	var fail func(err error)
+	if c.applicationContext != nil {
+		fail = c.applicationContext.Fail
+	}
+	sf := newServerFactory(c.instantiateFactory, c.Properties, grpcServer, &c.inProcess, fail)
+	if c.applicationContext != nil {
+		c.applicationContext.RegisterShutdownHook(sf.Shutdown)
+	}
+	return sf
+}
+
+// Gateway create the REST gateway and register its routes to the web application if grpc.server.gateway.enabled is true
+func (c *configuration) Gateway(grpcServer *grpc.Server, serverFactory ServerFactory, interceptors *Interceptors) (gw *Gateway) {
+	if grpcServer == nil || !c.Properties.Server.Gateway.Enabled {
+		return
+	}
+	var fail func(err error)
+	if c.applicationContext != nil {
+		fail = c.applicationContext.Fail
+	}
+	gw, err := newGateway(&c.Properties.Server, grpcServer, interceptors, fail)
+	if err != nil {
+		log.Errorf("failed to create gRPC gateway: %v", err)
+		return
+	}
+	if gw != nil {
+		c.applicationContext.RegisterShutdownHook(gw.Close)
+		gw.register(c.applicationContext)
+	}
+	return
 }
@@ -18,13 +18,16 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/net/context"
+	gogrpc "google.golang.org/grpc"
 	"google.golang.org/grpc/examples/helloworld/helloworld"
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"hidevops.io/hiboot/pkg/app"
 	"hidevops.io/hiboot/pkg/app/web"
+	"hidevops.io/hiboot/pkg/at"
 	"hidevops.io/hiboot/pkg/factory"
 	"hidevops.io/hiboot/pkg/starter/grpc"
 	"hidevops.io/hiboot/pkg/starter/grpc/mockgrpc"
+	"net/http"
 	"testing"
 )
 
@@ -60,14 +63,41 @@ func (s *greeterClientService) SayHello(name string) (*helloworld.HelloReply, er
 	return response, err
 }
 
-func TestGrpcServerAndClient(t *testing.T) {
+// gRpc interceptor
+type greeterInterceptor struct {
+	at.GrpcInterceptor
+	methods []string
+}
+
+func newGreeterInterceptor() *greeterInterceptor {
+	return &greeterInterceptor{}
+}
+
+// UnaryServerIntercept record the method that is called
+func (i *greeterInterceptor) UnaryServerIntercept(ctx context.Context, req interface{}, info *gogrpc.UnaryServerInfo, handler gogrpc.UnaryHandler) (interface{}, error) {
+	i.methods = append(i.methods, "server:"+info.FullMethod)
+	return handler(ctx, req)
+}
 
+// UnaryClientIntercept record the method that is called
+func (i *greeterInterceptor) UnaryClientIntercept(ctx context.Context, method string, req, reply interface{}, cc *gogrpc.ClientConn, invoker gogrpc.UnaryInvoker, opts ...gogrpc.CallOption) error {
+	i.methods = append(i.methods, "client:"+method)
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+func init() {
 	app.Register(newGreeterClientService)
+	app.Register(newGreeterInterceptor)
 
 	grpc.Server(helloworld.RegisterGreeterServer, newGreeterServerService)
+	grpc.Server(grpc.RegisterGatewayTestGreeterServer, grpc.NewGatewayTestGreeter)
 	grpc.Client("greeter-service", helloworld.NewGreeterClient)
+}
 
-	testApp := web.RunTestApplication(t)
+func TestGrpcServerAndClient(t *testing.T) {
+	testApp := web.NewTestApp().
+		SetProperty("grpc.server.gateway.enabled", true).
+		Run(t)
 	assert.NotEqual(t, nil, testApp)
 
 	applicationContext := testApp.(app.ApplicationContext)
@@ -92,6 +122,51 @@ func TestGrpcServerAndClient(t *testing.T) {
 		assert.NotEqual(t, nil, grpcCli)
 	})
 
+	t.Run("should intercept gRpc call by the interceptor component", func(t *testing.T) {
+		interceptor := applicationContext.GetInstance(greeterInterceptor{}).(*greeterInterceptor)
+		greeterCliSvc := applicationContext.GetInstance(greeterClientService{}).(*greeterClientService)
+		resp, err := greeterCliSvc.SayHello("Steve")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "Hello Steve", resp.Message)
+		assert.Equal(t, []string{
+			"client:/helloworld.Greeter/SayHello",
+			"server:/helloworld.Greeter/SayHello",
+		}, interceptor.methods)
+	})
+
+	t.Run("should transcode http get request to gRpc call through the gateway", func(t *testing.T) {
+		testApp.Get("/v1/greeter/{name}").
+			WithPath("name", "Steve").
+			Expect().Status(http.StatusOK).
+			JSON().Object().ValueEqual("message", "Hello Steve")
+	})
+
+	t.Run("should transcode http post request to gRpc call through the gateway", func(t *testing.T) {
+		testApp.Post("/v1/greeter").
+			WithJSON(map[string]string{"name": "Steve"}).
+			Expect().Status(http.StatusOK).
+			JSON().Object().ValueEqual("message", "Hello Steve")
+	})
+
+	t.Run("should transcode the response body field through the gateway", func(t *testing.T) {
+		testApp.Get("/v1/users/{name}/hello").
+			WithPath("name", "Steve").
+			Expect().Status(http.StatusOK).
+			JSON().String().Equal("Hello users/Steve")
+	})
+
+	t.Run("should map gRpc status to http status through the gateway", func(t *testing.T) {
+		testApp.Get("/v1/greeter/{name}").
+			WithPath("name", "nobody").
+			Expect().Status(http.StatusNotFound)
+	})
+
+	t.Run("should report bad request of invalid body through the gateway", func(t *testing.T) {
+		testApp.Post("/v1/greeter").
+			WithBytes([]byte(`{"name":`)).
+			Expect().Status(http.StatusBadRequest)
+	})
+
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 	mockGreeterClient := mockgrpc.NewMockGreeterClient(ctrl)
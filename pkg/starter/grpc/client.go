@@ -21,8 +21,12 @@ func newClientFactory(instantiateFactory factory.InstantiateFactory, properties
 	var gRPCCli interface{}
 	for _, cli := range grpcClients {
 		prop := new(ClientProperties)
+		instantiateFactory.InjectDefaultValue(prop)
 		err := mapstruct.Decode(prop, clientProps[cli.name])
 		if err == nil {
+			if prop.TimeoutSecond == 0 {
+				prop.TimeoutSecond = uint64(properties.TimeoutSecond)
+			}
 			gRPCCli, err = cc.Connect(cli.name, cli.cb, prop)
 			if err == nil {
 				clientInstanceName := reflector.GetLowerCamelFullName(gRPCCli)
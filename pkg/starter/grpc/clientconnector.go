@@ -2,9 +2,12 @@ package grpc
 
 import (
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"hidevops.io/hiboot/pkg/factory"
 	"hidevops.io/hiboot/pkg/log"
 	"hidevops.io/hiboot/pkg/utils/reflector"
+	"net"
+	"time"
 )
 
 // ClientConnector interface is response for creating grpc client connection
@@ -15,11 +18,16 @@ type ClientConnector interface {
 
 type clientConnector struct {
 	instantiateFactory factory.InstantiateFactory
+	interceptors       *Interceptors
+	// dialer dials to the in-process grpc server instead of the address if it is not nil
+	dialer func(string, time.Duration) (net.Conn, error)
 }
 
-func newClientConnector(instantiateFactory factory.InstantiateFactory) ClientConnector {
+func newClientConnector(instantiateFactory factory.InstantiateFactory, interceptors *Interceptors, dialer func(string, time.Duration) (net.Conn, error)) ClientConnector {
 	cc := &clientConnector{
 		instantiateFactory: instantiateFactory,
+		interceptors:       interceptors,
+		dialer:             dialer,
 	}
 	return cc
 }
@@ -29,18 +37,62 @@ func newClientConnector(instantiateFactory factory.InstantiateFactory) ClientCon
 // clientConstructor: client constructor
 // properties: properties for configuring
 func (c *clientConnector) Connect(name string, clientConstructor interface{}, properties *ClientProperties) (gRPCCli interface{}, err error) {
-	host := properties.Host
-	if host == "" {
-		host = name
-	}
-	address := host + ":" + properties.Port
 	conn := c.instantiateFactory.GetInstance(name)
 	if conn == nil {
+		var r *clientResolver
+		r, err = newClientResolver(name, properties)
+		if err != nil {
+			log.Errorf("failed to create gRPC client resolver: %v", err)
+			return
+		}
+		transport := grpc.WithInsecure()
+		if properties.TLS.Enabled {
+			tlsProperties := properties.TLS
+			if tlsProperties.ServerNameOverride == "" {
+				tlsProperties.ServerNameOverride = r.serverName()
+			}
+			var creds credentials.TransportCredentials
+			creds, err = newClientCredentials(&tlsProperties)
+			if err != nil {
+				log.Errorf("failed to create gRPC client tls credentials: %v", err)
+				return
+			}
+			transport = grpc.WithTransportCredentials(creds)
+		}
+		unaryInterceptors := []grpc.UnaryClientInterceptor{
+			newTimeoutUnaryClientInterceptor(seconds(properties.TimeoutSecond)),
+			requestIDUnaryClientInterceptor,
+		}
+		streamInterceptors := []grpc.StreamClientInterceptor{
+			requestIDStreamClientInterceptor,
+		}
+		if properties.PropagateToken {
+			unaryInterceptors = append(unaryInterceptors, jwtUnaryClientInterceptor)
+			streamInterceptors = append(streamInterceptors, jwtStreamClientInterceptor)
+		}
+		opts := append(dialOptions(properties),
+			transport,
+			grpc.WithUnaryInterceptor(chainUnaryClientInterceptors(
+				append(unaryInterceptors, c.interceptors.UnaryClient)...,
+			)),
+			grpc.WithStreamInterceptor(chainStreamClientInterceptors(
+				append(streamInterceptors, c.interceptors.StreamClient)...,
+			)),
+		)
+		target := r.target()
+		if c.dialer != nil {
+			target = NetworkInProcess
+			opts = append(opts, grpc.WithDialer(c.dialer))
+		} else {
+			// the health checks share the transport of the client
+			r.dialOptions = []grpc.DialOption{transport}
+			resolvers.Set(name, r)
+		}
 		// connect to grpc server
-		conn, err = grpc.Dial(address, grpc.WithInsecure())
+		conn, err = grpc.Dial(target, opts...)
 		c.instantiateFactory.SetInstance(name, conn)
 		if err == nil {
-			log.Infof("gRPC client connected to: %v", address)
+			log.Infof("gRPC client %v connected to: %v", name, r.addresses())
 		}
 	}
 	if err == nil && clientConstructor != nil {
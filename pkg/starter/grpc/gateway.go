@@ -0,0 +1,201 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"crypto/tls"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/log"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	gatewayBufferSize = 1024 * 1024
+
+	// GatewayMetadataHeaderPrefix is the prefix of the http headers that are forwarded as grpc metadata
+	GatewayMetadataHeaderPrefix = "Grpc-Metadata-"
+)
+
+// Gateway transcodes the http routes into the calls against the grpc server in-process
+type Gateway struct {
+	conn    *grpc.ClientConn
+	lis     *bufconn.Listener
+	methods map[string]*gatewayMethod
+	routes  []GatewayRoute
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// newGateway serve the grpc server on the in-memory listener and connect to it,
+// the routes are read from the google.api.http annotations and the properties,
+// fail is called with the error if the grpc server fails to serve before the gateway is closed
+func newGateway(properties *server, grpcServer *grpc.Server, interceptors *Interceptors, fail func(err error)) (g *Gateway, err error) {
+	transport := grpc.WithInsecure()
+	if properties.TLS.Enabled {
+		if properties.TLS.ClientAuth {
+			log.Warn("gRPC gateway does not support the server that requires client certificate")
+			return
+		}
+		// the connection is in-process, so there is nothing to verify
+		transport = grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{InsecureSkipVerify: true}))
+	}
+
+	g = &Gateway{
+		lis:     bufconn.Listen(gatewayBufferSize),
+		methods: make(map[string]*gatewayMethod),
+		closed:  make(chan struct{}),
+	}
+	go g.serve(grpcServer, fail)
+
+	g.conn, err = grpc.Dial("bufconn",
+		transport,
+		grpc.WithDialer(func(string, time.Duration) (net.Conn, error) {
+			return g.lis.Dial()
+		}),
+		grpc.WithUnaryInterceptor(chainUnaryClientInterceptors(
+			requestIDUnaryClientInterceptor,
+			interceptors.UnaryClient,
+		)),
+	)
+	if err != nil {
+		g.Close()
+		return nil, err
+	}
+
+	for service, info := range grpcServer.GetServiceInfo() {
+		filename, ok := info.Metadata.(string)
+		if !ok {
+			continue
+		}
+		methods, routes, e := loadServiceMethods(service, filename)
+		if e != nil {
+			log.Warnf("gRPC gateway failed to load service %v: %v", service, e)
+			continue
+		}
+		for _, method := range methods {
+			g.methods[method.rpc] = method
+		}
+		g.routes = append(g.routes, routes...)
+	}
+	g.routes = append(g.routes, properties.Gateway.Routes...)
+	return
+}
+
+// serve serves the grpc server on the in-memory listener
+func (g *Gateway) serve(grpcServer *grpc.Server, fail func(err error)) {
+	err := grpcServer.Serve(g.lis)
+	select {
+	case <-g.closed:
+		return
+	default:
+	}
+	if err != nil {
+		log.Errorf("gRPC gateway failed to serve: %v", err)
+		if fail != nil {
+			fail(err)
+		}
+	}
+}
+
+// Close closes the connection and the in-memory listener of the gateway
+func (g *Gateway) Close() {
+	g.closeOnce.Do(func() {
+		close(g.closed)
+		if g.conn != nil {
+			g.conn.Close()
+		}
+		g.lis.Close()
+	})
+}
+
+// Routes return the routes of the gateway
+func (g *Gateway) Routes() []GatewayRoute {
+	return g.routes
+}
+
+// register register the routes to the web application
+func (g *Gateway) register(applicationContext app.ApplicationContext) {
+	for _, route := range g.routes {
+		method, ok := g.methods[route.RPC]
+		if !ok {
+			log.Warnf("gRPC gateway route %v %v: unary method %v is not found", route.Method, route.Path, route.RPC)
+			continue
+		}
+		tpl, err := parsePathTemplate(route.Path)
+		if err != nil {
+			log.Warnf("gRPC gateway route %v %v: %v", route.Method, route.Path, err)
+			continue
+		}
+		applicationContext.Handle(strings.ToUpper(route.Method), tpl.path, g.handler(route, method, tpl))
+		log.Infof("Mapped gRPC gateway route %v %v to %v", route.Method, route.Path, route.RPC)
+	}
+}
+
+// outgoingMetadata forward the authorization header and the headers that prefixed by Grpc-Metadata-
+func outgoingMetadata(header http.Header) metadata.MD {
+	md := metadata.MD{}
+	for key, values := range header {
+		if strings.EqualFold(key, "Authorization") {
+			md.Append("authorization", values...)
+		} else if strings.HasPrefix(key, GatewayMetadataHeaderPrefix) {
+			md.Append(strings.TrimPrefix(key, GatewayMetadataHeaderPrefix), values...)
+		}
+	}
+	return md
+}
+
+func (g *Gateway) handler(route GatewayRoute, method *gatewayMethod, tpl *pathTemplate) context.Handler {
+	return func(ctx context.Context) {
+		body, err := ioutil.ReadAll(ctx.Request().Body)
+		if err != nil {
+			ctx.ResponseError(err.Error(), http.StatusBadRequest)
+			return
+		}
+		req := method.newInput()
+		params := tpl.values(ctx.Params().Get)
+		if err = decodeRequest(req, body, route.Body, params, ctx.Request().URL.Query()); err != nil {
+			ctx.ResponseError(err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		callCtx := metadata.NewOutgoingContext(ctx.Request().Context(), outgoingMetadata(ctx.Request().Header))
+		resp := method.newOutput()
+		if err = g.conn.Invoke(callCtx, method.rpc, req, resp); err != nil {
+			st := status.Convert(err)
+			ctx.ResponseError(st.Message(), httpStatusFromCode(st.Code()))
+			return
+		}
+
+		b, err := encodeResponse(resp, route.ResponseBody)
+		if err != nil {
+			ctx.ResponseError(err.Error(), http.StatusInternalServerError)
+			return
+		}
+		ctx.ContentType("application/json")
+		ctx.Write(b)
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc/codes"
+	"hidevops.io/hiboot/pkg/utils/str"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// fieldType find the type of the field of the message type by the proto name or json name of the field path
+func fieldType(typ reflect.Type, path []string) (t reflect.Type, ok bool) {
+	t = typ
+	for _, name := range path {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return nil, false
+		}
+		var found bool
+		for _, prop := range proto.GetProperties(t).Prop {
+			if prop.OrigName == name || prop.JSONName == name {
+				var f reflect.StructField
+				f, found = t.FieldByName(prop.Name)
+				t = f.Type
+				break
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	return t, true
+}
+
+// convertValue convert the string value of path or query param to the JSON value of the field type
+func convertValue(typ reflect.Type, value string) interface{} {
+	if typ.Kind() == reflect.Bool {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	// jsonpb accepts the numbers and the enums in string
+	return value
+}
+
+// setField set the value of the field path in the nested map
+func setField(fields map[string]interface{}, path []string, value interface{}) {
+	for _, name := range path[:len(path)-1] {
+		child, ok := fields[name].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			fields[name] = child
+		}
+		fields = child
+	}
+	fields[path[len(path)-1]] = value
+}
+
+// setParam set the string value of path or query param to the request field
+func setParam(fields map[string]interface{}, typ reflect.Type, name string, values []string) {
+	path := strings.Split(name, ".")
+	ft, ok := fieldType(typ, path)
+	if !ok {
+		return
+	}
+	if ft.Kind() == reflect.Slice && ft.Elem().Kind() != reflect.Uint8 {
+		list := make([]interface{}, len(values))
+		for i, v := range values {
+			list[i] = convertValue(ft.Elem(), v)
+		}
+		setField(fields, path, list)
+	} else if len(values) != 0 {
+		setField(fields, path, convertValue(ft, values[len(values)-1]))
+	}
+}
+
+// decodeRequest fill the request message with the http body, the path params and the query params
+func decodeRequest(req proto.Message, body []byte, bodyField string, params map[string]string, query url.Values) (err error) {
+	fields := make(map[string]interface{})
+	if bodyField != "" && len(bytes.TrimSpace(body)) != 0 {
+		var v interface{}
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		if err = decoder.Decode(&v); err != nil {
+			return
+		}
+		if bodyField == "*" {
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("request body must be a JSON object")
+			}
+			fields = m
+		} else {
+			setField(fields, strings.Split(bodyField, "."), v)
+		}
+	}
+
+	typ := reflect.TypeOf(req)
+	if bodyField != "*" {
+		for name, values := range query {
+			setParam(fields, typ, name, values)
+		}
+	}
+	for name, value := range params {
+		setParam(fields, typ, name, []string{value})
+	}
+
+	b, err := json.Marshal(fields)
+	if err == nil {
+		unmarshaler := &jsonpb.Unmarshaler{AllowUnknownFields: true}
+		err = unmarshaler.Unmarshal(bytes.NewReader(b), req)
+	}
+	return
+}
+
+// encodeResponse encode the response message or its field to JSON
+func encodeResponse(resp proto.Message, responseField string) (b []byte, err error) {
+	marshaler := &jsonpb.Marshaler{EmitDefaults: true}
+	var buf bytes.Buffer
+	if err = marshaler.Marshal(&buf, resp); err != nil {
+		return
+	}
+	b = buf.Bytes()
+	if responseField == "" {
+		return
+	}
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(b, &fields); err != nil {
+		return
+	}
+	b, ok := fields[str.ToLowerCamel(responseField)]
+	if !ok {
+		err = fmt.Errorf("response field %v is not found", responseField)
+	}
+	return
+}
+
+// httpStatusFromCode map the grpc status code to http status code
+func httpStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return http.StatusRequestTimeout
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
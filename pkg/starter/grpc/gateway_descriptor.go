@@ -0,0 +1,242 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// GatewayRoute maps the http route to the grpc method, it is as same as the google.api.http annotation
+type GatewayRoute struct {
+	// Method is the http method, e.g. GET
+	Method string `json:"method"`
+	// Path is the path template of google.api.http, e.g. /v1/greeter/{name} or /v1/{name=messages/*}
+	Path string `json:"path"`
+	// RPC is the full name of the grpc method, e.g. /helloworld.Greeter/SayHello
+	RPC string `json:"rpc"`
+	// Body is the request field that the http body is mapped to, "*" maps the body to the whole request message,
+	// the query parameters are mapped to the request fields unless it is "*"
+	Body string `json:"body"`
+	// ResponseBody is the response field that is written to the http body, it is the whole response message if it is empty
+	ResponseBody string `json:"response_body"`
+}
+
+// gatewayMethod is the unary grpc method that can be transcoded
+type gatewayMethod struct {
+	rpc    string
+	input  reflect.Type
+	output reflect.Type
+}
+
+// newInput create the request message of the method
+func (m *gatewayMethod) newInput() proto.Message {
+	return reflect.New(m.input.Elem()).Interface().(proto.Message)
+}
+
+// newOutput create the response message of the method
+func (m *gatewayMethod) newOutput() proto.Message {
+	return reflect.New(m.output.Elem()).Interface().(proto.Message)
+}
+
+// pathVariable is the variable of the path template, e.g. {name=messages/*}
+type pathVariable struct {
+	field string
+	// segments are the literals and the wildcards of the variable, the wildcards are replaced by the path params
+	segments []string
+	params   []string
+}
+
+// pathTemplate is the path template that converted to iris path
+type pathTemplate struct {
+	path      string
+	variables []*pathVariable
+}
+
+// values return the field values of the path variables from the path params
+func (t *pathTemplate) values(param func(name string) string) map[string]string {
+	values := make(map[string]string)
+	for _, v := range t.variables {
+		segments := make([]string, len(v.segments))
+		var i int
+		for n, s := range v.segments {
+			if s == "*" || s == "**" {
+				segments[n] = param(v.params[i])
+				i++
+			} else {
+				segments[n] = s
+			}
+		}
+		values[v.field] = strings.Join(segments, "/")
+	}
+	return values
+}
+
+// parsePathTemplate convert the path template of google.api.http to iris path, e.g.
+// /v1/{name=messages/*}/{id} is converted to /v1/messages/{p0}/{p1}
+func parsePathTemplate(tpl string) (t *pathTemplate, err error) {
+	if !strings.HasPrefix(tpl, "/") {
+		err = fmt.Errorf("path template %v must start with /", tpl)
+		return
+	}
+	t = new(pathTemplate)
+	var path []string
+	var numParams int
+	for len(tpl) > 0 {
+		start := strings.Index(tpl, "{")
+		if start < 0 {
+			path = append(path, tpl)
+			break
+		}
+		path = append(path, tpl[:start])
+		end := strings.Index(tpl, "}")
+		if end < start {
+			err = fmt.Errorf("invalid path template %v", tpl)
+			return
+		}
+		v := &pathVariable{segments: []string{"*"}}
+		v.field = tpl[start+1 : end]
+		if n := strings.Index(v.field, "="); n >= 0 {
+			v.segments = strings.Split(v.field[n+1:], "/")
+			v.field = v.field[:n]
+		}
+		var segments []string
+		for i, s := range v.segments {
+			switch s {
+			case "*":
+				name := "p" + strconv.Itoa(numParams)
+				v.params = append(v.params, name)
+				segments = append(segments, "{"+name+"}")
+				numParams++
+			case "**":
+				if i != len(v.segments)-1 || end != len(tpl)-1 {
+					err = fmt.Errorf("** must be the last segment of path template %v", tpl)
+					return
+				}
+				name := "p" + strconv.Itoa(numParams)
+				v.params = append(v.params, name)
+				segments = append(segments, "{"+name+":path}")
+				numParams++
+			default:
+				segments = append(segments, s)
+			}
+		}
+		path = append(path, strings.Join(segments, "/"))
+		t.variables = append(t.variables, v)
+		tpl = tpl[end+1:]
+	}
+	t.path = strings.Join(path, "")
+	return
+}
+
+// loadFileDescriptor load the registered file descriptor by file name, e.g. helloworld.proto
+func loadFileDescriptor(filename string) (fd *descriptor.FileDescriptorProto, err error) {
+	gz := proto.FileDescriptor(filename)
+	if gz == nil {
+		err = fmt.Errorf("file descriptor %v is not registered", filename)
+		return
+	}
+	r, err := gzip.NewReader(bytes.NewReader(gz))
+	if err != nil {
+		return
+	}
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return
+	}
+	fd = new(descriptor.FileDescriptorProto)
+	err = proto.Unmarshal(b, fd)
+	return
+}
+
+// routesFromRule create the routes from the google.api.http rule and its additional bindings
+func routesFromRule(rpc string, rule *annotations.HttpRule) (routes []GatewayRoute) {
+	route := GatewayRoute{
+		RPC:          rpc,
+		Body:         rule.GetBody(),
+		ResponseBody: rule.GetResponseBody(),
+	}
+	switch {
+	case rule.GetGet() != "":
+		route.Method, route.Path = http.MethodGet, rule.GetGet()
+	case rule.GetPost() != "":
+		route.Method, route.Path = http.MethodPost, rule.GetPost()
+	case rule.GetPut() != "":
+		route.Method, route.Path = http.MethodPut, rule.GetPut()
+	case rule.GetPatch() != "":
+		route.Method, route.Path = http.MethodPatch, rule.GetPatch()
+	case rule.GetDelete() != "":
+		route.Method, route.Path = http.MethodDelete, rule.GetDelete()
+	case rule.GetCustom() != nil:
+		route.Method, route.Path = strings.ToUpper(rule.GetCustom().GetKind()), rule.GetCustom().GetPath()
+	}
+	if route.Path != "" {
+		routes = append(routes, route)
+	}
+	for _, binding := range rule.GetAdditionalBindings() {
+		routes = append(routes, routesFromRule(rpc, binding)...)
+	}
+	return
+}
+
+// loadServiceMethods load the unary methods and the routes of google.api.http annotations of the service
+func loadServiceMethods(service string, filename string) (methods []*gatewayMethod, routes []GatewayRoute, err error) {
+	fd, err := loadFileDescriptor(filename)
+	if err != nil {
+		return
+	}
+	for _, sd := range fd.GetService() {
+		name := sd.GetName()
+		if fd.GetPackage() != "" {
+			name = fd.GetPackage() + "." + name
+		}
+		if name != service {
+			continue
+		}
+		for _, md := range sd.GetMethod() {
+			if md.GetClientStreaming() || md.GetServerStreaming() {
+				continue
+			}
+			method := &gatewayMethod{
+				rpc:    "/" + name + "/" + md.GetName(),
+				input:  proto.MessageType(strings.TrimPrefix(md.GetInputType(), ".")),
+				output: proto.MessageType(strings.TrimPrefix(md.GetOutputType(), ".")),
+			}
+			if method.input == nil || method.output == nil {
+				continue
+			}
+			methods = append(methods, method)
+
+			if md.GetOptions() == nil {
+				continue
+			}
+			if ext, e := proto.GetExtension(md.GetOptions(), annotations.E_Http); e == nil {
+				if rule, ok := ext.(*annotations.HttpRule); ok {
+					routes = append(routes, routesFromRule(method.rpc, rule)...)
+				}
+			}
+		}
+	}
+	return
+}
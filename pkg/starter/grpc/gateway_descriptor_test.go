@@ -0,0 +1,300 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/examples/helloworld/helloworld"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const gatewayTestProto = "hiboot/gateway_test.proto"
+
+// gatewayTestGreeter implements the greeter with google.api.http annotations for gateway tests
+type gatewayTestGreeter struct{}
+
+func (g *gatewayTestGreeter) SayHello(ctx context.Context, in *helloworld.HelloRequest) (*helloworld.HelloReply, error) {
+	if in.Name == "nobody" {
+		return nil, status.Error(codes.NotFound, "nobody is not found")
+	}
+	return &helloworld.HelloReply{Message: "Hello " + in.Name}, nil
+}
+
+// NewGatewayTestGreeter is the constructor of the greeter with google.api.http annotations, it is exported for gateway tests
+func NewGatewayTestGreeter() helloworld.GreeterServer {
+	return new(gatewayTestGreeter)
+}
+
+func gatewayTestHandler(method string) func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	return func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+		in := new(helloworld.HelloRequest)
+		if err := dec(in); err != nil {
+			return nil, err
+		}
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.(helloworld.GreeterServer).SayHello(ctx, req.(*helloworld.HelloRequest))
+		}
+		if interceptor == nil {
+			return handler(ctx, in)
+		}
+		return interceptor(ctx, in, &grpc.UnaryServerInfo{Server: srv, FullMethod: method}, handler)
+	}
+}
+
+var gatewayTestGreeterDesc = grpc.ServiceDesc{
+	ServiceName: "hiboot.gateway.test.Greeter",
+	HandlerType: (*helloworld.GreeterServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "SayHello", Handler: gatewayTestHandler("/hiboot.gateway.test.Greeter/SayHello")},
+		{MethodName: "SayHelloTo", Handler: gatewayTestHandler("/hiboot.gateway.test.Greeter/SayHelloTo")},
+	},
+	Metadata: gatewayTestProto,
+}
+
+// RegisterGatewayTestGreeterServer register the greeter with google.api.http annotations, it is exported for gateway tests
+func RegisterGatewayTestGreeterServer(s *grpc.Server, srv helloworld.GreeterServer) {
+	s.RegisterService(&gatewayTestGreeterDesc, srv)
+}
+
+func newMethodDescriptor(name string, rule *annotations.HttpRule) *descriptor.MethodDescriptorProto {
+	opts := new(descriptor.MethodOptions)
+	if err := proto.SetExtension(opts, annotations.E_Http, rule); err != nil {
+		panic(err)
+	}
+	return &descriptor.MethodDescriptorProto{
+		Name:       proto.String(name),
+		InputType:  proto.String(".helloworld.HelloRequest"),
+		OutputType: proto.String(".helloworld.HelloReply"),
+		Options:    opts,
+	}
+}
+
+func init() {
+	fd := &descriptor.FileDescriptorProto{
+		Name:       proto.String(gatewayTestProto),
+		Package:    proto.String("hiboot.gateway.test"),
+		Dependency: []string{"helloworld.proto", "google/api/annotations.proto"},
+		Service: []*descriptor.ServiceDescriptorProto{{
+			Name: proto.String("Greeter"),
+			Method: []*descriptor.MethodDescriptorProto{
+				newMethodDescriptor("SayHello", &annotations.HttpRule{
+					Pattern: &annotations.HttpRule_Get{Get: "/v1/greeter/{name}"},
+					AdditionalBindings: []*annotations.HttpRule{{
+						Pattern: &annotations.HttpRule_Post{Post: "/v1/greeter"},
+						Body:    "*",
+					}},
+				}),
+				newMethodDescriptor("SayHelloTo", &annotations.HttpRule{
+					Pattern:      &annotations.HttpRule_Get{Get: "/v1/{name=users/*}/hello"},
+					ResponseBody: "message",
+				}),
+			},
+		}},
+	}
+	b, err := proto.Marshal(fd)
+	if err != nil {
+		panic(err)
+	}
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write(b)
+	w.Close()
+	proto.RegisterFile(gatewayTestProto, buf.Bytes())
+}
+
+func TestPathTemplate(t *testing.T) {
+	testCases := []struct {
+		template string
+		path     string
+		params   map[string]string
+		values   map[string]string
+	}{
+		{"/v1/greeter", "/v1/greeter", nil, map[string]string{}},
+		{"/v1/greeter/{name}", "/v1/greeter/{p0}", map[string]string{"p0": "foo"}, map[string]string{"name": "foo"}},
+		{"/v1/{name=users/*}/hello", "/v1/users/{p0}/hello", map[string]string{"p0": "foo"}, map[string]string{"name": "users/foo"}},
+		{"/v1/{parent.id}/{name=**}", "/v1/{p0}/{p1:path}",
+			map[string]string{"p0": "foo", "p1": "bar/baz"},
+			map[string]string{"parent.id": "foo", "name": "bar/baz"}},
+	}
+	for _, tc := range testCases {
+		t.Run("should parse path template "+tc.template, func(t *testing.T) {
+			tpl, err := parsePathTemplate(tc.template)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, tc.path, tpl.path)
+			assert.Equal(t, tc.values, tpl.values(func(name string) string { return tc.params[name] }))
+		})
+	}
+
+	t.Run("should report error of invalid path template", func(t *testing.T) {
+		for _, tpl := range []string{"v1/greeter", "/v1/{name", "/v1/{name=**}/foo"} {
+			_, err := parsePathTemplate(tpl)
+			assert.NotEqual(t, nil, err, tpl)
+		}
+	})
+}
+
+func TestGatewayDescriptor(t *testing.T) {
+	t.Run("should load methods and routes of google.api.http annotations", func(t *testing.T) {
+		methods, routes, err := loadServiceMethods("hiboot.gateway.test.Greeter", gatewayTestProto)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 2, len(methods))
+		assert.Equal(t, "/hiboot.gateway.test.Greeter/SayHello", methods[0].rpc)
+		assert.Equal(t, &helloworld.HelloRequest{}, methods[0].newInput())
+		assert.Equal(t, &helloworld.HelloReply{}, methods[0].newOutput())
+		assert.Equal(t, []GatewayRoute{
+			{Method: http.MethodGet, Path: "/v1/greeter/{name}", RPC: "/hiboot.gateway.test.Greeter/SayHello"},
+			{Method: http.MethodPost, Path: "/v1/greeter", RPC: "/hiboot.gateway.test.Greeter/SayHello", Body: "*"},
+			{Method: http.MethodGet, Path: "/v1/{name=users/*}/hello", RPC: "/hiboot.gateway.test.Greeter/SayHelloTo", ResponseBody: "message"},
+		}, routes)
+	})
+
+	t.Run("should load methods without annotations", func(t *testing.T) {
+		methods, routes, err := loadServiceMethods("grpc.health.v1.Health", "grpc/health/v1/health.proto")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 1, len(methods))
+		assert.Equal(t, 0, len(routes))
+	})
+
+	t.Run("should report error of unregistered file", func(t *testing.T) {
+		_, _, err := loadServiceMethods("foo.Bar", "foo.proto")
+		assert.NotEqual(t, nil, err)
+	})
+}
+
+func TestGatewayCodec(t *testing.T) {
+	t.Run("should decode request from body", func(t *testing.T) {
+		req := new(helloworld.HelloRequest)
+		err := decodeRequest(req, []byte(`{"name": "foo"}`), "*", nil, url.Values{"name": {"bar"}})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "foo", req.Name)
+	})
+
+	t.Run("should decode request from path and query params", func(t *testing.T) {
+		req := new(helloworld.HelloRequest)
+		err := decodeRequest(req, nil, "", nil, url.Values{"name": {"bar"}, "unknown": {"baz"}})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "bar", req.Name)
+
+		err = decodeRequest(req, nil, "", map[string]string{"name": "foo"}, url.Values{"name": {"bar"}})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "foo", req.Name)
+	})
+
+	t.Run("should convert bool and repeated params", func(t *testing.T) {
+		typ := reflect.TypeOf(new(grpc_health_v1.HealthCheckResponse))
+		fields := make(map[string]interface{})
+		setParam(fields, typ, "status", []string{"SERVING"})
+		assert.Equal(t, map[string]interface{}{"status": "SERVING"}, fields)
+		assert.Equal(t, true, convertValue(reflect.TypeOf(true), "true"))
+		assert.Equal(t, "foo", convertValue(reflect.TypeOf(true), "foo"))
+	})
+
+	t.Run("should report error of invalid body", func(t *testing.T) {
+		req := new(helloworld.HelloRequest)
+		assert.NotEqual(t, nil, decodeRequest(req, []byte(`{"name":`), "*", nil, nil))
+		assert.NotEqual(t, nil, decodeRequest(req, []byte(`"foo"`), "*", nil, nil))
+		assert.NotEqual(t, nil, decodeRequest(req, []byte(`{"name": 1}`), "*", nil, nil))
+	})
+
+	t.Run("should encode response", func(t *testing.T) {
+		b, err := encodeResponse(&helloworld.HelloReply{Message: "foo"}, "")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, `{"message":"foo"}`, string(b))
+
+		b, err = encodeResponse(&helloworld.HelloReply{Message: "foo"}, "message")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, `"foo"`, string(b))
+
+		_, err = encodeResponse(&helloworld.HelloReply{Message: "foo"}, "unknown")
+		assert.NotEqual(t, nil, err)
+	})
+
+	t.Run("should map grpc status code to http status", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, httpStatusFromCode(codes.OK))
+		assert.Equal(t, http.StatusNotFound, httpStatusFromCode(codes.NotFound))
+		assert.Equal(t, http.StatusUnauthorized, httpStatusFromCode(codes.Unauthenticated))
+		assert.Equal(t, http.StatusInternalServerError, httpStatusFromCode(codes.Internal))
+	})
+}
+
+func TestGatewayTranscoding(t *testing.T) {
+	lis := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	RegisterGatewayTestGreeterServer(server, NewGatewayTestGreeter())
+	go server.Serve(lis)
+	defer server.Stop()
+
+	conn, err := grpc.Dial("bufconn", grpc.WithInsecure(), grpc.WithDialer(func(string, time.Duration) (net.Conn, error) {
+		return lis.Dial()
+	}))
+	assert.Equal(t, nil, err)
+	defer conn.Close()
+
+	methods, routes, err := loadServiceMethods("hiboot.gateway.test.Greeter", gatewayTestProto)
+	assert.Equal(t, nil, err)
+
+	call := func(route GatewayRoute, method *gatewayMethod, body string, params map[string]string) (string, error) {
+		tpl, err := parsePathTemplate(route.Path)
+		assert.Equal(t, nil, err)
+		req := method.newInput()
+		err = decodeRequest(req, []byte(body), route.Body, tpl.values(func(name string) string { return params[name] }), nil)
+		assert.Equal(t, nil, err)
+		resp := method.newOutput()
+		if err = conn.Invoke(context.Background(), method.rpc, req, resp); err != nil {
+			return "", err
+		}
+		b, err := encodeResponse(resp, route.ResponseBody)
+		return string(b), err
+	}
+
+	t.Run("should transcode get request with path param", func(t *testing.T) {
+		resp, err := call(routes[0], methods[0], "", map[string]string{"p0": "foo"})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, `{"message":"Hello foo"}`, resp)
+	})
+
+	t.Run("should transcode post request with body", func(t *testing.T) {
+		resp, err := call(routes[1], methods[0], `{"name":"bar"}`, nil)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, `{"message":"Hello bar"}`, resp)
+	})
+
+	t.Run("should transcode request with response body", func(t *testing.T) {
+		resp, err := call(routes[2], methods[1], "", map[string]string{"p0": "baz"})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, `"Hello users/baz"`, resp)
+	})
+
+	t.Run("should return grpc error", func(t *testing.T) {
+		_, err := call(routes[0], methods[0], "", map[string]string{"p0": "nobody"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
@@ -0,0 +1,56 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"testing"
+	"time"
+)
+
+func TestGatewayLifecycle(t *testing.T) {
+	t.Run("should close the connection and the listener", func(t *testing.T) {
+		grpcServer := grpc.NewServer()
+		defer grpcServer.Stop()
+		gw, err := newGateway(&server{}, grpcServer, newInterceptors(), func(err error) {
+			t.Errorf("unexpected failure: %v", err)
+		})
+		assert.Equal(t, nil, err)
+		gw.Close()
+		gw.Close()
+		assert.Equal(t, connectivity.Shutdown, gw.conn.GetState())
+		_, err = gw.lis.Dial()
+		assert.NotEqual(t, nil, err)
+	})
+
+	t.Run("should fail the application if the grpc server failed to serve", func(t *testing.T) {
+		grpcServer := grpc.NewServer()
+		grpcServer.Stop()
+		failures := make(chan error, 1)
+		gw, err := newGateway(&server{}, grpcServer, newInterceptors(), func(err error) {
+			failures <- err
+		})
+		assert.Equal(t, nil, err)
+		defer gw.Close()
+		select {
+		case err = <-failures:
+			assert.Equal(t, grpc.ErrServerStopped, err)
+		case <-time.After(time.Second):
+			t.Fatal("the failure is not reported")
+		}
+	})
+}
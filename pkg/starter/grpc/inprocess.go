@@ -0,0 +1,49 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// NetworkInProcess is the grpc.server.network that serves the grpc server on the in-memory listener,
+	// and all the registered grpc clients dial to it regardless of their host and port
+	NetworkInProcess = "bufconn"
+
+	inProcessBufferSize = 1024 * 1024
+)
+
+// inProcessListener holds the in-memory listener that is created on demand
+type inProcessListener struct {
+	once     sync.Once
+	listener *bufconn.Listener
+}
+
+// get returns the in-memory listener
+func (l *inProcessListener) get() *bufconn.Listener {
+	l.once.Do(func() {
+		l.listener = bufconn.Listen(inProcessBufferSize)
+	})
+	return l.listener
+}
+
+// dial dials to the in-memory listener, it implements the dialer of grpc.WithDialer
+func (l *inProcessListener) dial(string, time.Duration) (net.Conn, error) {
+	return l.get().Dial()
+}
@@ -0,0 +1,161 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"hidevops.io/hiboot/pkg/log"
+	"testing"
+)
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestRequestIDInterceptors(t *testing.T) {
+	t.Run("should not append request id if it is not in context", func(t *testing.T) {
+		err := requestIDUnaryClientInterceptor(context.Background(), "/foo", nil, nil, nil,
+			func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				_, ok := metadata.FromOutgoingContext(ctx)
+				assert.Equal(t, false, ok)
+				return nil
+			})
+		assert.Equal(t, nil, err)
+	})
+
+	t.Run("should append request id to outgoing metadata", func(t *testing.T) {
+		ctx := log.NewContext(context.Background(), log.RequestIDKey, "foo")
+		err := requestIDUnaryClientInterceptor(ctx, "/foo", nil, nil, nil,
+			func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				md, _ := metadata.FromOutgoingContext(ctx)
+				assert.Equal(t, []string{"foo"}, md.Get(log.RequestIDKey))
+				return nil
+			})
+		assert.Equal(t, nil, err)
+
+		_, err = requestIDStreamClientInterceptor(ctx, nil, nil, "/foo",
+			func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+				md, _ := metadata.FromOutgoingContext(ctx)
+				assert.Equal(t, []string{"foo"}, md.Get(log.RequestIDKey))
+				return nil, nil
+			})
+		assert.Equal(t, nil, err)
+	})
+
+	t.Run("should keep request id that is set by caller", func(t *testing.T) {
+		ctx := log.NewContext(context.Background(), log.RequestIDKey, "foo")
+		ctx = metadata.AppendToOutgoingContext(ctx, log.RequestIDKey, "bar")
+		err := requestIDUnaryClientInterceptor(ctx, "/foo", nil, nil, nil,
+			func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				md, _ := metadata.FromOutgoingContext(ctx)
+				assert.Equal(t, []string{"bar"}, md.Get(log.RequestIDKey))
+				return nil
+			})
+		assert.Equal(t, nil, err)
+	})
+
+	t.Run("should pass incoming request id to handler context", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(log.RequestIDKey, "foo"))
+		_, err := requestIDUnaryServerInterceptor(ctx, nil, nil,
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				assert.Equal(t, "foo", log.FromContext(ctx, log.RequestIDKey))
+				return nil, nil
+			})
+		assert.Equal(t, nil, err)
+
+		err = requestIDStreamServerInterceptor(nil, &fakeServerStream{ctx: ctx}, nil,
+			func(srv interface{}, stream grpc.ServerStream) error {
+				assert.Equal(t, "foo", log.FromContext(stream.Context(), log.RequestIDKey))
+				return nil
+			})
+		assert.Equal(t, nil, err)
+	})
+}
+
+func TestChainInterceptors(t *testing.T) {
+	t.Run("should chain unary server interceptors in order", func(t *testing.T) {
+		var calls []string
+		newInterceptor := func(name string) grpc.UnaryServerInterceptor {
+			return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+				calls = append(calls, name)
+				return handler(ctx, req)
+			}
+		}
+		_, err := chainUnaryServerInterceptors(newInterceptor("foo"), newInterceptor("bar"))(context.Background(), nil, nil,
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				calls = append(calls, "handler")
+				return nil, nil
+			})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []string{"foo", "bar", "handler"}, calls)
+	})
+
+	t.Run("should chain unary client interceptors in order", func(t *testing.T) {
+		var calls []string
+		newInterceptor := func(name string) grpc.UnaryClientInterceptor {
+			return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+				calls = append(calls, name)
+				return invoker(ctx, method, req, reply, cc, opts...)
+			}
+		}
+		err := chainUnaryClientInterceptors(newInterceptor("foo"), newInterceptor("bar"))(context.Background(), "/foo", nil, nil, nil,
+			func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				calls = append(calls, "invoker")
+				return nil
+			})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []string{"foo", "bar", "invoker"}, calls)
+	})
+
+	t.Run("should chain stream interceptors in order", func(t *testing.T) {
+		var calls []string
+		newServerInterceptor := func(name string) grpc.StreamServerInterceptor {
+			return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+				calls = append(calls, name)
+				return handler(srv, ss)
+			}
+		}
+		err := chainStreamServerInterceptors(newServerInterceptor("foo"), newServerInterceptor("bar"))(nil, nil, nil,
+			func(srv interface{}, stream grpc.ServerStream) error {
+				calls = append(calls, "handler")
+				return nil
+			})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []string{"foo", "bar", "handler"}, calls)
+
+		calls = nil
+		newClientInterceptor := func(name string) grpc.StreamClientInterceptor {
+			return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+				calls = append(calls, name)
+				return streamer(ctx, desc, cc, method, opts...)
+			}
+		}
+		_, err = chainStreamClientInterceptors(newClientInterceptor("foo"), newClientInterceptor("bar"))(context.Background(), nil, nil, "/foo",
+			func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+				calls = append(calls, "streamer")
+				return nil, nil
+			})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []string{"foo", "bar", "streamer"}, calls)
+	})
+}
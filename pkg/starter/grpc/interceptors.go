@@ -0,0 +1,133 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"hidevops.io/hiboot/pkg/log"
+	"hidevops.io/hiboot/pkg/utils/reflector"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// UnaryServerInterceptor is the interface of the unary server interceptor component that annotated by at.GrpcInterceptor
+type UnaryServerInterceptor interface {
+	UnaryServerIntercept(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error)
+}
+
+// StreamServerInterceptor is the interface of the stream server interceptor component that annotated by at.GrpcInterceptor
+type StreamServerInterceptor interface {
+	StreamServerIntercept(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error
+}
+
+// UnaryClientInterceptor is the interface of the unary client interceptor component that annotated by at.GrpcInterceptor
+type UnaryClientInterceptor interface {
+	UnaryClientIntercept(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error
+}
+
+// StreamClientInterceptor is the interface of the stream client interceptor component that annotated by at.GrpcInterceptor
+type StreamClientInterceptor interface {
+	StreamClientIntercept(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error)
+}
+
+type orderedInterceptor struct {
+	order       int
+	interceptor interface{}
+}
+
+// Interceptors holds the interceptor components, the server and the clients look them up on each call,
+// so that the interceptors that are instantiated after the server and the clients still take effect
+type Interceptors struct {
+	mu           sync.RWMutex
+	interceptors []orderedInterceptor
+	unaryServer  []grpc.UnaryServerInterceptor
+	streamServer []grpc.StreamServerInterceptor
+	unaryClient  []grpc.UnaryClientInterceptor
+	streamClient []grpc.StreamClientInterceptor
+}
+
+func newInterceptors() *Interceptors {
+	return &Interceptors{}
+}
+
+// Add add the interceptor component, the order is read from the order tag of at.GrpcInterceptor
+func (i *Interceptors) Add(interceptor interface{}) {
+	var order int
+	if tag, ok := reflector.FindEmbeddedFieldTag(interceptor, "GrpcInterceptor", "order"); ok {
+		var err error
+		order, err = strconv.Atoi(tag)
+		if err != nil {
+			log.Warnf("invalid order %v of grpc interceptor %v", tag, reflector.GetLowerCamelFullName(interceptor))
+		}
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.interceptors = append(i.interceptors, orderedInterceptor{order: order, interceptor: interceptor})
+	sort.SliceStable(i.interceptors, func(a, b int) bool {
+		return i.interceptors[a].order < i.interceptors[b].order
+	})
+
+	i.unaryServer, i.streamServer, i.unaryClient, i.streamClient = nil, nil, nil, nil
+	for _, oi := range i.interceptors {
+		if itc, ok := oi.interceptor.(UnaryServerInterceptor); ok {
+			i.unaryServer = append(i.unaryServer, itc.UnaryServerIntercept)
+		}
+		if itc, ok := oi.interceptor.(StreamServerInterceptor); ok {
+			i.streamServer = append(i.streamServer, itc.StreamServerIntercept)
+		}
+		if itc, ok := oi.interceptor.(UnaryClientInterceptor); ok {
+			i.unaryClient = append(i.unaryClient, itc.UnaryClientIntercept)
+		}
+		if itc, ok := oi.interceptor.(StreamClientInterceptor); ok {
+			i.streamClient = append(i.streamClient, itc.StreamClientIntercept)
+		}
+	}
+}
+
+// UnaryServer chain the unary server interceptors
+func (i *Interceptors) UnaryServer(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	i.mu.RLock()
+	interceptors := i.unaryServer
+	i.mu.RUnlock()
+	return chainUnaryServerInterceptors(interceptors...)(ctx, req, info, handler)
+}
+
+// StreamServer chain the stream server interceptors
+func (i *Interceptors) StreamServer(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	i.mu.RLock()
+	interceptors := i.streamServer
+	i.mu.RUnlock()
+	return chainStreamServerInterceptors(interceptors...)(srv, ss, info, handler)
+}
+
+// UnaryClient chain the unary client interceptors
+func (i *Interceptors) UnaryClient(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	i.mu.RLock()
+	interceptors := i.unaryClient
+	i.mu.RUnlock()
+	return chainUnaryClientInterceptors(interceptors...)(ctx, method, req, reply, cc, invoker, opts...)
+}
+
+// StreamClient chain the stream client interceptors
+func (i *Interceptors) StreamClient(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	i.mu.RLock()
+	interceptors := i.streamClient
+	i.mu.RUnlock()
+	return chainStreamClientInterceptors(interceptors...)(ctx, desc, cc, method, streamer, opts...)
+}
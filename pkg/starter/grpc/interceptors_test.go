@@ -0,0 +1,128 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"hidevops.io/hiboot/pkg/at"
+	"testing"
+)
+
+type recorder struct {
+	calls []string
+}
+
+type fooInterceptor struct {
+	at.GrpcInterceptor `order:"2"`
+	*recorder
+}
+
+func (i *fooInterceptor) UnaryServerIntercept(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	i.calls = append(i.calls, "foo.unaryServer")
+	return handler(ctx, req)
+}
+
+func (i *fooInterceptor) UnaryClientIntercept(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	i.calls = append(i.calls, "foo.unaryClient")
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+type barInterceptor struct {
+	at.GrpcInterceptor `order:"1"`
+	*recorder
+}
+
+func (i *barInterceptor) UnaryServerIntercept(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	i.calls = append(i.calls, "bar.unaryServer")
+	return handler(ctx, req)
+}
+
+func (i *barInterceptor) StreamServerIntercept(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	i.calls = append(i.calls, "bar.streamServer")
+	return handler(srv, ss)
+}
+
+func (i *barInterceptor) StreamClientIntercept(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	i.calls = append(i.calls, "bar.streamClient")
+	return streamer(ctx, desc, cc, method, opts...)
+}
+
+type bazInterceptor struct {
+	at.GrpcInterceptor `order:"2"`
+	*recorder
+}
+
+func (i *bazInterceptor) UnaryServerIntercept(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	i.calls = append(i.calls, "baz.unaryServer")
+	return handler(ctx, req)
+}
+
+func TestInterceptors(t *testing.T) {
+	r := new(recorder)
+	interceptors := newInterceptors()
+	interceptors.Add(&fooInterceptor{recorder: r})
+	interceptors.Add(&barInterceptor{recorder: r})
+	interceptors.Add(&bazInterceptor{recorder: r})
+
+	t.Run("should chain unary server interceptors in order", func(t *testing.T) {
+		r.calls = nil
+		_, err := interceptors.UnaryServer(context.Background(), nil, nil,
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				r.calls = append(r.calls, "handler")
+				return nil, nil
+			})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []string{"bar.unaryServer", "foo.unaryServer", "baz.unaryServer", "handler"}, r.calls)
+	})
+
+	t.Run("should chain stream server interceptors", func(t *testing.T) {
+		r.calls = nil
+		err := interceptors.StreamServer(nil, nil, nil,
+			func(srv interface{}, stream grpc.ServerStream) error {
+				r.calls = append(r.calls, "handler")
+				return nil
+			})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []string{"bar.streamServer", "handler"}, r.calls)
+	})
+
+	t.Run("should chain client interceptors", func(t *testing.T) {
+		r.calls = nil
+		err := interceptors.UnaryClient(context.Background(), "/foo", nil, nil, nil,
+			func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				r.calls = append(r.calls, "invoker")
+				return nil
+			})
+		assert.Equal(t, nil, err)
+		_, err = interceptors.StreamClient(context.Background(), nil, nil, "/foo",
+			func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+				r.calls = append(r.calls, "streamer")
+				return nil, nil
+			})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []string{"foo.unaryClient", "invoker", "bar.streamClient", "streamer"}, r.calls)
+	})
+
+	t.Run("should call handler directly without interceptors", func(t *testing.T) {
+		resp, err := newInterceptors().UnaryServer(context.Background(), "foo", nil,
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				return req, nil
+			})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "foo", resp)
+	})
+}
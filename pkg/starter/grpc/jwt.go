@@ -0,0 +1,138 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"crypto/rsa"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"hidevops.io/hiboot/pkg/log"
+	"hidevops.io/hiboot/pkg/starter/jwt/jwtctx"
+	"strings"
+)
+
+const (
+	// AuthorizationKey is the grpc metadata that carries the bearer token
+	AuthorizationKey = "authorization"
+
+	bearerPrefix = "Bearer "
+)
+
+// outgoingToken forward the token as the authorization metadata, the token is looked up from
+// the outgoing metadata, the context and the incoming metadata of the grpc server in order,
+// e.g. the context of the web request carries the token that the jwt middleware verified
+func outgoingToken(ctx context.Context) context.Context {
+	if md, ok := metadata.FromOutgoingContext(ctx); ok && len(md.Get(AuthorizationKey)) != 0 {
+		return ctx
+	}
+	if token, ok := jwtctx.TokenFromContext(ctx); ok && token != "" {
+		return metadata.AppendToOutgoingContext(ctx, AuthorizationKey, bearerPrefix+token)
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if auth := md.Get(AuthorizationKey); len(auth) != 0 && auth[0] != "" {
+			return metadata.AppendToOutgoingContext(ctx, AuthorizationKey, auth[0])
+		}
+	}
+	return ctx
+}
+
+// jwtUnaryClientInterceptor forward the token of current request as the authorization metadata
+func jwtUnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(outgoingToken(ctx), method, req, reply, cc, opts...)
+}
+
+// jwtStreamClientInterceptor forward the token of current request as the authorization metadata
+func jwtStreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(outgoingToken(ctx), desc, cc, method, opts...)
+}
+
+// verifyKeyGetter is implemented by the token of the jwt starter
+type verifyKeyGetter interface {
+	VerifyKey() *rsa.PublicKey
+}
+
+// jwtVerifier verifies the token of the authorization metadata with the verify key of the jwt starter
+type jwtVerifier struct {
+	verifyKey func() *rsa.PublicKey
+	excludes  []string
+}
+
+// newJwtVerifier is the constructor of jwtVerifier, verifyKey is called on each call
+// as the jwt token is instantiated after the grpc server
+func newJwtVerifier(verifyKey func() *rsa.PublicKey, excludes []string) *jwtVerifier {
+	return &jwtVerifier{
+		verifyKey: verifyKey,
+		excludes:  excludes,
+	}
+}
+
+// excluded checks if the service of the method is excluded from the verification
+func (v *jwtVerifier) excluded(fullMethod string) bool {
+	service := strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(service, "/"); i >= 0 {
+		service = service[:i]
+	}
+	for _, exclude := range v.excludes {
+		if exclude == service || exclude == fullMethod {
+			return true
+		}
+	}
+	return false
+}
+
+// verify verifies the token and returns the context that carries its claims
+func (v *jwtVerifier) verify(ctx context.Context, fullMethod string) (context.Context, error) {
+	if v.excluded(fullMethod) {
+		return ctx, nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	auth := md.Get(AuthorizationKey)
+	if len(auth) == 0 || !strings.HasPrefix(auth[0], bearerPrefix) {
+		return ctx, status.Error(codes.Unauthenticated, "required authorization token not found")
+	}
+	token := strings.TrimPrefix(auth[0], bearerPrefix)
+	verifyKey := v.verifyKey()
+	if verifyKey == nil {
+		log.Warnf("gRPC %v: the verify key is not found, the jwt starter is required by grpc.server.jwt.enabled", fullMethod)
+	}
+	claims, err := jwtctx.ParseToken(token, verifyKey)
+	if err != nil {
+		log.Debugf("gRPC %v: invalid token: %v", fullMethod, err)
+		return ctx, status.Error(codes.Unauthenticated, "invalid authorization token")
+	}
+	ctx = jwtctx.NewTokenContext(ctx, token)
+	return jwtctx.NewClaimsContext(ctx, claims), nil
+}
+
+// UnaryServer verifies the token of the unary calls, the claims can be got by jwtctx.ClaimsFromContext
+func (v *jwtVerifier) UnaryServer(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := v.verify(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// StreamServer verifies the token of the streams, the claims can be got by jwtctx.ClaimsFromContext
+func (v *jwtVerifier) StreamServer(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := v.verify(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+}
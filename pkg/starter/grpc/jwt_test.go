@@ -0,0 +1,133 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"crypto/rsa"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/examples/helloworld/helloworld"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"hidevops.io/hiboot/pkg/starter/jwt"
+	"hidevops.io/hiboot/pkg/starter/jwt/jwtctx"
+	"hidevops.io/hiboot/pkg/utils/io"
+	"net"
+	"testing"
+	"time"
+)
+
+// claimsGreeter replies with the username claim
+type claimsGreeter struct{}
+
+func (g *claimsGreeter) SayHello(ctx context.Context, in *helloworld.HelloRequest) (*helloworld.HelloReply, error) {
+	claims, ok := jwtctx.ClaimsFromContext(ctx)
+	if !ok {
+		return &helloworld.HelloReply{Message: "Hello anonymous"}, nil
+	}
+	return &helloworld.HelloReply{Message: "Hello " + claims["username"].(string)}, nil
+}
+
+func TestOutgoingToken(t *testing.T) {
+	authorization := func(ctx context.Context) []string {
+		md, _ := metadata.FromOutgoingContext(outgoingToken(ctx))
+		return md.Get(AuthorizationKey)
+	}
+
+	t.Run("should not forward token if there is no token", func(t *testing.T) {
+		assert.Equal(t, 0, len(authorization(context.Background())))
+	})
+
+	t.Run("should forward the token of the context", func(t *testing.T) {
+		ctx := jwtctx.NewTokenContext(context.Background(), "foo")
+		assert.Equal(t, []string{"Bearer foo"}, authorization(ctx))
+	})
+
+	t.Run("should keep the authorization of the outgoing metadata", func(t *testing.T) {
+		ctx := metadata.AppendToOutgoingContext(jwtctx.NewTokenContext(context.Background(), "foo"), AuthorizationKey, "Bearer bar")
+		assert.Equal(t, []string{"Bearer bar"}, authorization(ctx))
+	})
+
+	t.Run("should forward the authorization of the incoming metadata", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(AuthorizationKey, "Bearer baz"))
+		assert.Equal(t, []string{"Bearer baz"}, authorization(ctx))
+	})
+}
+
+func TestJwtVerifier(t *testing.T) {
+	io.EnsureWorkDir(1, "config/ssl/app.rsa")
+	token := jwt.NewJwtToken(&jwt.Properties{
+		PrivateKeyPath: "config/ssl/app.rsa",
+		PublicKeyPath:  "config/ssl/app.rsa.pub",
+	})
+	tokenStr, err := token.Generate(jwt.Map{"username": "johnd"}, 10, time.Second)
+	assert.Equal(t, nil, err)
+
+	verifier := newJwtVerifier(token.VerifyKey, []string{"grpc.health.v1.Health", "/helloworld.Greeter/SayGoodbye"})
+
+	t.Run("should exclude the services and methods", func(t *testing.T) {
+		assert.Equal(t, true, verifier.excluded("/grpc.health.v1.Health/Check"))
+		assert.Equal(t, true, verifier.excluded("/helloworld.Greeter/SayGoodbye"))
+		assert.Equal(t, false, verifier.excluded("/helloworld.Greeter/SayHello"))
+	})
+
+	t.Run("should report unauthenticated without verify key", func(t *testing.T) {
+		v := newJwtVerifier(func() *rsa.PublicKey { return nil }, nil)
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(AuthorizationKey, bearerPrefix+tokenStr))
+		_, err := v.verify(ctx, "/helloworld.Greeter/SayHello")
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	lis := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(grpc.UnaryInterceptor(verifier.UnaryServer))
+	helloworld.RegisterGreeterServer(server, new(claimsGreeter))
+	go server.Serve(lis)
+	defer server.Stop()
+
+	conn, err := grpc.Dial("bufconn", grpc.WithInsecure(),
+		grpc.WithDialer(func(string, time.Duration) (net.Conn, error) {
+			return lis.Dial()
+		}),
+		grpc.WithUnaryInterceptor(jwtUnaryClientInterceptor),
+	)
+	assert.Equal(t, nil, err)
+	defer conn.Close()
+	cli := helloworld.NewGreeterClient(conn)
+
+	t.Run("should expose the claims of the propagated token to the service", func(t *testing.T) {
+		ctx := jwtctx.NewTokenContext(context.Background(), tokenStr)
+		resp, err := cli.SayHello(ctx, &helloworld.HelloRequest{})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "Hello johnd", resp.Message)
+	})
+
+	t.Run("should report unauthenticated without token", func(t *testing.T) {
+		_, err := cli.SayHello(context.Background(), &helloworld.HelloRequest{})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("should report unauthenticated with invalid token", func(t *testing.T) {
+		ctx := jwtctx.NewTokenContext(context.Background(), tokenStr+"x")
+		_, err := cli.SayHello(ctx, &helloworld.HelloRequest{})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		ctx = metadata.AppendToOutgoingContext(context.Background(), AuthorizationKey, "Basic Zm9vOmJhcg==")
+		_, err = cli.SayHello(ctx, &helloworld.HelloRequest{})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+}
@@ -0,0 +1,91 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+	"time"
+)
+
+func seconds(s uint64) time.Duration {
+	return time.Duration(s) * time.Second
+}
+
+// serverOptions create the server options from the server properties
+func serverOptions(properties *server) (opts []grpc.ServerOption) {
+	if properties.MaxRecvMsgSize > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(properties.MaxRecvMsgSize))
+	}
+	if properties.MaxSendMsgSize > 0 {
+		opts = append(opts, grpc.MaxSendMsgSize(properties.MaxSendMsgSize))
+	}
+	if properties.MaxConcurrentStreams > 0 {
+		opts = append(opts, grpc.MaxConcurrentStreams(properties.MaxConcurrentStreams))
+	}
+
+	ka := properties.KeepAlive
+	opts = append(opts,
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			MaxConnectionIdle:     seconds(ka.MaxConnectionIdle),
+			MaxConnectionAge:      seconds(ka.MaxConnectionAge),
+			MaxConnectionAgeGrace: seconds(ka.MaxConnectionAgeGrace),
+			Time:                  seconds(ka.Time),
+			Timeout:               seconds(ka.Timeout),
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             seconds(ka.MinTime),
+			PermitWithoutStream: ka.PermitWithoutStream,
+		}),
+	)
+	return
+}
+
+// dialOptions create the dial options from the client properties
+func dialOptions(properties *ClientProperties) (opts []grpc.DialOption) {
+	var callOpts []grpc.CallOption
+	if properties.MaxRecvMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallRecvMsgSize(properties.MaxRecvMsgSize))
+	}
+	if properties.MaxSendMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallSendMsgSize(properties.MaxSendMsgSize))
+	}
+	if len(callOpts) != 0 {
+		opts = append(opts, grpc.WithDefaultCallOptions(callOpts...))
+	}
+
+	if properties.KeepAlive.Enabled {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                seconds(properties.KeepAlive.Delay),
+			Timeout:             seconds(properties.KeepAlive.Timeout),
+			PermitWithoutStream: properties.KeepAlive.PermitWithoutStream,
+		}))
+	}
+	return
+}
+
+// newTimeoutUnaryClientInterceptor create the interceptor that sets the default deadline of the unary calls
+// that have no deadline, the interceptor does nothing if timeout is 0
+func newTimeoutUnaryClientInterceptor(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if _, ok := ctx.Deadline(); !ok && timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
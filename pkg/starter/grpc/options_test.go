@@ -0,0 +1,136 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/examples/helloworld/helloworld"
+	"google.golang.org/grpc/status"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+type slowGreeter struct {
+	delay time.Duration
+}
+
+func (g *slowGreeter) SayHello(ctx context.Context, in *helloworld.HelloRequest) (*helloworld.HelloReply, error) {
+	select {
+	case <-time.After(g.delay):
+	case <-ctx.Done():
+	}
+	return &helloworld.HelloReply{Message: "Hello " + in.Name}, nil
+}
+
+// serveGreeter start the greeter server with the server properties on random port
+func serveGreeter(t *testing.T, properties *server, delay time.Duration) (address string, stop func()) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Equal(t, nil, err)
+	s := grpc.NewServer(serverOptions(properties)...)
+	helloworld.RegisterGreeterServer(s, &slowGreeter{delay: delay})
+	go s.Serve(lis)
+	return lis.Addr().String(), s.Stop
+}
+
+// dialGreeter dial the greeter server with the client properties
+func dialGreeter(t *testing.T, address string, properties *ClientProperties) (helloworld.GreeterClient, func() error) {
+	opts := append(dialOptions(properties),
+		grpc.WithInsecure(),
+		grpc.WithUnaryInterceptor(newTimeoutUnaryClientInterceptor(seconds(properties.TimeoutSecond))),
+	)
+	conn, err := grpc.Dial(address, opts...)
+	assert.Equal(t, nil, err)
+	return helloworld.NewGreeterClient(conn), conn.Close
+}
+
+func TestOptions(t *testing.T) {
+	t.Run("should create server options", func(t *testing.T) {
+		assert.Equal(t, 2, len(serverOptions(&server{})))
+		assert.Equal(t, 5, len(serverOptions(&server{MaxRecvMsgSize: 1, MaxSendMsgSize: 1, MaxConcurrentStreams: 1})))
+	})
+
+	t.Run("should create dial options", func(t *testing.T) {
+		assert.Equal(t, 0, len(dialOptions(&ClientProperties{})))
+		assert.Equal(t, 2, len(dialOptions(&ClientProperties{
+			MaxRecvMsgSize: 1,
+			MaxSendMsgSize: 1,
+			KeepAlive:      keepAlive{Enabled: true, Delay: 10, Timeout: 20},
+		})))
+	})
+
+	t.Run("should reject the message that exceeds max receive message size of server", func(t *testing.T) {
+		address, stop := serveGreeter(t, &server{MaxRecvMsgSize: 64}, 0)
+		defer stop()
+		client, closeConn := dialGreeter(t, address, &ClientProperties{})
+		defer closeConn()
+
+		_, err := client.SayHello(context.Background(), &helloworld.HelloRequest{Name: "Steve"})
+		assert.Equal(t, nil, err)
+
+		_, err = client.SayHello(context.Background(), &helloworld.HelloRequest{Name: strings.Repeat("x", 128)})
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	})
+
+	t.Run("should reject the message that exceeds max send message size of client", func(t *testing.T) {
+		address, stop := serveGreeter(t, &server{}, 0)
+		defer stop()
+		client, closeConn := dialGreeter(t, address, &ClientProperties{MaxSendMsgSize: 64})
+		defer closeConn()
+
+		_, err := client.SayHello(context.Background(), &helloworld.HelloRequest{Name: strings.Repeat("x", 128)})
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	})
+
+	t.Run("should apply default deadline of client", func(t *testing.T) {
+		address, stop := serveGreeter(t, &server{}, 3*time.Second)
+		defer stop()
+		client, closeConn := dialGreeter(t, address, &ClientProperties{TimeoutSecond: 1})
+		defer closeConn()
+
+		start := time.Now()
+		_, err := client.SayHello(context.Background(), &helloworld.HelloRequest{Name: "Steve"})
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+		assert.Equal(t, true, time.Since(start) < 3*time.Second)
+	})
+
+	t.Run("should keep the deadline of caller", func(t *testing.T) {
+		var deadline time.Time
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		expected, _ := ctx.Deadline()
+		err := newTimeoutUnaryClientInterceptor(time.Second)(ctx, "/foo", nil, nil, nil,
+			func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				deadline, _ = ctx.Deadline()
+				return nil
+			})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, expected, deadline)
+	})
+
+	t.Run("should not set deadline if timeout is 0", func(t *testing.T) {
+		err := newTimeoutUnaryClientInterceptor(0)(context.Background(), "/foo", nil, nil, nil,
+			func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				_, ok := ctx.Deadline()
+				assert.Equal(t, false, ok)
+				return nil
+			})
+		assert.Equal(t, nil, err)
+	})
+}
@@ -0,0 +1,49 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/factory"
+)
+
+type postProcessor struct {
+	configurableFactory factory.ConfigurableFactory
+	interceptors        *Interceptors
+}
+
+func init() {
+	// register postProcessor
+	app.RegisterPostProcessor(newPostProcessor)
+}
+
+func newPostProcessor(configurableFactory factory.ConfigurableFactory, interceptors *Interceptors) *postProcessor {
+	return &postProcessor{
+		configurableFactory: configurableFactory,
+		interceptors:        interceptors,
+	}
+}
+
+// AfterInitialization add the interceptor components that annotated by at.GrpcInterceptor
+func (p *postProcessor) AfterInitialization() {
+	interceptors := p.configurableFactory.GetInstances(new(at.GrpcInterceptor))
+	for _, md := range interceptors {
+		metaData := factory.CastMetaData(md)
+		if metaData.Instance != nil {
+			p.interceptors.Add(metaData.Instance)
+		}
+	}
+}
@@ -5,7 +5,8 @@ import "time"
 type server struct {
 	Enabled bool `json:"enabled" default:"false"`
 
-	// The network must be "tcp", "tcp4", "tcp6", "unix" or "unixpacket".
+	// The network must be "tcp", "tcp4", "tcp6", "unix" or "unixpacket",
+	// or "bufconn" that serves on the in-memory listener for testing, see NewTestApp.
 	Network string `json:"network" default:"tcp"`
 
 	// The address can use a host name, but this is not recommended,
@@ -20,23 +21,145 @@ type server struct {
 	Host string `json:"host"`
 	// server port, default is 7575
 	Port string `json:"port" default:"7575"`
+	// TLS the server tls, the client cert is required if tls.client_auth is true
+	TLS TLS `json:"tls"`
+	// MaxRecvMsgSize is the max message size in bytes the server can receive, 0 means the grpc default 4MB
+	MaxRecvMsgSize int `json:"max_recv_msg_size"`
+	// MaxSendMsgSize is the max message size in bytes the server can send, 0 means the grpc default
+	MaxSendMsgSize int `json:"max_send_msg_size"`
+	// MaxConcurrentStreams is the max number of concurrent streams of each client connection, 0 means no limit
+	MaxConcurrentStreams uint32 `json:"max_concurrent_streams"`
+	// KeepAlive the server keepalive parameters and enforcement policy
+	KeepAlive serverKeepAlive `json:"keep_alive"`
+	// Gateway the REST gateway that transcodes the http routes into the calls against the grpc server
+	Gateway gateway `json:"gateway"`
+	// Reflection registers the server reflection service
+	Reflection bool `json:"reflection" default:"true"`
+	// Jwt verifies the bearer token of the authorization metadata with the verify key of the jwt starter
+	Jwt serverJwt `json:"jwt"`
 }
 
+// serverJwt the token verification properties of the server
+type serverJwt struct {
+	Enabled bool `json:"enabled"`
+	// Excludes is the services or the full methods that are not verified, e.g. grpc.health.v1.Health
+	Excludes []string `json:"excludes" default:"grpc.health.v1.Health,grpc.reflection.v1alpha.ServerReflection"`
+}
+
+// gateway the REST gateway properties, the routes are read from the google.api.http annotations
+// of the registered servers and the routes below
+type gateway struct {
+	Enabled bool           `json:"enabled"`
+	Routes  []GatewayRoute `json:"routes"`
+}
+
+// serverKeepAlive the server keepalive properties in seconds, 0 means the grpc default
+type serverKeepAlive struct {
+	// MaxConnectionIdle closes the connection that is idle for the seconds
+	MaxConnectionIdle uint64 `json:"max_connection_idle"`
+	// MaxConnectionAge closes the connection that exists for the seconds
+	MaxConnectionAge uint64 `json:"max_connection_age"`
+	// MaxConnectionAgeGrace is the additive period after MaxConnectionAge to complete the pending rpcs
+	MaxConnectionAgeGrace uint64 `json:"max_connection_age_grace"`
+	// Time pings the client after the connection is idle for the seconds
+	Time uint64 `json:"time"`
+	// Timeout closes the connection if the ping is not acknowledged in the seconds
+	Timeout uint64 `json:"timeout"`
+	// MinTime is the min seconds a client should wait before sending a keepalive ping,
+	// the connection is closed if the client pings too frequently, it allows the default delay of the clients
+	MinTime uint64 `json:"min_time" default:"10"`
+	// PermitWithoutStream allows the client to send keepalive pings even if there are no active streams
+	PermitWithoutStream bool `json:"permit_without_stream"`
+}
+
+// TLS the tls properties of grpc server and client
+type TLS struct {
+	// Enabled indicates whether the tls is enabled
+	Enabled bool `json:"enabled"`
+	// CertFile is the PEM encoded certificate, it is the client certificate for mutual tls on client side
+	CertFile string `json:"cert_file"`
+	// KeyFile is the PEM encoded private key of CertFile
+	KeyFile string `json:"key_file"`
+	// CAFile is the PEM encoded CA certificate, it verifies the client certificates on server side,
+	// and the server certificate on client side, the system root CAs are used on client side if it is empty
+	CAFile string `json:"ca_file"`
+	// ServerNameOverride overrides the server name that verified by client, it is for testing only
+	ServerNameOverride string `json:"server_name_override"`
+	// ClientAuth indicates whether the server requires and verifies the client certificate
+	ClientAuth bool `json:"client_auth"`
+}
+
+// keepAlive the client keepalive properties in seconds, note that the server closes the connection
+// that pings more frequently than the min_time of its enforcement policy
 type keepAlive struct {
-	Enabled bool   `json:"enabled" default:"true"`
-	Delay   uint64 `json:"delay" default:"10"`
+	Enabled bool `json:"enabled" default:"true"`
+	// Delay pings the server after the connection is idle for the seconds
+	Delay uint64 `json:"delay" default:"10"`
+	// Timeout closes the connection if the ping is not acknowledged in the seconds
 	Timeout uint64 `json:"timeout" default:"120"`
+	// PermitWithoutStream sends keepalive pings even if there are no active rpcs
+	PermitWithoutStream bool `json:"permit_without_stream"`
 }
 
 // ClientProperties used for grpc client injection
 type ClientProperties struct {
 	Host      string    `json:"host"`
 	Port      string    `json:"port" default:"7575"`
-	PlainText bool      `json:"plain_text" default:"true"`
 	KeepAlive keepAlive `json:"keep_alive"`
+	TLS       TLS       `json:"tls"`
+	// TimeoutSecond is the default deadline of the unary calls that have no deadline,
+	// grpc.timeout_second is used if it is 0
+	TimeoutSecond uint64 `json:"timeout_second"`
+	// MaxRecvMsgSize is the max message size in bytes the client can receive, 0 means the grpc default 4MB
+	MaxRecvMsgSize int `json:"max_recv_msg_size"`
+	// MaxSendMsgSize is the max message size in bytes the client can send, 0 means the grpc default
+	MaxSendMsgSize int `json:"max_send_msg_size"`
+	// Addresses is the list of host:port that the client balances across, it overrides Host and Port
+	Addresses []string `json:"addresses"`
+	// Resolver is static or dns, dns resolves the hosts of the addresses to ip addresses periodically
+	Resolver string `json:"resolver" default:"static"`
+	// RefreshInterval is the interval in seconds that the dns resolver resolves the addresses
+	RefreshInterval uint64 `json:"refresh_interval" default:"30"`
+	// LoadBalancing is the load balancing policy, pick_first or round_robin
+	LoadBalancing string `json:"load_balancing" default:"pick_first"`
+	// Retry is the retry policy of the calls
+	Retry retryPolicy `json:"retry"`
+	// HealthCheck ejects the addresses that are not serving
+	HealthCheck healthCheck `json:"health_check"`
+	// PropagateToken forwards the jwt token of current request as the authorization metadata
+	PropagateToken bool `json:"propagate_token"`
+}
+
+// retryPolicy the retry policy that is applied through the service config,
+// note that grpc enables the retry only if the environment variable GRPC_GO_RETRY=on
+type retryPolicy struct {
+	// MaxAttempts is the max number of attempts including the original call, the retry is disabled if it is less than 2
+	MaxAttempts int `json:"max_attempts"`
+	// InitialBackoff is the backoff before the first retry, e.g. 0.1s
+	InitialBackoff string `json:"initial_backoff" default:"0.1s"`
+	// MaxBackoff is the max backoff between the retries, e.g. 1s
+	MaxBackoff string `json:"max_backoff" default:"1s"`
+	// BackoffMultiplier multiplies the backoff after each retry
+	BackoffMultiplier float64 `json:"backoff_multiplier" default:"2"`
+	// RetryableStatusCodes is the status codes that are retried, e.g. UNAVAILABLE
+	RetryableStatusCodes []string `json:"retryable_status_codes" default:"UNAVAILABLE"`
+	// Services is the full names of the services that the policy applies to, e.g. helloworld.Greeter
+	Services []string `json:"services"`
+}
+
+// healthCheck checks the addresses by the health client periodically, and ejects the addresses that are not serving
+type healthCheck struct {
+	Enabled bool `json:"enabled"`
+	// Service is the service name of the health check request, empty means the overall health of the server
+	Service string `json:"service"`
+	// Interval is the interval in seconds of the health checks
+	Interval uint64 `json:"interval" default:"10"`
+	// Timeout is the timeout in seconds of each health check
+	Timeout uint64 `json:"timeout" default:"1"`
 }
 
 type properties struct {
+	// TimeoutSecond is the default deadline in seconds of the unary client calls
 	TimeoutSecond time.Duration          `json:"timeout_second"`
 	Server        server                 `json:"server"`
 	Client        map[string]interface{} `json:"client"`
@@ -0,0 +1,363 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"encoding/json"
+	"errors"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	_ "google.golang.org/grpc/balancer/roundrobin" // register the round_robin balancer
+	pb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/resolver"
+	"hidevops.io/hiboot/pkg/log"
+	"hidevops.io/hiboot/pkg/utils/cmap"
+	"net"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ResolverStatic resolves the client to its addresses as they are
+	ResolverStatic = "static"
+	// ResolverDNS resolves the hosts of the client addresses to ip addresses periodically
+	ResolverDNS = "dns"
+
+	// LoadBalancingPickFirst sends all the calls to the first address that is connected
+	LoadBalancingPickFirst = "pick_first"
+	// LoadBalancingRoundRobin balances the calls across all the addresses
+	LoadBalancingRoundRobin = "round_robin"
+
+	resolverScheme = "hiboot"
+)
+
+var (
+	// ErrUnknownClient the client resolver is not registered
+	ErrUnknownClient = errors.New("[grpc] unknown client")
+
+	// ErrInvalidLoadBalancing the load balancing policy is neither pick_first nor round_robin
+	ErrInvalidLoadBalancing = errors.New("[grpc] invalid load balancing policy, it must be pick_first or round_robin")
+
+	resolvers = cmap.New()
+)
+
+func init() {
+	resolver.Register(new(resolverBuilder))
+}
+
+// resolverBuilder builds the resolvers of the grpc clients, the resolver of each client
+// is registered by name before dialing to hiboot:///name
+type resolverBuilder struct{}
+
+// Build starts the resolver that is registered by the target endpoint
+func (b *resolverBuilder) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOption) (resolver.Resolver, error) {
+	r, ok := resolvers.Get(target.Endpoint)
+	if !ok {
+		return nil, ErrUnknownClient
+	}
+	c := r.(*clientResolver)
+	c.start(cc)
+	return c, nil
+}
+
+// Scheme returns the scheme of the resolver
+func (b *resolverBuilder) Scheme() string {
+	return resolverScheme
+}
+
+// clientResolver resolves the addresses of the client, and ejects the addresses that are not serving
+// if the health check is enabled, all the addresses are kept if none of them is serving
+type clientResolver struct {
+	name          string
+	properties    *ClientProperties
+	serviceConfig string
+	// dialOptions is the options of the health check connections
+	dialOptions []grpc.DialOption
+
+	// lookupHost resolves the host to ip addresses, it is replaceable for testing
+	lookupHost func(host string) ([]string, error)
+	// checkHealth checks if the address is serving, it is replaceable for testing
+	checkHealth func(address string) bool
+
+	mu       sync.Mutex
+	cc       resolver.ClientConn
+	resolved []string
+	healthy  []string
+	conns    map[string]*grpc.ClientConn
+	done     chan struct{}
+	once     sync.Once
+}
+
+// newClientResolver creates the resolver of the client
+func newClientResolver(name string, properties *ClientProperties) (r *clientResolver, err error) {
+	sc, err := serviceConfig(properties)
+	if err != nil {
+		return
+	}
+	r = &clientResolver{
+		name:          name,
+		properties:    properties,
+		serviceConfig: sc,
+		dialOptions:   []grpc.DialOption{grpc.WithInsecure()},
+		lookupHost:    net.LookupHost,
+		conns:         make(map[string]*grpc.ClientConn),
+		done:          make(chan struct{}),
+	}
+	r.checkHealth = r.check
+	return
+}
+
+// target returns the target that the client dials to
+func (r *clientResolver) target() string {
+	return resolverScheme + ":///" + r.name
+}
+
+// addresses returns the client addresses, host:port is used if the addresses are not specified
+func (r *clientResolver) addresses() []string {
+	if len(r.properties.Addresses) != 0 {
+		return r.properties.Addresses
+	}
+	host := r.properties.Host
+	if host == "" {
+		host = r.name
+	}
+	return []string{host + ":" + r.properties.Port}
+}
+
+// resolve resolves the client addresses, the address is kept as it is if its host can not be resolved
+func (r *clientResolver) resolve() (addresses []string) {
+	for _, address := range r.addresses() {
+		if r.properties.Resolver != ResolverDNS {
+			addresses = append(addresses, address)
+			continue
+		}
+		host, port, err := net.SplitHostPort(address)
+		if err != nil || net.ParseIP(host) != nil {
+			addresses = append(addresses, address)
+			continue
+		}
+		ips, err := r.lookupHost(host)
+		if err != nil || len(ips) == 0 {
+			log.Warnf("gRPC client %v failed to resolve %v: %v", r.name, host, err)
+			addresses = append(addresses, address)
+			continue
+		}
+		for _, ip := range ips {
+			addresses = append(addresses, net.JoinHostPort(ip, port))
+		}
+	}
+	return
+}
+
+// start notifies the client connection of the service config and the addresses,
+// and refreshes them periodically if the dns resolver or the health check is enabled
+func (r *clientResolver) start(cc resolver.ClientConn) {
+	r.mu.Lock()
+	r.cc = cc
+	r.mu.Unlock()
+
+	if r.serviceConfig != "" {
+		cc.NewServiceConfig(r.serviceConfig)
+	}
+	r.refresh()
+
+	if r.properties.Resolver == ResolverDNS {
+		go r.run(seconds(r.properties.RefreshInterval), r.refresh)
+	}
+	if r.properties.HealthCheck.Enabled {
+		go r.run(seconds(r.properties.HealthCheck.Interval), r.eject)
+	}
+}
+
+// run calls fn periodically until the resolver is closed
+func (r *clientResolver) run(interval time.Duration, fn func()) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C:
+			fn()
+		}
+	}
+}
+
+// refresh resolves the addresses and ejects the addresses that are not serving
+func (r *clientResolver) refresh() {
+	resolved := r.resolve()
+	r.mu.Lock()
+	r.resolved = resolved
+	r.mu.Unlock()
+	r.eject()
+}
+
+// eject checks the health of the resolved addresses, and notifies the client connection of the serving addresses
+func (r *clientResolver) eject() {
+	r.mu.Lock()
+	resolved := r.resolved
+	r.mu.Unlock()
+
+	healthy := resolved
+	if r.properties.HealthCheck.Enabled {
+		healthy = nil
+		for _, address := range resolved {
+			if r.checkHealth(address) {
+				healthy = append(healthy, address)
+			}
+		}
+		if len(healthy) == 0 {
+			healthy = resolved
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cc == nil || reflect.DeepEqual(healthy, r.healthy) {
+		return
+	}
+	r.healthy = healthy
+	var addrs []resolver.Address
+	for _, address := range healthy {
+		addrs = append(addrs, resolver.Address{Addr: address})
+	}
+	r.cc.NewAddress(addrs)
+	log.Debugf("gRPC client %v resolved to %v", r.name, healthy)
+}
+
+// serverName returns the host of the first address, the client verifies the server certificate against it
+func (r *clientResolver) serverName() string {
+	address := r.addresses()[0]
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+	return host
+}
+
+// check checks if the address is serving by the health client
+func (r *clientResolver) check(address string) bool {
+	r.mu.Lock()
+	conn, ok := r.conns[address]
+	if !ok {
+		var err error
+		conn, err = grpc.Dial(address, r.dialOptions...)
+		if err != nil {
+			r.mu.Unlock()
+			return false
+		}
+		r.conns[address] = conn
+	}
+	r.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), seconds(r.properties.HealthCheck.Timeout))
+	defer cancel()
+	// wait for the connection that is not ready yet until timeout
+	resp, err := pb.NewHealthClient(conn).Check(ctx,
+		&pb.HealthCheckRequest{Service: r.properties.HealthCheck.Service}, grpc.FailFast(false))
+	if err != nil {
+		log.Debugf("gRPC client %v health check on %v failed: %v", r.name, address, err)
+		return false
+	}
+	return resp.Status == pb.HealthCheckResponse_SERVING
+}
+
+// ResolveNow resolves the addresses again
+func (r *clientResolver) ResolveNow(resolver.ResolveNowOption) {
+	go r.refresh()
+}
+
+// Close stops refreshing the addresses and closes the health check connections
+func (r *clientResolver) Close() {
+	r.once.Do(func() {
+		close(r.done)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for address, conn := range r.conns {
+			conn.Close()
+			delete(r.conns, address)
+		}
+	})
+}
+
+// jsonRetryPolicy is the retry policy of the service config
+type jsonRetryPolicy struct {
+	MaxAttempts          int      `json:"maxAttempts"`
+	InitialBackoff       string   `json:"initialBackoff"`
+	MaxBackoff           string   `json:"maxBackoff"`
+	BackoffMultiplier    float64  `json:"backoffMultiplier"`
+	RetryableStatusCodes []string `json:"retryableStatusCodes"`
+}
+
+type jsonName struct {
+	Service string `json:"service"`
+}
+
+type jsonMethodConfig struct {
+	Name        []jsonName       `json:"name"`
+	RetryPolicy *jsonRetryPolicy `json:"retryPolicy"`
+}
+
+type jsonServiceConfig struct {
+	LoadBalancingPolicy string             `json:"loadBalancingPolicy,omitempty"`
+	MethodConfig        []jsonMethodConfig `json:"methodConfig,omitempty"`
+}
+
+// serviceConfig creates the service config of the load balancing policy and the retry policy
+func serviceConfig(properties *ClientProperties) (sc string, err error) {
+	var jsc jsonServiceConfig
+	switch properties.LoadBalancing {
+	case "", LoadBalancingPickFirst:
+	case LoadBalancingRoundRobin:
+		jsc.LoadBalancingPolicy = LoadBalancingRoundRobin
+	default:
+		return "", ErrInvalidLoadBalancing
+	}
+
+	retry := properties.Retry
+	if retry.MaxAttempts > 1 && len(retry.Services) != 0 {
+		if os.Getenv("GRPC_GO_RETRY") != "on" {
+			log.Warn("gRPC client retry policy takes effect only if the environment variable GRPC_GO_RETRY=on")
+		}
+		mc := jsonMethodConfig{
+			RetryPolicy: &jsonRetryPolicy{
+				MaxAttempts:       retry.MaxAttempts,
+				InitialBackoff:    retry.InitialBackoff,
+				MaxBackoff:        retry.MaxBackoff,
+				BackoffMultiplier: retry.BackoffMultiplier,
+			},
+		}
+		for _, code := range retry.RetryableStatusCodes {
+			mc.RetryPolicy.RetryableStatusCodes = append(mc.RetryPolicy.RetryableStatusCodes, strings.ToUpper(code))
+		}
+		for _, service := range retry.Services {
+			mc.Name = append(mc.Name, jsonName{Service: service})
+		}
+		jsc.MethodConfig = append(jsc.MethodConfig, mc)
+	}
+
+	if jsc.LoadBalancingPolicy == "" && len(jsc.MethodConfig) == 0 {
+		return
+	}
+	b, err := json.Marshal(&jsc)
+	sc = string(b)
+	return
+}
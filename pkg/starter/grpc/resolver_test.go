@@ -0,0 +1,187 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/examples/helloworld/helloworld"
+	"google.golang.org/grpc/health"
+	pb "google.golang.org/grpc/health/grpc_health_v1"
+	"net"
+	"testing"
+	"time"
+)
+
+// namedGreeter replies with its name
+type namedGreeter struct {
+	name string
+}
+
+func (g *namedGreeter) SayHello(ctx context.Context, in *helloworld.HelloRequest) (*helloworld.HelloReply, error) {
+	return &helloworld.HelloReply{Message: g.name}, nil
+}
+
+func startNamedGreeter(t *testing.T, name string) (address string, healthServer *health.Server, stop func()) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Equal(t, nil, err)
+	server := grpc.NewServer()
+	healthServer = health.NewServer()
+	healthServer.SetServingStatus("helloworld.Greeter", pb.HealthCheckResponse_SERVING)
+	helloworld.RegisterGreeterServer(server, &namedGreeter{name: name})
+	pb.RegisterHealthServer(server, healthServer)
+	go server.Serve(lis)
+	return lis.Addr().String(), healthServer, server.Stop
+}
+
+// waitFor calls the greeter until the replies match the names or timeout
+func waitFor(cli helloworld.GreeterClient, names ...string) bool {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		replies := make(map[string]bool)
+		for i := 0; i < 10; i++ {
+			resp, err := cli.SayHello(context.Background(), &helloworld.HelloRequest{})
+			if err == nil {
+				replies[resp.Message] = true
+			}
+		}
+		matched := len(replies) == len(names)
+		for _, name := range names {
+			matched = matched && replies[name]
+		}
+		if matched {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func TestServiceConfig(t *testing.T) {
+	t.Run("should not create service config by default", func(t *testing.T) {
+		sc, err := serviceConfig(&ClientProperties{LoadBalancing: LoadBalancingPickFirst})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "", sc)
+	})
+
+	t.Run("should create service config of round robin and retry policy", func(t *testing.T) {
+		sc, err := serviceConfig(&ClientProperties{
+			LoadBalancing: LoadBalancingRoundRobin,
+			Retry: retryPolicy{
+				MaxAttempts:          3,
+				InitialBackoff:       "0.1s",
+				MaxBackoff:           "1s",
+				BackoffMultiplier:    2,
+				RetryableStatusCodes: []string{"unavailable"},
+				Services:             []string{"helloworld.Greeter"},
+			},
+		})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, `{"loadBalancingPolicy":"round_robin","methodConfig":[{"name":[{"service":"helloworld.Greeter"}],`+
+			`"retryPolicy":{"maxAttempts":3,"initialBackoff":"0.1s","maxBackoff":"1s","backoffMultiplier":2,"retryableStatusCodes":["UNAVAILABLE"]}}]}`, sc)
+	})
+
+	t.Run("should not create retry policy without services", func(t *testing.T) {
+		sc, err := serviceConfig(&ClientProperties{Retry: retryPolicy{MaxAttempts: 3}})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "", sc)
+	})
+
+	t.Run("should report error of invalid load balancing policy", func(t *testing.T) {
+		_, err := serviceConfig(&ClientProperties{LoadBalancing: "foo"})
+		assert.Equal(t, ErrInvalidLoadBalancing, err)
+	})
+}
+
+func TestClientResolver(t *testing.T) {
+	t.Run("should resolve to host and port by default", func(t *testing.T) {
+		r, err := newClientResolver("greeter-service", &ClientProperties{Port: "7575"})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "hiboot:///greeter-service", r.target())
+		assert.Equal(t, []string{"greeter-service:7575"}, r.resolve())
+		assert.Equal(t, "greeter-service", r.serverName())
+	})
+
+	t.Run("should resolve the hosts of addresses by dns", func(t *testing.T) {
+		r, err := newClientResolver("greeter-service", &ClientProperties{
+			Resolver:  ResolverDNS,
+			Addresses: []string{"greeter:7575", "127.0.0.1:7576", "unknown:7577"},
+		})
+		assert.Equal(t, nil, err)
+		r.lookupHost = func(host string) ([]string, error) {
+			if host == "greeter" {
+				return []string{"10.0.0.1", "10.0.0.2"}, nil
+			}
+			return nil, errors.New("no such host")
+		}
+		assert.Equal(t, []string{"10.0.0.1:7575", "10.0.0.2:7575", "127.0.0.1:7576", "unknown:7577"}, r.resolve())
+	})
+
+	t.Run("should report error of unknown client", func(t *testing.T) {
+		_, err := grpc.Dial("hiboot:///unknown", grpc.WithInsecure())
+		assert.NotEqual(t, nil, err)
+	})
+
+	addressA, _, stopA := startNamedGreeter(t, "a")
+	defer stopA()
+	addressB, healthB, stopB := startNamedGreeter(t, "b")
+	defer stopB()
+
+	r, err := newClientResolver("round-robin-greeter", &ClientProperties{
+		Addresses:     []string{addressA, addressB},
+		LoadBalancing: LoadBalancingRoundRobin,
+		HealthCheck:   healthCheck{Enabled: true, Service: "helloworld.Greeter", Timeout: 1},
+	})
+	assert.Equal(t, nil, err)
+	resolvers.Set("round-robin-greeter", r)
+	defer resolvers.Remove("round-robin-greeter")
+
+	conn, err := grpc.Dial(r.target(), grpc.WithInsecure())
+	assert.Equal(t, nil, err)
+	defer conn.Close()
+	cli := helloworld.NewGreeterClient(conn)
+
+	t.Run("should balance the calls across the addresses", func(t *testing.T) {
+		assert.Equal(t, true, waitFor(cli, "a", "b"))
+	})
+
+	t.Run("should eject the address that is not serving", func(t *testing.T) {
+		healthB.SetServingStatus("helloworld.Greeter", pb.HealthCheckResponse_NOT_SERVING)
+		r.eject()
+		assert.Equal(t, []string{addressA}, r.healthy)
+		assert.Equal(t, true, waitFor(cli, "a"))
+	})
+
+	t.Run("should add back the address that is serving again", func(t *testing.T) {
+		healthB.SetServingStatus("helloworld.Greeter", pb.HealthCheckResponse_SERVING)
+		r.eject()
+		assert.Equal(t, []string{addressA, addressB}, r.healthy)
+		assert.Equal(t, true, waitFor(cli, "a", "b"))
+	})
+
+	t.Run("should keep all the addresses if none of them is serving", func(t *testing.T) {
+		r.checkHealth = func(address string) bool { return false }
+		r.eject()
+		assert.Equal(t, []string{addressA, addressB}, r.healthy)
+	})
+
+	t.Run("should close the health check connections", func(t *testing.T) {
+		r.Close()
+		r.Close()
+		assert.Equal(t, 0, len(r.conns))
+	})
+}
@@ -16,49 +16,122 @@ package grpc
 
 import (
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	pb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 	"hidevops.io/hiboot/pkg/factory"
 	"hidevops.io/hiboot/pkg/log"
 	"hidevops.io/hiboot/pkg/utils/reflector"
 	"net"
+	"sync"
 )
 
 // ServerFactory build grpc servers
 type ServerFactory interface {
+	// Err returns the error that the server failed to listen or serve with
+	Err() error
+	// Shutdown marks all the services as NOT_SERVING and stops the server gracefully
+	Shutdown()
 }
 
 type serverFactory struct {
+	grpcServer   *grpc.Server
+	healthServer *health.Server
+	mu           sync.Mutex
+	err          error
+	// fail stops the application run with the error that the server failed to listen or serve with
+	fail func(err error)
 }
 
-func newServerFactory(instantiateFactory factory.InstantiateFactory, properties properties, grpcServer *grpc.Server) ServerFactory {
-	sf := &serverFactory{}
+func newServerFactory(instantiateFactory factory.InstantiateFactory, properties properties, grpcServer *grpc.Server, inProcess *inProcessListener, fail func(err error)) ServerFactory {
+	sf := &serverFactory{grpcServer: grpcServer, fail: fail}
 
 	// just return if grpc server is not enabled
-	if properties.Server.Enabled && grpcServer != nil {
-		address := properties.Server.Host + ":" + properties.Server.Port
-		lis, err := net.Listen(properties.Server.Network, address)
-		if err == nil {
-			// register server
-			// Register reflection service on gRPC server.
-			chn := make(chan bool)
-			go func() {
-				for _, srv := range grpcServers {
-					svc := instantiateFactory.GetInstance(srv.name)
-					reflector.CallFunc(srv.cb, grpcServer, svc)
-					if err == nil {
-						log.Infof("Registered %v on gRPC server", srv.name)
-					}
-				}
-				reflection.Register(grpcServer)
-				chn <- true
-				grpcServer.Serve(lis)
-			}()
-			<-chn
+	if !properties.Server.Enabled || grpcServer == nil {
+		return sf
+	}
 
-			log.Infof("gRPC server listening on: localhost%v", address)
-		}
+	var lis net.Listener
+	var err error
+	address := properties.Server.Host + ":" + properties.Server.Port
+	if properties.Server.Network == NetworkInProcess {
+		// serve on the in-memory listener that the grpc clients dial to
+		address = NetworkInProcess
+		lis = inProcess.get()
+	} else {
+		lis, err = net.Listen(properties.Server.Network, address)
+	}
+	if err != nil {
+		log.Errorf("gRPC server failed to listen on %v %v: %v", properties.Server.Network, address, err)
+		sf.setErr(err)
+		return sf
+	}
 
+	// register servers
+	for _, srv := range grpcServers {
+		svc := instantiateFactory.GetInstance(srv.name)
+		if _, err = reflector.CallFunc(srv.cb, grpcServer, svc); err != nil {
+			log.Errorf("failed to register %v on gRPC server: %v", srv.name, err)
+			continue
+		}
+		if hs, ok := svc.(*health.Server); ok {
+			sf.healthServer = hs
+		}
+		log.Infof("Registered %v on gRPC server", srv.name)
 	}
+	// Register reflection service on gRPC server.
+	if properties.Server.Reflection {
+		reflection.Register(grpcServer)
+	}
+	sf.setServingStatus(pb.HealthCheckResponse_SERVING)
+
+	go func() {
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Errorf("gRPC server failed to serve on %v %v: %v", properties.Server.Network, address, err)
+			sf.setErr(err)
+			sf.setServingStatus(pb.HealthCheckResponse_NOT_SERVING)
+		}
+	}()
+	log.Infof("gRPC server listening on: %v %v", properties.Server.Network, address)
 
 	return sf
 }
+
+// setServingStatus set the serving status of all the registered services
+func (sf *serverFactory) setServingStatus(status pb.HealthCheckResponse_ServingStatus) {
+	if sf.healthServer == nil {
+		return
+	}
+	sf.healthServer.SetServingStatus("", status)
+	for service := range sf.grpcServer.GetServiceInfo() {
+		sf.healthServer.SetServingStatus(service, status)
+	}
+}
+
+// setErr keep the error and fail the application run with it
+func (sf *serverFactory) setErr(err error) {
+	sf.mu.Lock()
+	sf.err = err
+	sf.mu.Unlock()
+
+	if sf.fail != nil {
+		sf.fail(err)
+	}
+}
+
+// Err returns the error that the server failed to listen or serve with
+func (sf *serverFactory) Err() error {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	return sf.err
+}
+
+// Shutdown marks all the services as NOT_SERVING and stops the server gracefully
+func (sf *serverFactory) Shutdown() {
+	if sf.grpcServer == nil {
+		return
+	}
+	sf.setServingStatus(pb.HealthCheckResponse_NOT_SERVING)
+	sf.grpcServer.GracefulStop()
+	log.Info("gRPC server is stopped")
+}
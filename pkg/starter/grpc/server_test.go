@@ -0,0 +1,67 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"hidevops.io/hiboot/pkg/app/fake"
+	"net"
+	"testing"
+)
+
+// failingApplicationContext records the failure of the application
+type failingApplicationContext struct {
+	fake.ApplicationContext
+	failure error
+}
+
+func (a *failingApplicationContext) Fail(err error) {
+	a.failure = err
+}
+
+func TestServerFactory(t *testing.T) {
+	t.Run("should fail the application if the server failed to listen", func(t *testing.T) {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.Equal(t, nil, err)
+		defer lis.Close()
+		_, port, _ := net.SplitHostPort(lis.Addr().String())
+
+		var p properties
+		p.Server.Enabled = true
+		p.Server.Network = "tcp"
+		p.Server.Host = "127.0.0.1"
+		p.Server.Port = port
+
+		var failure error
+		sf := newServerFactory(nil, p, grpc.NewServer(), nil, func(err error) {
+			failure = err
+		})
+		assert.NotEqual(t, nil, sf.Err())
+		assert.Equal(t, sf.Err(), failure)
+	})
+
+	t.Run("should fail the application if the server tls credentials are not able to be loaded", func(t *testing.T) {
+		applicationContext := new(failingApplicationContext)
+		c := newConfiguration(nil, applicationContext)
+		c.Properties.Server.Enabled = true
+		c.Properties.Server.TLS.Enabled = true
+		c.Properties.Server.TLS.CertFile = "config/certs/not-found.pem"
+		c.Properties.Server.TLS.KeyFile = "config/certs/not-found-key.pem"
+
+		assert.Equal(t, (*grpc.Server)(nil), c.Server(newInterceptors()))
+		assert.NotEqual(t, nil, applicationContext.failure)
+	})
+}
@@ -0,0 +1,33 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"hidevops.io/hiboot/pkg/app/web"
+	"testing"
+)
+
+// NewTestApp returns the test application that serves the grpc server on the in-memory listener,
+// and the registered grpc clients dial to it, so that the end-to-end grpc tests are fast and port-free
+func NewTestApp(controllers ...interface{}) web.TestApplication {
+	return web.NewTestApp(controllers...).
+		SetProperty("grpc.server.enabled", true).
+		SetProperty("grpc.server.network", NetworkInProcess)
+}
+
+// RunTestApplication run the test application that serves the grpc server on the in-memory listener
+func RunTestApplication(t *testing.T, controllers ...interface{}) web.TestApplication {
+	return NewTestApp(controllers...).Run(t)
+}
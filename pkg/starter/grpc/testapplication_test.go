@@ -0,0 +1,83 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/starter/grpc"
+	"testing"
+)
+
+func TestInProcessGrpcServerAndClient(t *testing.T) {
+	testApp := grpc.NewTestApp().
+		SetProperty("grpc.server.reflection", false).
+		Run(t)
+	applicationContext := testApp.(app.ApplicationContext)
+	conn := applicationContext.GetInstance("greeter-service").(*gogrpc.ClientConn)
+
+	t.Run("should start the gRpc server without error", func(t *testing.T) {
+		sf := applicationContext.GetInstance(new(grpc.ServerFactory)).(grpc.ServerFactory)
+		assert.Equal(t, nil, sf.Err())
+	})
+
+	t.Run("should dial to the in-process gRpc server", func(t *testing.T) {
+		assert.Equal(t, grpc.NetworkInProcess, conn.Target())
+	})
+
+	t.Run("should call the gRpc service through the in-memory listener", func(t *testing.T) {
+		greeterCliSvc := applicationContext.GetInstance(greeterClientService{}).(*greeterClientService)
+		resp, err := greeterCliSvc.SayHello("Steve")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "Hello Steve", resp.Message)
+	})
+
+	t.Run("should get health status through the in-memory listener", func(t *testing.T) {
+		healthCheckService := applicationContext.GetInstance(grpc.HealthCheckService{}).(*grpc.HealthCheckService)
+		assert.Equal(t, true, healthCheckService.Status())
+	})
+
+	healthClient := grpc_health_v1.NewHealthClient(conn)
+	t.Run("should mark the registered services as serving", func(t *testing.T) {
+		resp, err := healthClient.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "helloworld.Greeter"})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.Status)
+	})
+
+	t.Run("should not register reflection service if it is disabled", func(t *testing.T) {
+		stream, err := grpc_reflection_v1alpha.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+		assert.Equal(t, nil, err)
+		err = stream.Send(&grpc_reflection_v1alpha.ServerReflectionRequest{
+			MessageRequest: &grpc_reflection_v1alpha.ServerReflectionRequest_ListServices{},
+		})
+		if err == nil {
+			_, err = stream.Recv()
+		}
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+
+	t.Run("should stop the gRpc server gracefully on shutdown", func(t *testing.T) {
+		testApp.(interface{ Shutdown() }).Shutdown()
+		greeterCliSvc := applicationContext.GetInstance(greeterClientService{}).(*greeterClientService)
+		_, err := greeterCliSvc.SayHello("Steve")
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+}
@@ -0,0 +1,90 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"google.golang.org/grpc/credentials"
+	"io/ioutil"
+)
+
+// loadCertPool load the PEM encoded CA certificates from file
+func loadCertPool(caFile string) (pool *x509.CertPool, err error) {
+	var pem []byte
+	pem, err = ioutil.ReadFile(caFile)
+	if err != nil {
+		return
+	}
+	pool = x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		err = fmt.Errorf("failed to append certificates from %v", caFile)
+	}
+	return
+}
+
+// newServerCredentials create the server transport credentials, the client certificate
+// is required and verified by the CA if ClientAuth is true
+func newServerCredentials(properties *TLS) (creds credentials.TransportCredentials, err error) {
+	if properties.CertFile == "" || properties.KeyFile == "" {
+		err = fmt.Errorf("cert_file and key_file are required by grpc server tls")
+		return
+	}
+	cert, err := tls.LoadX509KeyPair(properties.CertFile, properties.KeyFile)
+	if err != nil {
+		return
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+	}
+	if properties.ClientAuth {
+		if properties.CAFile == "" {
+			err = fmt.Errorf("ca_file is required by grpc server tls client auth")
+			return
+		}
+		config.ClientCAs, err = loadCertPool(properties.CAFile)
+		if err != nil {
+			return
+		}
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	creds = credentials.NewTLS(config)
+	return
+}
+
+// newClientCredentials create the client transport credentials, the client certificate
+// is presented for mutual tls if both CertFile and KeyFile are specified
+func newClientCredentials(properties *TLS) (creds credentials.TransportCredentials, err error) {
+	config := &tls.Config{
+		ServerName: properties.ServerNameOverride,
+	}
+	if properties.CAFile != "" {
+		config.RootCAs, err = loadCertPool(properties.CAFile)
+		if err != nil {
+			return
+		}
+	}
+	if properties.CertFile != "" && properties.KeyFile != "" {
+		var cert tls.Certificate
+		cert, err = tls.LoadX509KeyPair(properties.CertFile, properties.KeyFile)
+		if err != nil {
+			return
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	creds = credentials.NewTLS(config)
+	return
+}
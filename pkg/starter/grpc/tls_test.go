@@ -0,0 +1,203 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	pb "google.golang.org/grpc/health/grpc_health_v1"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCert struct {
+	cert     *x509.Certificate
+	key      *ecdsa.PrivateKey
+	certFile string
+	keyFile  string
+}
+
+// newTestCert generate the certificate signed by parent, it is self-signed if parent is nil
+func newTestCert(t *testing.T, dir, name string, parent *testCert, isCA bool) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Equal(t, nil, err)
+
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if isCA {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	}
+	signerCert, signerKey := template, key
+	if parent != nil {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, &key.PublicKey, signerKey)
+	assert.Equal(t, nil, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.Equal(t, nil, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.Equal(t, nil, err)
+
+	tc := &testCert{
+		cert:     cert,
+		key:      key,
+		certFile: filepath.Join(dir, name+".crt"),
+		keyFile:  filepath.Join(dir, name+".key"),
+	}
+	err = ioutil.WriteFile(tc.certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	assert.Equal(t, nil, err)
+	err = ioutil.WriteFile(tc.keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+	assert.Equal(t, nil, err)
+	return tc
+}
+
+// serveTLS start the grpc health server with tls on random port
+func serveTLS(t *testing.T, properties *TLS) (address string, stop func()) {
+	creds, err := newServerCredentials(properties)
+	assert.Equal(t, nil, err)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Equal(t, nil, err)
+	server := grpc.NewServer(grpc.Creds(creds))
+	pb.RegisterHealthServer(server, health.NewServer())
+	go server.Serve(lis)
+	return lis.Addr().String(), server.Stop
+}
+
+// checkHealth dial the server with tls and call the health check
+func checkHealth(t *testing.T, address string, properties *TLS) error {
+	creds, err := newClientCredentials(properties)
+	assert.Equal(t, nil, err)
+	conn, err := grpc.Dial(address, grpc.WithTransportCredentials(creds))
+	assert.Equal(t, nil, err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = pb.NewHealthClient(conn).Check(ctx, &pb.HealthCheckRequest{})
+	return err
+}
+
+func TestTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hiboot-grpc-tls")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCert(t, dir, "ca", nil, true)
+	serverCert := newTestCert(t, dir, "server.hiboot.local", ca, false)
+	clientCert := newTestCert(t, dir, "client", ca, false)
+	otherCA := newTestCert(t, dir, "other-ca", nil, true)
+	otherClientCert := newTestCert(t, dir, "other-client", otherCA, false)
+
+	t.Run("should connect to tls server", func(t *testing.T) {
+		address, stop := serveTLS(t, &TLS{Enabled: true, CertFile: serverCert.certFile, KeyFile: serverCert.keyFile})
+		defer stop()
+
+		err := checkHealth(t, address, &TLS{
+			Enabled:            true,
+			CAFile:             ca.certFile,
+			ServerNameOverride: "server.hiboot.local",
+		})
+		assert.Equal(t, nil, err)
+	})
+
+	t.Run("should fail to connect to server with untrusted certificate", func(t *testing.T) {
+		address, stop := serveTLS(t, &TLS{Enabled: true, CertFile: serverCert.certFile, KeyFile: serverCert.keyFile})
+		defer stop()
+
+		err := checkHealth(t, address, &TLS{
+			Enabled:            true,
+			CAFile:             otherCA.certFile,
+			ServerNameOverride: "server.hiboot.local",
+		})
+		assert.NotEqual(t, nil, err)
+	})
+
+	t.Run("should connect to mutual tls server with client certificate", func(t *testing.T) {
+		address, stop := serveTLS(t, &TLS{
+			Enabled:    true,
+			CertFile:   serverCert.certFile,
+			KeyFile:    serverCert.keyFile,
+			CAFile:     ca.certFile,
+			ClientAuth: true,
+		})
+		defer stop()
+
+		err := checkHealth(t, address, &TLS{
+			Enabled:            true,
+			CertFile:           clientCert.certFile,
+			KeyFile:            clientCert.keyFile,
+			CAFile:             ca.certFile,
+			ServerNameOverride: "server.hiboot.local",
+		})
+		assert.Equal(t, nil, err)
+
+		err = checkHealth(t, address, &TLS{
+			Enabled:            true,
+			CAFile:             ca.certFile,
+			ServerNameOverride: "server.hiboot.local",
+		})
+		assert.NotEqual(t, nil, err)
+
+		err = checkHealth(t, address, &TLS{
+			Enabled:            true,
+			CertFile:           otherClientCert.certFile,
+			KeyFile:            otherClientCert.keyFile,
+			CAFile:             ca.certFile,
+			ServerNameOverride: "server.hiboot.local",
+		})
+		assert.NotEqual(t, nil, err)
+	})
+
+	t.Run("should report error of invalid tls properties", func(t *testing.T) {
+		_, err := newServerCredentials(&TLS{Enabled: true})
+		assert.NotEqual(t, nil, err)
+
+		_, err = newServerCredentials(&TLS{Enabled: true, CertFile: serverCert.certFile, KeyFile: serverCert.keyFile, ClientAuth: true})
+		assert.NotEqual(t, nil, err)
+
+		_, err = newServerCredentials(&TLS{Enabled: true, CertFile: "not-exist.crt", KeyFile: "not-exist.key"})
+		assert.NotEqual(t, nil, err)
+
+		_, err = newClientCredentials(&TLS{Enabled: true, CAFile: "not-exist.crt"})
+		assert.NotEqual(t, nil, err)
+
+		_, err = newClientCredentials(&TLS{Enabled: true, CAFile: serverCert.keyFile})
+		assert.NotEqual(t, nil, err)
+	})
+}
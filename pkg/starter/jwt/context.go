@@ -0,0 +1,40 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"hidevops.io/hiboot/pkg/starter/jwt/jwtctx"
+)
+
+var (
+	// ErrInvalidToken the token is not valid
+	ErrInvalidToken = jwtctx.ErrInvalidToken
+
+	// NewTokenContext returns the context that carries the raw token, it is an alias of jwtctx.NewTokenContext
+	NewTokenContext = jwtctx.NewTokenContext
+
+	// TokenFromContext returns the raw token that the context carries, it is an alias of jwtctx.TokenFromContext,
+	// the jwt middleware puts the token of the web request to the request context
+	TokenFromContext = jwtctx.TokenFromContext
+
+	// NewClaimsContext returns the context that carries the claims, it is an alias of jwtctx.NewClaimsContext
+	NewClaimsContext = jwtctx.NewClaimsContext
+
+	// ClaimsFromContext returns the claims that the context carries, it is an alias of jwtctx.ClaimsFromContext
+	ClaimsFromContext = jwtctx.ClaimsFromContext
+
+	// ParseToken verifies the raw token that is signed by RS256, it is an alias of jwtctx.ParseToken
+	ParseToken = jwtctx.ParseToken
+)
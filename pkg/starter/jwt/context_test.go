@@ -0,0 +1,80 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt_test
+
+import (
+	"context"
+	jwtgo "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"hidevops.io/hiboot/pkg/starter/jwt"
+	"hidevops.io/hiboot/pkg/utils/io"
+	"testing"
+	"time"
+)
+
+func TestTokenContext(t *testing.T) {
+	io.EnsureWorkDir(1, "config/ssl/app.rsa")
+	token := jwt.NewJwtToken(&jwt.Properties{
+		PrivateKeyPath: "config/ssl/app.rsa",
+		PublicKeyPath:  "config/ssl/app.rsa.pub",
+	})
+	assert.NotEqual(t, nil, token)
+
+	tokenStr, err := token.Generate(jwt.Map{"username": "johnd"}, 10, time.Second)
+	assert.Equal(t, nil, err)
+
+	t.Run("should carry the token by context", func(t *testing.T) {
+		_, ok := jwt.TokenFromContext(context.Background())
+		assert.Equal(t, false, ok)
+		ts, ok := jwt.TokenFromContext(jwt.NewTokenContext(context.Background(), tokenStr))
+		assert.Equal(t, true, ok)
+		assert.Equal(t, tokenStr, ts)
+	})
+
+	t.Run("should carry the claims by context", func(t *testing.T) {
+		_, ok := jwt.ClaimsFromContext(context.Background())
+		assert.Equal(t, false, ok)
+		claims, ok := jwt.ClaimsFromContext(jwt.NewClaimsContext(context.Background(), jwtgo.MapClaims{"username": "johnd"}))
+		assert.Equal(t, true, ok)
+		assert.Equal(t, "johnd", claims["username"])
+	})
+
+	t.Run("should parse the token with verify key", func(t *testing.T) {
+		claims, err := jwt.ParseToken(tokenStr, token.VerifyKey())
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "johnd", claims["username"])
+	})
+
+	t.Run("should not parse the invalid token", func(t *testing.T) {
+		_, err := jwt.ParseToken(tokenStr+"x", token.VerifyKey())
+		assert.NotEqual(t, nil, err)
+		_, err = jwt.ParseToken(tokenStr, nil)
+		assert.Equal(t, jwt.ErrInvalidToken, err)
+	})
+
+	t.Run("should not parse the token that is not signed by RS256", func(t *testing.T) {
+		hs, err := jwtgo.NewWithClaims(jwtgo.SigningMethodHS256, jwtgo.MapClaims{"username": "johnd"}).SignedString([]byte("secret"))
+		assert.Equal(t, nil, err)
+		_, err = jwt.ParseToken(hs, token.VerifyKey())
+		assert.NotEqual(t, nil, err)
+	})
+
+	t.Run("should not parse the expired token", func(t *testing.T) {
+		expired, err := token.Generate(jwt.Map{"username": "johnd"}, -1, time.Second)
+		assert.Equal(t, nil, err)
+		_, err = jwt.ParseToken(expired, token.VerifyKey())
+		assert.NotEqual(t, nil, err)
+	})
+}
@@ -32,8 +32,8 @@ type TokenProperties struct {
 	context context.Context
 }
 
-// newTokenProperties is the constructor of TokenProperties
-func newTokenProperties(context context.Context) *TokenProperties {
+// NewTokenProperties is the constructor of TokenProperties
+func NewTokenProperties(context context.Context) *TokenProperties {
 	return &TokenProperties{context: context}
 }
 
@@ -85,3 +85,13 @@ func (p *TokenProperties) Items() (propMap map[string]string, ok bool) {
 	}
 	return
 }
+
+// Token returns the raw token of current request, it can be forwarded to the downstream services
+func (p *TokenProperties) Token() (token string) {
+	if p.context != nil {
+		if t, ok := p.context.Values().Get("jwt").(*jwt.Token); ok && t.Valid {
+			token = t.Raw
+		}
+	}
+	return
+}
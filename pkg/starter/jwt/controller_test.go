@@ -113,6 +113,12 @@ func (c *barController) Get() string {
 	return "Hello, world"
 }
 
+// GetToken returns the token that the request context carries
+func (c *barController) GetToken(ctx context.Context) string {
+	token, _ := jwt.TokenFromContext(ctx.Request().Context())
+	return token
+}
+
 func (c *barController) Options() {
 	log.Debug("barController.Options")
 }
@@ -161,6 +167,13 @@ func TestJwtController(t *testing.T) {
 			Expect().Status(http.StatusUnauthorized)
 	})
 
+	t.Run("should get the token of current request", func(t *testing.T) {
+		testApp.Get("/bar/token").
+			WithHeader("Authorization", token).
+			Expect().Status(http.StatusOK).
+			Body().Equal(fooCtrl.tokenStr)
+	})
+
 	applicationContext := testApp.(app.ApplicationContext)
 	jwtMiddleware := applicationContext.GetInstance(jwt.Middleware{}).(*jwt.Middleware)
 
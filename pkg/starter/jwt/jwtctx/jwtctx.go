@@ -0,0 +1,79 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package jwtctx carries the jwt token and its claims by context.Context, it is shared by the starters
+// that forward or verify the token, e.g. grpc and websocket, without importing the jwt starter itself
+package jwtctx
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"github.com/dgrijalva/jwt-go"
+)
+
+type tokenContextKey struct{}
+
+type claimsContextKey struct{}
+
+var (
+	// ErrInvalidToken the token is not valid
+	ErrInvalidToken = errors.New("[jwt] invalid token")
+)
+
+// NewTokenContext returns the context that carries the raw token, e.g. the token of the web request
+// that is forwarded by grpc clients
+func NewTokenContext(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, tokenContextKey{}, token)
+}
+
+// TokenFromContext returns the raw token that the context carries
+func TokenFromContext(ctx context.Context) (token string, ok bool) {
+	token, ok = ctx.Value(tokenContextKey{}).(string)
+	return
+}
+
+// NewClaimsContext returns the context that carries the claims of the verified token
+func NewClaimsContext(ctx context.Context, claims jwt.MapClaims) context.Context {
+	return context.WithValue(ctx, claimsContextKey{}, claims)
+}
+
+// ClaimsFromContext returns the claims of the verified token that the context carries,
+// e.g. the claims that the grpc server puts to the context of the services
+func ClaimsFromContext(ctx context.Context) (claims jwt.MapClaims, ok bool) {
+	claims, ok = ctx.Value(claimsContextKey{}).(jwt.MapClaims)
+	return
+}
+
+// ParseToken verifies the raw token that is signed by RS256 with the verify key, and returns its claims
+func ParseToken(token string, verifyKey *rsa.PublicKey) (claims jwt.MapClaims, err error) {
+	if verifyKey == nil {
+		return nil, ErrInvalidToken
+	}
+	parsedToken, err := jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
+		if t.Method != jwt.SigningMethodRS256 {
+			return nil, fmt.Errorf("unexpected signing method %v", t.Header["alg"])
+		}
+		return verifyKey, nil
+	})
+	if err != nil {
+		return
+	}
+	claims, ok := parsedToken.Claims.(jwt.MapClaims)
+	if !ok || !parsedToken.Valid {
+		return nil, ErrInvalidToken
+	}
+	return
+}
@@ -38,6 +38,11 @@ func (m *Middleware) Serve(ctx context.Context) {
 		ctx.StopExecution()
 		return
 	}
+	// the request context carries the token of current request for forwarding, e.g. to the grpc calls
+	if token, ok := ctx.Values().Get(m.Config.ContextKey).(*jwt.Token); ok {
+		r := ctx.Request()
+		ctx.ResetRequest(r.WithContext(NewTokenContext(r.Context(), token.Raw)))
+	}
 	// If everything ok then call next.
 	ctx.Next()
 }
@@ -0,0 +1,53 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ratelimit provides the hiboot starter for injectable rate limit middleware
+package ratelimit
+
+import (
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/at"
+)
+
+const (
+	// Profile is the profile of ratelimit, it should be as same as the package name
+	Profile = "ratelimit"
+)
+
+type configuration struct {
+	at.AutoConfiguration
+
+	Properties         Properties `mapstructure:"ratelimit"`
+	applicationContext app.ApplicationContext
+}
+
+func init() {
+	app.Register(newConfiguration)
+}
+
+func newConfiguration(applicationContext app.ApplicationContext) *configuration {
+	return &configuration{
+		applicationContext: applicationContext,
+	}
+}
+
+// Middleware throttles the requests by the global and the route rules,
+// the jwt middleware is looked up on request so that the jwt starter is optional
+func (c *configuration) Middleware() *Middleware {
+	mw := NewMiddleware(&c.Properties, c.applicationContext.GetInstance)
+
+	c.applicationContext.Use(mw.Serve)
+
+	return mw
+}
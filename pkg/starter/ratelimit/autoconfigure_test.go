@@ -0,0 +1,93 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit_test
+
+import (
+	"hidevops.io/hiboot/pkg/app/web"
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/starter/ratelimit"
+	"net/http"
+	"testing"
+)
+
+type fooController struct {
+	at.RestController
+}
+
+func newFooController() *fooController {
+	return &fooController{}
+}
+
+func (c *fooController) Get() string {
+	return "foo"
+}
+
+func (c *fooController) GetBar() string {
+	return "bar"
+}
+
+func (c *fooController) GetBaz() string {
+	return "baz"
+}
+
+func init() {
+	ratelimit.RegisterKeyFunc("apiKey", func(ctx context.Context) string {
+		return ctx.GetHeader("X-Api-Key")
+	})
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	testApp := web.NewTestApp(newFooController).
+		SetProperty("ratelimit.algorithm", ratelimit.SlidingWindow).
+		SetProperty("ratelimit.limit", 2).
+		SetProperty("ratelimit.routes", []map[string]interface{}{
+			{"path": "/foo/bar", "method": "get", "limit": 1, "algorithm": ratelimit.TokenBucket},
+			{"path": "/foo/baz*", "limit": 1, "keyBy": "apiKey"},
+		}).
+		Run(t)
+
+	t.Run("should limit requests with the global rule", func(t *testing.T) {
+		testApp.Get("/foo").
+			Expect().Status(http.StatusOK).
+			Header("RateLimit-Remaining").Equal("1")
+		testApp.Get("/foo").
+			Expect().Status(http.StatusOK).
+			Header("RateLimit-Remaining").Equal("0")
+		resp := testApp.Get("/foo").
+			Expect().Status(http.StatusTooManyRequests)
+		resp.Header("RateLimit-Limit").Equal("2")
+		resp.Header("Retry-After").NotEmpty()
+	})
+
+	t.Run("should limit requests with the route rule", func(t *testing.T) {
+		testApp.Get("/foo/bar").
+			Expect().Status(http.StatusOK)
+		testApp.Get("/foo/bar").
+			Expect().Status(http.StatusTooManyRequests)
+	})
+
+	t.Run("should limit requests with the custom key func", func(t *testing.T) {
+		testApp.Get("/foo/baz").
+			WithHeader("X-Api-Key", "foo").
+			Expect().Status(http.StatusOK)
+		testApp.Get("/foo/baz").
+			WithHeader("X-Api-Key", "foo").
+			Expect().Status(http.StatusTooManyRequests)
+		testApp.Get("/foo/baz").
+			WithHeader("X-Api-Key", "bar").
+			Expect().Status(http.StatusOK)
+	})
+}
@@ -0,0 +1,85 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// TokenBucket is the token bucket algorithm
+	TokenBucket = "token_bucket"
+	// SlidingWindow is the sliding window algorithm
+	SlidingWindow = "sliding_window"
+)
+
+// Result is the result of the limiter
+type Result struct {
+	// Allowed reports whether the request is allowed
+	Allowed bool
+	// Limit is the number of requests that allowed in the window
+	Limit int
+	// Remaining is the number of requests that remain in current window
+	Remaining int
+	// Reset is the duration until the quota is fully restored
+	Reset time.Duration
+	// RetryAfter is the duration that the client should wait before retry, it is zero if the request is allowed
+	RetryAfter time.Duration
+}
+
+// Limiter is the interface of rate limiter
+type Limiter interface {
+	// Allow reports whether the request identified by key may happen now
+	Allow(key string) (result *Result, err error)
+}
+
+type tokenBucketLimiter struct {
+	store  Store
+	limit  int
+	window time.Duration
+}
+
+type slidingWindowLimiter struct {
+	store  Store
+	limit  int
+	window time.Duration
+}
+
+// NewLimiter create the limiter with specific algorithm, limit requests are allowed per window
+func NewLimiter(algorithm string, store Store, limit int, window time.Duration) (limiter Limiter, err error) {
+	if limit <= 0 || window <= 0 {
+		return nil, fmt.Errorf("invalid rate limit %v per %v", limit, window)
+	}
+	switch algorithm {
+	case TokenBucket:
+		limiter = &tokenBucketLimiter{store: store, limit: limit, window: window}
+	case SlidingWindow:
+		limiter = &slidingWindowLimiter{store: store, limit: limit, window: window}
+	default:
+		err = fmt.Errorf("unsupported rate limit algorithm: %v", algorithm)
+	}
+	return
+}
+
+// Allow implements Limiter
+func (l *tokenBucketLimiter) Allow(key string) (result *Result, err error) {
+	return l.store.TakeToken(TokenBucket+":"+key, l.limit, l.window, time.Now())
+}
+
+// Allow implements Limiter
+func (l *slidingWindowLimiter) Allow(key string) (result *Result, err error) {
+	return l.store.Hit(SlidingWindow+":"+key, l.limit, l.window, time.Now())
+}
@@ -0,0 +1,103 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Now().Truncate(time.Minute)
+
+	t.Run("should take tokens from the bucket", func(t *testing.T) {
+		s := NewMemoryStore()
+		for i := 0; i < 3; i++ {
+			result, err := s.TakeToken("foo", 3, time.Minute, now)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, true, result.Allowed)
+			assert.Equal(t, 2-i, result.Remaining)
+		}
+
+		result, err := s.TakeToken("foo", 3, time.Minute, now)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, false, result.Allowed)
+		assert.Equal(t, 20*time.Second, result.RetryAfter)
+
+		// one token is refilled every 20 seconds
+		result, err = s.TakeToken("foo", 3, time.Minute, now.Add(20*time.Second))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, true, result.Allowed)
+
+		// the other key has its own bucket
+		result, err = s.TakeToken("bar", 3, time.Minute, now)
+		assert.Equal(t, true, result.Allowed)
+	})
+
+	t.Run("should hit the sliding window", func(t *testing.T) {
+		s := NewMemoryStore()
+		for i := 0; i < 4; i++ {
+			result, err := s.Hit("foo", 4, time.Minute, now)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, true, result.Allowed)
+		}
+		result, _ := s.Hit("foo", 4, time.Minute, now.Add(30*time.Second))
+		assert.Equal(t, false, result.Allowed)
+		assert.Equal(t, 30*time.Second, result.RetryAfter)
+
+		// half of the previous window is still in the sliding window
+		result, _ = s.Hit("foo", 4, time.Minute, now.Add(90*time.Second))
+		assert.Equal(t, true, result.Allowed)
+		result, _ = s.Hit("foo", 4, time.Minute, now.Add(90*time.Second))
+		assert.Equal(t, true, result.Allowed)
+		result, _ = s.Hit("foo", 4, time.Minute, now.Add(90*time.Second))
+		assert.Equal(t, false, result.Allowed)
+		assert.Equal(t, 0, result.Remaining)
+
+		// the previous window is expired
+		result, _ = s.Hit("foo", 4, time.Minute, now.Add(200*time.Second))
+		assert.Equal(t, true, result.Allowed)
+		assert.Equal(t, 3, result.Remaining)
+	})
+}
+
+func TestLimiter(t *testing.T) {
+	t.Run("should report error with invalid arguments", func(t *testing.T) {
+		_, err := NewLimiter(TokenBucket, NewMemoryStore(), 0, time.Second)
+		assert.NotEqual(t, nil, err)
+
+		_, err = NewLimiter("unknown", NewMemoryStore(), 1, time.Second)
+		assert.NotEqual(t, nil, err)
+	})
+
+	t.Run("should limit requests with token bucket", func(t *testing.T) {
+		l, err := NewLimiter(TokenBucket, NewMemoryStore(), 1, time.Hour)
+		assert.Equal(t, nil, err)
+		result, _ := l.Allow("foo")
+		assert.Equal(t, true, result.Allowed)
+		result, _ = l.Allow("foo")
+		assert.Equal(t, false, result.Allowed)
+	})
+
+	t.Run("should limit requests with sliding window", func(t *testing.T) {
+		l, err := NewLimiter(SlidingWindow, NewMemoryStore(), 1, time.Hour)
+		assert.Equal(t, nil, err)
+		result, _ := l.Allow("foo")
+		assert.Equal(t, true, result.Allowed)
+		result, _ = l.Allow("foo")
+		assert.Equal(t, false, result.Allowed)
+	})
+}
@@ -0,0 +1,224 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"fmt"
+	jwtgo "github.com/dgrijalva/jwt-go"
+	ictx "github.com/kataras/iris/context"
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/log"
+	"hidevops.io/hiboot/pkg/utils/str"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	headerLimit      = "RateLimit-Limit"
+	headerRemaining  = "RateLimit-Remaining"
+	headerReset      = "RateLimit-Reset"
+	headerRetryAfter = "Retry-After"
+
+	// jwtContextKey is the key that the jwt middleware puts the parsed token into the context values
+	jwtContextKey = "jwt"
+)
+
+// jwtChecker is implemented by the middleware of the jwt starter, it is looked up by the instance getter,
+// so that the jwt starter is only required if the client is identified by jwt claim
+type jwtChecker interface {
+	CheckJWT(ctx ictx.Context) error
+}
+
+// InstanceGetter get the instance by name, it is implemented by the application context
+type InstanceGetter func(params ...interface{}) interface{}
+
+type rule struct {
+	name    string
+	route   Route
+	limiter Limiter
+	keyFunc KeyFunc
+}
+
+// Middleware is the rate limit middleware
+type Middleware struct {
+	properties  *Properties
+	getInstance InstanceGetter
+	global      *rule
+	routes      []*rule
+}
+
+// NewMiddleware is the constructor of rate limit middleware, getInstance is used for looking up the jwt middleware
+// that parses the token if the client is identified by jwt claim, it can be nil if the jwt starter is not used
+func NewMiddleware(properties *Properties, getInstance InstanceGetter) *Middleware {
+	m := &Middleware{
+		properties:  properties,
+		getInstance: getInstance,
+	}
+
+	var store Store
+	if s, ok := stores.Get(properties.Store); ok {
+		store = s.(Store)
+	} else {
+		log.Warnf("rate limit store %v is not registered, use %v store instead", properties.Store, MemoryStore)
+		store = NewMemoryStore()
+	}
+
+	global := Route{
+		Algorithm: properties.Algorithm,
+		Limit:     properties.Limit,
+		Window:    properties.Window,
+		KeyBy:     properties.KeyBy,
+	}
+	if global.Limit > 0 {
+		m.global = m.newRule("global", global, store)
+	}
+
+	for _, route := range properties.Routes {
+		if route.Algorithm == "" {
+			route.Algorithm = global.Algorithm
+		}
+		if route.Limit == 0 {
+			route.Limit = properties.Limit
+		}
+		if route.Window == 0 {
+			route.Window = global.Window
+		}
+		if route.KeyBy == "" {
+			route.KeyBy = global.KeyBy
+		}
+		route.Method = strings.ToUpper(route.Method)
+		r := m.newRule(fmt.Sprintf("%v %v", route.Method, route.Path), route, store)
+		if r != nil {
+			m.routes = append(m.routes, r)
+		}
+	}
+	return m
+}
+
+func (m *Middleware) newRule(name string, route Route, store Store) *rule {
+	limiter, err := NewLimiter(route.Algorithm, store, route.Limit, time.Duration(route.Window)*time.Second)
+	if err != nil {
+		log.Errorf("failed to create rate limiter %v: %v", name, err)
+		return nil
+	}
+	return &rule{
+		name:    name,
+		route:   route,
+		limiter: limiter,
+		keyFunc: m.keyFunc(route.KeyBy),
+	}
+}
+
+func (m *Middleware) keyFunc(keyBy string) (keyFunc KeyFunc) {
+	switch keyBy {
+	case KeyByIP, "":
+		keyFunc = ipKey
+	case KeyByJwt:
+		keyFunc = m.jwtKey
+	default:
+		if kf, ok := keyFuncs.Get(keyBy); ok {
+			keyFunc = kf.(KeyFunc)
+		} else {
+			log.Warnf("rate limit key func %v is not registered, use %v instead", keyBy, KeyByIP)
+			keyFunc = ipKey
+		}
+	}
+	return
+}
+
+// jwtChecker find the middleware of the jwt starter, it returns nil if the jwt starter is not imported
+func (m *Middleware) jwtChecker() (checker jwtChecker) {
+	if m.getInstance != nil {
+		checker, _ = m.getInstance("jwt.middleware").(jwtChecker)
+	}
+	return
+}
+
+// jwtKey use the jwt claim as the key
+func (m *Middleware) jwtKey(ctx context.Context) (key string) {
+	// the token is not parsed yet as the rate limit middleware runs before the jwt middleware
+	if ctx.Values().Get(jwtContextKey) == nil {
+		checker := m.jwtChecker()
+		if checker == nil {
+			log.Debugf("rate limit key %v requires the jwt starter, use %v instead", KeyByJwt, KeyByIP)
+			return
+		}
+		checker.CheckJWT(ctx)
+	}
+	token, ok := ctx.Values().Get(jwtContextKey).(*jwtgo.Token)
+	if ok && token.Valid {
+		if claims, ok := token.Claims.(jwtgo.MapClaims); ok {
+			if claim, ok := claims[m.properties.JwtClaim]; ok {
+				key = fmt.Sprintf("%v", claim)
+			}
+		}
+	}
+	return
+}
+
+// match find the rule of the request, the route rules take precedence over the global rule
+func (m *Middleware) match(ctx context.Context) *rule {
+	path := ctx.Path()
+	method := ctx.Method()
+	for _, r := range m.routes {
+		if (r.route.Method == "" || r.route.Method == method) && str.MatchPath(r.route.Path, path) {
+			return r
+		}
+	}
+	return m.global
+}
+
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// Serve the middleware's action
+func (m *Middleware) Serve(ctx context.Context) {
+	r := m.match(ctx)
+	if r == nil {
+		ctx.Next()
+		return
+	}
+
+	key := r.keyFunc(ctx)
+	if key == "" {
+		key = ipKey(ctx)
+	}
+
+	result, err := r.limiter.Allow(r.name + ":" + key)
+	if err != nil {
+		// let the request pass if the store is not available
+		log.Errorf("rate limit error: %v", err)
+		ctx.Next()
+		return
+	}
+
+	if m.properties.Headers {
+		ctx.Header(headerLimit, strconv.Itoa(result.Limit))
+		ctx.Header(headerRemaining, strconv.Itoa(result.Remaining))
+		ctx.Header(headerReset, seconds(result.Reset))
+	}
+
+	if !result.Allowed {
+		ctx.Header(headerRetryAfter, seconds(result.RetryAfter))
+		ctx.ResponseError(http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		ctx.StopExecution()
+		return
+	}
+	ctx.Next()
+}
@@ -0,0 +1,52 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+// Route is the rate limit properties of the specific route, the zero values are inherited from the global properties
+type Route struct {
+	// Path is the request path, the trailing * matches any path with the prefix, e.g. /foo/*
+	Path string `json:"path"`
+	// Method is the http method, empty means all methods
+	Method string `json:"method"`
+	// Algorithm is the limiter algorithm, token_bucket or sliding_window
+	Algorithm string `json:"algorithm"`
+	// Limit is the number of requests that allowed per window
+	Limit int `json:"limit"`
+	// Window is the window in second
+	Window int64 `json:"window"`
+	// KeyBy is the key of the client, ip, jwt or the name of the registered key func
+	KeyBy string `json:"key_by"`
+}
+
+// Properties the rate limit properties
+type Properties struct {
+	// Algorithm is the limiter algorithm, token_bucket or sliding_window
+	Algorithm string `json:"algorithm" default:"token_bucket"`
+	// Limit is the number of requests that allowed per window, 0 means the global limit is disabled,
+	// only the routes are limited
+	Limit int `json:"limit" default:"100"`
+	// Window is the window in second
+	Window int64 `json:"window" default:"60"`
+	// KeyBy is the key of the client, ip, jwt or the name of the registered key func, it is configured by ratelimit.key_by
+	KeyBy string `json:"key_by" default:"ip"`
+	// JwtClaim is the jwt claim that used as the key if key_by is jwt, it is configured by ratelimit.jwt_claim
+	JwtClaim string `json:"jwt_claim" default:"sub"`
+	// Store is the name of the registered store
+	Store string `json:"store" default:"memory"`
+	// Headers set to true to send RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers
+	Headers bool `json:"headers" default:"true"`
+	// Routes is the rate limit properties of the specific routes
+	Routes []Route `json:"routes"`
+}
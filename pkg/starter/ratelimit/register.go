@@ -0,0 +1,57 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/utils/cmap"
+)
+
+const (
+	// KeyByIP use the client ip as the key
+	KeyByIP = "ip"
+	// KeyByJwt use the jwt claim as the key
+	KeyByJwt = "jwt"
+	// MemoryStore is the name of in-memory store
+	MemoryStore = "memory"
+)
+
+// KeyFunc return the key that identify the client, the client ip is used if the key is empty
+type KeyFunc func(ctx context.Context) string
+
+var (
+	keyFuncs cmap.ConcurrentMap
+	stores   cmap.ConcurrentMap
+)
+
+func init() {
+	keyFuncs = cmap.New()
+	stores = cmap.New()
+	RegisterStore(MemoryStore, NewMemoryStore())
+}
+
+// RegisterKeyFunc register the custom key func, it can be referenced by ratelimit.key_by in application.yml
+func RegisterKeyFunc(name string, keyFunc KeyFunc) {
+	keyFuncs.Set(name, keyFunc)
+}
+
+// RegisterStore register the store, it can be referenced by store in application.yml
+func RegisterStore(name string, store Store) {
+	stores.Set(name, store)
+}
+
+func ipKey(ctx context.Context) string {
+	return ctx.RemoteAddr()
+}
@@ -0,0 +1,142 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Store is the storage of the limiter state, the operations must be atomic as the store may be shared
+// by many instances, e.g. a redis store can implement them with lua scripts
+type Store interface {
+	// TakeToken take one token from the bucket identified by key, the bucket holds capacity tokens at most,
+	// and it is refilled with capacity tokens per window
+	TakeToken(key string, capacity int, window time.Duration, now time.Time) (result *Result, err error)
+	// Hit record a hit in the sliding window identified by key, the hit is rejected if there are limit hits in the window
+	Hit(key string, limit int, window time.Duration, now time.Time) (result *Result, err error)
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type slidingWindow struct {
+	start    time.Time
+	previous int
+	current  int
+}
+
+type memoryStore struct {
+	mu       sync.Mutex
+	buckets  map[string]*bucket
+	windows  map[string]*slidingWindow
+	expireAt map[string]time.Time
+	sweptAt  time.Time
+}
+
+// NewMemoryStore create the in-memory store, it is only shared within current process
+func NewMemoryStore() Store {
+	return &memoryStore{
+		buckets:  make(map[string]*bucket),
+		windows:  make(map[string]*slidingWindow),
+		expireAt: make(map[string]time.Time),
+	}
+}
+
+// sweep remove the expired states, it is called with lock held
+func (s *memoryStore) sweep(now time.Time) {
+	if now.Sub(s.sweptAt) < time.Minute {
+		return
+	}
+	s.sweptAt = now
+	for key, t := range s.expireAt {
+		if now.After(t) {
+			delete(s.buckets, key)
+			delete(s.windows, key)
+			delete(s.expireAt, key)
+		}
+	}
+}
+
+// TakeToken implements Store
+func (s *memoryStore) TakeToken(key string, capacity int, window time.Duration, now time.Time) (result *Result, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+
+	rate := float64(capacity) / float64(window)
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(capacity), last: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(capacity), b.tokens+float64(now.Sub(b.last))*rate)
+	b.last = now
+
+	result = &Result{Limit: capacity}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - b.tokens) / rate)
+	}
+	result.Remaining = int(b.tokens)
+	// the time that the bucket will be full again
+	result.Reset = time.Duration((float64(capacity) - b.tokens) / rate)
+	s.expireAt[key] = now.Add(result.Reset)
+	return
+}
+
+// Hit implements Store
+func (s *memoryStore) Hit(key string, limit int, window time.Duration, now time.Time) (result *Result, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+
+	start := now.Truncate(window)
+	w, ok := s.windows[key]
+	if !ok {
+		w = &slidingWindow{start: start}
+		s.windows[key] = w
+	}
+	if elapsed := start.Sub(w.start); elapsed == window {
+		w.previous, w.current, w.start = w.current, 0, start
+	} else if elapsed > window {
+		w.previous, w.current, w.start = 0, 0, start
+	}
+
+	// weight the previous window by its overlap with the sliding window
+	weight := 1 - float64(now.Sub(start))/float64(window)
+	count := int(math.Floor(float64(w.previous)*weight)) + w.current
+
+	result = &Result{Limit: limit}
+	if count < limit {
+		w.current++
+		count++
+		result.Allowed = true
+	} else {
+		result.RetryAfter = start.Add(window).Sub(now)
+	}
+	result.Remaining = limit - count
+	if result.Remaining < 0 {
+		result.Remaining = 0
+	}
+	result.Reset = start.Add(window).Sub(now)
+	s.expireAt[key] = start.Add(2 * window)
+	return
+}
@@ -0,0 +1,77 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package requestid provides the hiboot starter for request correlation id, the request id is read from the
+// X-Request-ID header or generated by idgen, it is carried by the request context and is forwarded as grpc metadata
+// by the grpc clients.
+//
+// The request id prefixes the log messages of the injected *requestid.Logger, or of log.WithContext(ctx.Request().Context()),
+// the package level log functions, e.g. log.Infof, are not bound to the request so that they are not prefixed, e.g.
+//
+//	// Get GET /foo
+//	func (c *fooController) Get(logger *requestid.Logger) string {
+//		logger.Info("handling foo")
+//		return "foo"
+//	}
+package requestid
+
+import (
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/log"
+)
+
+const (
+	// Profile is the profile of requestid, it should be as same as the package name
+	Profile = "requestid"
+)
+
+type configuration struct {
+	at.AutoConfiguration
+
+	Properties         Properties `mapstructure:"requestid"`
+	applicationContext app.ApplicationContext
+}
+
+// Logger is the logger of the request, it prefixes the log messages with the request id
+type Logger struct {
+	at.ContextAware
+	*log.Logger
+}
+
+func init() {
+	app.Register(newConfiguration)
+}
+
+func newConfiguration(applicationContext app.ApplicationContext) *configuration {
+	return &configuration{
+		applicationContext: applicationContext,
+	}
+}
+
+// Middleware assigns the request id to each request, it is used ahead of the controllers
+// so that the request id is available to the other middlewares
+func (c *configuration) Middleware() *Middleware {
+	mw := NewMiddleware(&c.Properties)
+
+	c.applicationContext.Use(mw.Serve)
+
+	return mw
+}
+
+// Logger is the logger of the request for runtime dependency injection
+func (c *configuration) Logger(ctx context.Context) *Logger {
+	return &Logger{Logger: log.WithContext(ctx.Request().Context())}
+}
@@ -0,0 +1,104 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package requestid_test
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"hidevops.io/hiboot/pkg/app/web"
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/log"
+	"hidevops.io/hiboot/pkg/starter/requestid"
+	"net/http"
+	"os"
+	"testing"
+)
+
+type fooController struct {
+	at.RestController
+}
+
+func newFooController() *fooController {
+	return &fooController{}
+}
+
+func (c *fooController) Get(ctx context.Context) string {
+	log.WithContext(ctx.Request().Context()).Info("handling foo")
+	return requestid.Get(ctx)
+}
+
+// GetBar GET /foo/bar
+func (c *fooController) GetBar(logger *requestid.Logger) string {
+	logger.Infof("handling %v", "bar")
+	return "bar"
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	testApp := web.RunTestApplication(t, newFooController)
+
+	t.Run("should generate request id", func(t *testing.T) {
+		resp := testApp.Get("/foo").Expect().Status(http.StatusOK)
+		id := resp.Header("X-Request-ID").NotEmpty().Raw()
+		resp.Body().Equal(id)
+	})
+
+	t.Run("should reuse request id from header", func(t *testing.T) {
+		testApp.Get("/foo").
+			WithHeader("X-Request-ID", "f81d4fae").
+			Expect().Status(http.StatusOK).
+			Header("X-Request-ID").Equal("f81d4fae")
+	})
+
+	t.Run("should replace invalid request id", func(t *testing.T) {
+		testApp.Get("/foo").
+			WithHeader("X-Request-ID", "foo bar").
+			Expect().Status(http.StatusOK).
+			Header("X-Request-ID").NotEqual("foo bar")
+	})
+
+	t.Run("should prefix log with request id", func(t *testing.T) {
+		var buf bytes.Buffer
+		log.SetOutput(&buf)
+		defer log.SetOutput(os.Stdout)
+
+		testApp.Get("/foo").
+			WithHeader("X-Request-ID", "c0ffee").
+			Expect().Status(http.StatusOK)
+		assert.Contains(t, buf.String(), "[c0ffee] handling foo")
+	})
+
+	t.Run("should prefix log of the injected logger with request id", func(t *testing.T) {
+		var buf bytes.Buffer
+		log.SetOutput(&buf)
+		defer log.SetOutput(os.Stdout)
+
+		testApp.Get("/foo/bar").
+			WithHeader("X-Request-ID", "c0ffee").
+			Expect().Status(http.StatusOK)
+		assert.Contains(t, buf.String(), "[c0ffee] handling bar")
+	})
+}
+
+func TestRequestIDWithoutTrust(t *testing.T) {
+	testApp := web.NewTestApp(newFooController).
+		SetProperty("requestid.trust", false).
+		Run(t)
+
+	testApp.Get("/foo").
+		WithHeader("X-Request-ID", "f81d4fae").
+		Expect().Status(http.StatusOK).
+		Header("X-Request-ID").NotEqual("f81d4fae")
+}
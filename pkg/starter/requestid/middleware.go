@@ -0,0 +1,83 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package requestid
+
+import (
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/log"
+	"hidevops.io/hiboot/pkg/utils/idgen"
+)
+
+const (
+	// ContextKey is the key of the request id that stored in the context values
+	ContextKey = "requestId"
+)
+
+// Middleware is the request id middleware, it reads the request id from the request header or generates a new one,
+// then echoes it in the response header and puts it to the request context
+type Middleware struct {
+	properties *Properties
+}
+
+// NewMiddleware is the constructor of request id middleware
+func NewMiddleware(properties *Properties) *Middleware {
+	return &Middleware{
+		properties: properties,
+	}
+}
+
+// isValid report whether the request id sent by client is safe to be logged and forwarded
+func (m *Middleware) isValid(id string) bool {
+	if id == "" || len(id) > m.properties.MaxLength {
+		return false
+	}
+	for _, c := range id {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// Serve the request id middleware handler
+func (m *Middleware) Serve(ctx context.Context) {
+	var id string
+	if m.properties.Trust {
+		id = ctx.GetHeader(m.properties.Header)
+	}
+	if !m.isValid(id) {
+		var err error
+		id, err = idgen.NextString()
+		if err != nil {
+			log.Warnf("failed to generate request id: %v", err)
+			ctx.Next()
+			return
+		}
+	}
+
+	ctx.Values().Set(ContextKey, id)
+	ctx.Header(m.properties.Header, id)
+
+	// the request context carries the request id to the grpc clients and log.WithContext
+	r := ctx.Request()
+	ctx.ResetRequest(r.WithContext(log.NewContext(r.Context(), log.RequestIDKey, id)))
+
+	ctx.Next()
+}
+
+// Get return the request id of current request
+func Get(ctx context.Context) string {
+	return ctx.Values().GetString(ContextKey)
+}
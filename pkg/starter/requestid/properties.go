@@ -0,0 +1,25 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package requestid
+
+// Properties the request id properties
+type Properties struct {
+	// Header is the http header that carries the request id
+	Header string `json:"header" default:"X-Request-ID"`
+	// Trust indicates whether the request id sent by the client is reused, a new one is generated if it is false
+	Trust bool `json:"trust" default:"true"`
+	// MaxLength is the max length of the request id sent by the client, a longer one is replaced by a generated one
+	MaxLength int `json:"max_length" default:"128"`
+}
@@ -0,0 +1,65 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package security provides the hiboot starter for injectable csrf protection and security response headers middleware
+package security
+
+import (
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/app/web/upload"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/log"
+)
+
+const (
+	// Profile is the profile of security, it should be as same as the package name
+	Profile = "security"
+)
+
+type configuration struct {
+	at.AutoConfiguration
+
+	Properties         Properties `mapstructure:"security"`
+	applicationContext app.ApplicationContext
+}
+
+func init() {
+	app.Register(newConfiguration)
+}
+
+func newConfiguration(applicationContext app.ApplicationContext) *configuration {
+	return &configuration{
+		applicationContext: applicationContext,
+	}
+}
+
+// Middleware writes the security headers and verifies the csrf tokens, the session manager is looked up at runtime,
+// so the session starter is not required if the tokens are bound to the binding cookie in double_submit mode
+func (c *configuration) Middleware(uploadProperties *upload.Properties) *Middleware {
+	mw := NewMiddleware(&c.Properties, c.applicationContext.GetInstance, uploadProperties)
+
+	c.applicationContext.Use(mw.Serve)
+
+	return mw
+}
+
+// Token is the csrf token of the request for runtime dependency injection
+func (c *configuration) Token(ctx context.Context, mw *Middleware) *Token {
+	token, err := mw.Token(ctx)
+	if err != nil {
+		log.Warnf("security: failed to create the csrf token: %v", err)
+	}
+	return token
+}
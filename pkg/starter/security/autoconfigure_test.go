@@ -0,0 +1,205 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security_test
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"hidevops.io/hiboot/pkg/app/web"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/starter/jwt"
+	_ "hidevops.io/hiboot/pkg/starter/requestid"
+	"hidevops.io/hiboot/pkg/starter/security"
+	"hidevops.io/hiboot/pkg/starter/tracing"
+	"hidevops.io/hiboot/pkg/utils/io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+type fooController struct {
+	at.RestController
+}
+
+type fooRequestForm struct {
+	at.RequestForm
+	Name string
+}
+
+func newFooController() *fooController {
+	return &fooController{}
+}
+
+// GetToken GET /foo/token
+func (c *fooController) GetToken(token *security.Token) string {
+	return token.Value
+}
+
+// Post POST /foo
+func (c *fooController) Post(request *fooRequestForm) string {
+	return "Hello, " + request.Name
+}
+
+func TestSecurity(t *testing.T) {
+	testApp := web.NewTestApp(newFooController).
+		SetProperty("security.csrf.enabled", true).
+		Run(t)
+
+	var token string
+	cookies := make(map[string]string)
+	t.Run("should write the security headers and issue the csrf cookie with the session", func(t *testing.T) {
+		resp := testApp.Get("/foo/token").
+			Expect().Status(http.StatusOK)
+		resp.Header("X-Frame-Options").Equal("DENY")
+		resp.Header("X-Content-Type-Options").Equal("nosniff")
+		resp.Header("Content-Security-Policy").Equal("default-src 'self'")
+		resp.Header("Referrer-Policy").Equal("strict-origin-when-cross-origin")
+
+		for _, cookie := range resp.Raw().Cookies() {
+			cookies[cookie.Name] = cookie.Value
+		}
+		assert.Equal(t, 2, len(cookies))
+		assert.NotEqual(t, "", cookies["HIBOOT_SESSION"])
+		token = cookies["XSRF-TOKEN"]
+		resp.Body().Equal(token)
+	})
+
+	t.Run("should bind the form with the csrf token", func(t *testing.T) {
+		testApp.Post("/foo").
+			WithCookies(cookies).
+			WithFormField("_csrf", token).
+			WithFormField("name", "John Doe").
+			Expect().Status(http.StatusOK).
+			Body().Equal("Hello, John Doe")
+	})
+
+	t.Run("should accept the csrf token in the header", func(t *testing.T) {
+		testApp.Post("/foo").
+			WithCookies(cookies).
+			WithHeader("X-XSRF-TOKEN", token).
+			WithFormField("name", "John Doe").
+			Expect().Status(http.StatusOK)
+	})
+
+	t.Run("should reject the form without csrf token", func(t *testing.T) {
+		testApp.Post("/foo").
+			WithCookies(cookies).
+			WithFormField("name", "John Doe").
+			Expect().Status(http.StatusForbidden)
+	})
+
+	t.Run("should reject the csrf token without the session", func(t *testing.T) {
+		testApp.Post("/foo").
+			WithCookie("XSRF-TOKEN", token).
+			WithHeader("X-XSRF-TOKEN", token).
+			WithFormField("name", "John Doe").
+			Expect().Status(http.StatusForbidden)
+	})
+
+	t.Run("should not verify the request without cookie", func(t *testing.T) {
+		testApp.Post("/foo").
+			WithFormField("name", "John Doe").
+			Expect().Status(http.StatusOK)
+	})
+
+	t.Run("should not verify the request with the bearer token", func(t *testing.T) {
+		testApp.Post("/foo").
+			WithCookies(cookies).
+			WithHeader("Authorization", "Bearer token").
+			WithFormField("name", "John Doe").
+			Expect().Status(http.StatusOK)
+	})
+}
+
+type avatarRequest struct {
+	at.RequestFile
+	Avatar *multipart.FileHeader `form:"avatar"`
+}
+
+type avatarController struct {
+	at.RestController
+}
+
+func newAvatarController() *avatarController {
+	return &avatarController{}
+}
+
+// Post POST /avatar
+func (c *avatarController) Post(request *avatarRequest) string {
+	return request.Avatar.Filename
+}
+
+type photoController struct {
+	at.JwtRestController
+}
+
+func newPhotoController() *photoController {
+	return &photoController{}
+}
+
+// Post POST /photo
+func (c *photoController) Post(request *avatarRequest) string {
+	return request.Avatar.Filename
+}
+
+func TestUploadTempFiles(t *testing.T) {
+	io.EnsureWorkDir(1, "config/ssl/app.rsa")
+	tmpDir, err := ioutil.TempDir("", "upload")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(tmpDir)
+	defer os.Setenv("TMPDIR", os.Getenv("TMPDIR"))
+	os.Setenv("TMPDIR", tmpDir)
+
+	tracing.RegisterExporter("upload", tracing.NewInMemoryExporter())
+	testApp := web.NewTestApp(newAvatarController, newPhotoController).
+		SetProperty("security.csrf.enabled", true).
+		SetProperty("tracing.exporter", "upload").
+		SetProperty("web.upload.maxMemory", 1).
+		Run(t)
+
+	content := bytes.Repeat([]byte("a"), 1024)
+	assertEmpty := func(t *testing.T) {
+		files, err := ioutil.ReadDir(tmpDir)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 0, len(files))
+	}
+
+	t.Run("should remove the files that the handler binds", func(t *testing.T) {
+		token, err := jwt.NewJwtToken(&jwt.Properties{
+			PrivateKeyPath: "config/ssl/app.rsa",
+			PublicKeyPath:  "config/ssl/app.rsa.pub",
+		}).Generate(jwt.Map{"username": "johndoe"}, 1, time.Minute)
+		assert.Equal(t, nil, err)
+		testApp.Post("/photo").
+			WithHeader("Authorization", "Bearer "+token).
+			WithMultipart().
+			WithFile("avatar", "a.txt", bytes.NewReader(content)).
+			Expect().Status(http.StatusOK).
+			Body().Equal("a.txt")
+		assertEmpty(t)
+	})
+
+	t.Run("should remove the files that the csrf verification parses", func(t *testing.T) {
+		testApp.Post("/avatar").
+			WithCookie("session", "foo").
+			WithMultipart().
+			WithFile("avatar", "a.txt", bytes.NewReader(content)).
+			Expect().Status(http.StatusForbidden)
+		assertEmpty(t)
+	})
+}
@@ -0,0 +1,272 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"hidevops.io/hiboot/pkg/app/web/upload"
+	"hidevops.io/hiboot/pkg/log"
+	"hidevops.io/hiboot/pkg/starter/session"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+const (
+	// DoubleSubmit keeps the token in the cookie, the request submits the token of the cookie in the header or the form field
+	DoubleSubmit = "double_submit"
+	// Synchronizer keeps the token in the session, the request submits the token that is rendered in the form
+	Synchronizer = "synchronizer"
+
+	// sessionKey is the key of the token in the session
+	sessionKey = "csrf_token"
+
+	// sessionManager is the instance name of the session manager
+	sessionManager = "session.manager"
+)
+
+var (
+	// ErrInvalidToken the csrf token is missing or it does not match
+	ErrInvalidToken = errors.New("[security] invalid csrf token")
+
+	// ErrSessionNotFound the session manager is not found, the session starter is required unless the binding cookie is set
+	ErrSessionNotFound = errors.New("[security] session manager is not found")
+
+	// ErrBindingNotFound the request carries neither the session nor the binding cookie that the token is bound to
+	ErrBindingNotFound = errors.New("[security] csrf token binding is not found")
+)
+
+// InstanceGetter get the instance by name, it is implemented by the application context
+type InstanceGetter func(params ...interface{}) interface{}
+
+type csrf struct {
+	properties       *csrfProperties
+	getInstance      InstanceGetter
+	uploadProperties *upload.Properties
+	secret           []byte
+}
+
+func newCSRF(p *csrfProperties, getInstance InstanceGetter, uploadProperties *upload.Properties) *csrf {
+	c := &csrf{
+		properties:       p,
+		getInstance:      getInstance,
+		uploadProperties: uploadProperties,
+		secret:           []byte(p.Secret),
+	}
+	if len(c.secret) == 0 {
+		c.secret = make([]byte, 32)
+		if _, err := rand.Read(c.secret); err != nil {
+			log.Errorf("security: failed to create the csrf secret: %v", err)
+		}
+	}
+	return c
+}
+
+// safe reports whether the method is safe, the safe requests are not verified
+func safe(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// ambient reports whether the request carries the credentials that the browser attaches automatically,
+// i.e. the cookies or the basic auth, the requests that carry the bearer token or no cookie are not able to be forged
+// across sites, e.g. the requests of the json clients or the grpc gateway
+func ambient(r *http.Request) bool {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		return strings.HasPrefix(strings.ToLower(auth), "basic ")
+	}
+	return len(r.Cookies()) != 0
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// sign signs the nonce and the binding with the secret, the signed token is nonce.signature
+func (c *csrf) sign(nonce, binding string) string {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(nonce))
+	// the nonce is base64 encoded, so the separator is not able to be a part of it
+	mac.Write([]byte{0})
+	mac.Write([]byte(binding))
+	return nonce + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// signed checks if the token is signed by the secret for the binding, so that the token that is planted in the cookie,
+// or the one that is minted for another client, is rejected
+func (c *csrf) signed(token, binding string) bool {
+	i := strings.LastIndex(token, ".")
+	return i > 0 && binding != "" && hmac.Equal([]byte(c.sign(token[:i], binding)), []byte(token))
+}
+
+// binding returns the value that the double_submit token is bound to, it is the binding cookie if it is set,
+// otherwise it is the session id, the new session is saved if start is true so that the anonymous client has one
+func (c *csrf) binding(w http.ResponseWriter, r *http.Request, start bool) (binding string, err error) {
+	if c.properties.BindingCookie != "" {
+		if cookie, e := r.Cookie(c.properties.BindingCookie); e == nil {
+			binding = cookie.Value
+		}
+	} else {
+		var s *session.Session
+		if s, err = c.session(w, r); err != nil {
+			return
+		}
+		if s.ID() == "" && start {
+			err = s.Regenerate()
+		}
+		binding = s.ID()
+	}
+	if binding == "" && err == nil {
+		err = ErrBindingNotFound
+	}
+	return
+}
+
+// session returns the session of the request, the session manager is looked up at runtime,
+// so that the session starter is not required if the token is bound to the binding cookie
+func (c *csrf) session(w http.ResponseWriter, r *http.Request) (*session.Session, error) {
+	var manager *session.Manager
+	if c.getInstance != nil {
+		manager, _ = c.getInstance(sessionManager).(*session.Manager)
+	}
+	if manager == nil {
+		return nil, ErrSessionNotFound
+	}
+	return manager.Session(w, r)
+}
+
+// token returns the token of the request, the token is created if it does not exist,
+// the new cookie is added to the request as well so that the token is stable within the request,
+// start is passed to binding, the token is not issued if the client has no binding
+func (c *csrf) token(w http.ResponseWriter, r *http.Request, start bool) (token string, err error) {
+	if c.properties.Mode == Synchronizer {
+		s, err := c.session(w, r)
+		if err != nil {
+			return "", err
+		}
+		if token = s.GetString(sessionKey); token == "" {
+			if token, err = newToken(); err == nil {
+				err = s.Set(sessionKey, token)
+			}
+		}
+		return token, err
+	}
+
+	binding, err := c.binding(w, r, start)
+	if err != nil {
+		return
+	}
+	if cookie, e := r.Cookie(c.properties.CookieName); e == nil && c.signed(cookie.Value, binding) {
+		return cookie.Value, nil
+	}
+	nonce, err := newToken()
+	if err != nil {
+		return
+	}
+	token = c.sign(nonce, binding)
+	cookie := &http.Cookie{
+		Name:     c.properties.CookieName,
+		Value:    token,
+		Path:     "/",
+		Secure:   c.properties.Secure,
+		SameSite: http.SameSiteStrictMode,
+	}
+	http.SetCookie(w, cookie)
+	r.AddCookie(cookie)
+	return
+}
+
+// expected returns the token that the request should submit, it is empty if there is not any,
+// or the cookie is not signed for the binding of the request, e.g. the session is absent
+func (c *csrf) expected(w http.ResponseWriter, r *http.Request) (string, error) {
+	if c.properties.Mode == Synchronizer {
+		s, err := c.session(w, r)
+		if err != nil {
+			return "", err
+		}
+		return s.GetString(sessionKey), nil
+	}
+	binding, err := c.binding(w, r, false)
+	if err == ErrBindingNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if cookie, err := r.Cookie(c.properties.CookieName); err == nil && c.signed(cookie.Value, binding) {
+		return cookie.Value, nil
+	}
+	return "", nil
+}
+
+// submitted returns the token that the request submits in the header or the form field,
+// the field is removed from the form so that it is not bound to the at.RequestForm struct
+func (c *csrf) submitted(w http.ResponseWriter, r *http.Request) (token string, err error) {
+	if token = r.Header.Get(c.properties.HeaderName); token != "" {
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		err = r.ParseForm()
+	case "multipart/form-data":
+		err = upload.Parse(w, r, c.uploadProperties)
+	default:
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	token = r.PostForm.Get(c.properties.FieldName)
+	r.PostForm.Del(c.properties.FieldName)
+	r.Form.Del(c.properties.FieldName)
+	if r.MultipartForm != nil {
+		delete(r.MultipartForm.Value, c.properties.FieldName)
+	}
+	return
+}
+
+// verify verifies the token of the unsafe request that carries the ambient credentials
+func (c *csrf) verify(w http.ResponseWriter, r *http.Request) (err error) {
+	if safe(r.Method) || !ambient(r) {
+		return
+	}
+	expected, err := c.expected(w, r)
+	if err != nil {
+		return
+	}
+	submitted, err := c.submitted(w, r)
+	if err != nil {
+		return
+	}
+	if expected == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(submitted)) != 1 {
+		return ErrInvalidToken
+	}
+	return
+}
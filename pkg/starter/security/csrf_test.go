@@ -0,0 +1,285 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"hidevops.io/hiboot/pkg/app/web/upload"
+	"hidevops.io/hiboot/pkg/starter/session"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func newTestCSRF(mode string) *csrf {
+	sessionProperties := &session.Properties{IdleTimeout: 60}
+	sessionProperties.Cookie.Name = "SID"
+	manager := session.NewManager(sessionProperties, session.NewMemoryStore())
+	return newCSRF(&csrfProperties{
+		Enabled:    true,
+		Mode:       mode,
+		CookieName: "XSRF-TOKEN",
+		HeaderName: "X-XSRF-TOKEN",
+		FieldName:  "_csrf",
+		Secret:     "secret",
+		Secure:     true,
+	}, func(params ...interface{}) interface{} {
+		if params[0] == sessionManager {
+			return manager
+		}
+		return nil
+	}, &upload.Properties{MaxSize: 1024, MaxMemory: 1024})
+}
+
+// issue returns the token and the cookies of the new token
+func issue(t *testing.T, c *csrf) (token string, cookies []*http.Cookie) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	token, err := c.token(w, r, true)
+	assert.Equal(t, nil, err)
+	again, err := c.token(w, r, true)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, token, again)
+	return token, w.Result().Cookies()
+}
+
+// cookie returns the cookie of the name
+func cookie(cookies []*http.Cookie, name string) *http.Cookie {
+	for _, c := range cookies {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func post(body string, contentType string, cookies []*http.Cookie) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	for _, cookie := range cookies {
+		r.AddCookie(cookie)
+	}
+	return r
+}
+
+func multipartForm(t *testing.T, fields map[string]string) (body string, contentType string) {
+	buf := new(bytes.Buffer)
+	mw := multipart.NewWriter(buf)
+	for k, v := range fields {
+		assert.Equal(t, nil, mw.WriteField(k, v))
+	}
+	assert.Equal(t, nil, mw.Close())
+	return buf.String(), mw.FormDataContentType()
+}
+
+func TestDoubleSubmit(t *testing.T) {
+	c := newTestCSRF(DoubleSubmit)
+	token, cookies := issue(t, c)
+
+	t.Run("should issue the token in the cookie and start the session that it is bound to", func(t *testing.T) {
+		assert.Equal(t, 2, len(cookies))
+		assert.NotEqual(t, (*http.Cookie)(nil), cookie(cookies, "SID"))
+		xsrf := cookie(cookies, "XSRF-TOKEN")
+		assert.Equal(t, token, xsrf.Value)
+		assert.Equal(t, false, xsrf.HttpOnly)
+		assert.Equal(t, true, xsrf.Secure)
+	})
+
+	t.Run("should not verify the safe request", func(t *testing.T) {
+		assert.Equal(t, nil, c.verify(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil)))
+	})
+
+	t.Run("should verify the token in the header", func(t *testing.T) {
+		r := post("", "", cookies)
+		r.Header.Set("X-XSRF-TOKEN", token)
+		assert.Equal(t, nil, c.verify(httptest.NewRecorder(), r))
+	})
+
+	t.Run("should verify the token in the form and remove the field", func(t *testing.T) {
+		r := post(url.Values{"_csrf": {token}, "name": {"foo"}}.Encode(), "application/x-www-form-urlencoded", cookies)
+		assert.Equal(t, nil, c.verify(httptest.NewRecorder(), r))
+		assert.Equal(t, url.Values{"name": {"foo"}}, r.Form)
+	})
+
+	t.Run("should verify the token in the multipart form and remove the field", func(t *testing.T) {
+		body, contentType := multipartForm(t, map[string]string{"_csrf": token, "name": "foo"})
+		r := post(body, contentType, cookies)
+		assert.Equal(t, nil, c.verify(httptest.NewRecorder(), r))
+		assert.Equal(t, url.Values{"name": {"foo"}}, r.Form)
+		assert.Equal(t, map[string][]string{"name": {"foo"}}, r.MultipartForm.Value)
+	})
+
+	t.Run("should parse the multipart form within the max size", func(t *testing.T) {
+		body, contentType := multipartForm(t, map[string]string{"_csrf": token, "name": strings.Repeat("foo", 1024)})
+		assert.Equal(t, upload.ErrTooLarge, c.verify(httptest.NewRecorder(), post(body, contentType, cookies)))
+	})
+
+	t.Run("should reject the request without token", func(t *testing.T) {
+		assert.Equal(t, ErrInvalidToken, c.verify(httptest.NewRecorder(), post("", "", cookies)))
+	})
+
+	t.Run("should reject the request without the csrf cookie", func(t *testing.T) {
+		r := post("", "", []*http.Cookie{{Name: "SID", Value: "foo"}})
+		r.Header.Set("X-XSRF-TOKEN", token)
+		assert.Equal(t, ErrInvalidToken, c.verify(httptest.NewRecorder(), r))
+	})
+
+	t.Run("should reject the forged token", func(t *testing.T) {
+		r := post("", "", cookies)
+		r.Header.Set("X-XSRF-TOKEN", "forged")
+		assert.Equal(t, ErrInvalidToken, c.verify(httptest.NewRecorder(), r))
+	})
+
+	t.Run("should reject the token that is not signed by the secret", func(t *testing.T) {
+		planted := "nonce.signature"
+		r := post("", "", []*http.Cookie{{Name: "XSRF-TOKEN", Value: planted}})
+		r.Header.Set("X-XSRF-TOKEN", planted)
+		assert.Equal(t, ErrInvalidToken, c.verify(httptest.NewRecorder(), r))
+
+		another := newTestCSRF(DoubleSubmit)
+		another.secret = []byte("another")
+		r = post("", "", cookies)
+		r.Header.Set("X-XSRF-TOKEN", token)
+		assert.Equal(t, ErrInvalidToken, another.verify(httptest.NewRecorder(), r))
+	})
+
+	t.Run("should replace the cookie that is not signed by the secret", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.AddCookie(&http.Cookie{Name: "XSRF-TOKEN", Value: "nonce.signature"})
+		issued, err := c.token(w, r, true)
+		assert.Equal(t, nil, err)
+		assert.NotEqual(t, "nonce.signature", issued)
+		binding, err := c.binding(w, r, false)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, true, c.signed(issued, binding))
+	})
+
+	t.Run("should reject the token without the session that it is bound to", func(t *testing.T) {
+		r := post("", "", []*http.Cookie{cookie(cookies, "XSRF-TOKEN")})
+		r.Header.Set("X-XSRF-TOKEN", token)
+		assert.Equal(t, ErrInvalidToken, c.verify(httptest.NewRecorder(), r))
+	})
+
+	t.Run("should reject the token that is minted for another client", func(t *testing.T) {
+		// the attacker mints the token for its own session and plants the cookie in the browser of the victim
+		minted, mintedCookies := issue(t, c)
+		r := post("", "", []*http.Cookie{cookie(cookies, "SID"), cookie(mintedCookies, "XSRF-TOKEN")})
+		r.Header.Set("X-XSRF-TOKEN", minted)
+		assert.Equal(t, ErrInvalidToken, c.verify(httptest.NewRecorder(), r))
+
+		r = post("", "", mintedCookies)
+		r.Header.Set("X-XSRF-TOKEN", minted)
+		assert.Equal(t, nil, c.verify(httptest.NewRecorder(), r))
+	})
+
+	t.Run("should not issue the token without the session on the safe request", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		_, err := c.token(w, httptest.NewRequest(http.MethodGet, "/", nil), false)
+		assert.Equal(t, ErrBindingNotFound, err)
+		assert.Equal(t, 0, len(w.Result().Cookies()))
+	})
+
+	t.Run("should not verify the request without the ambient credentials", func(t *testing.T) {
+		assert.Equal(t, nil, c.verify(httptest.NewRecorder(), post("{}", "application/json", nil)))
+
+		r := post("{}", "application/json", cookies)
+		r.Header.Set("Authorization", "Bearer token")
+		assert.Equal(t, nil, c.verify(httptest.NewRecorder(), r))
+
+		r = post("{}", "application/json", nil)
+		r.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+		assert.Equal(t, ErrInvalidToken, c.verify(httptest.NewRecorder(), r))
+	})
+}
+
+func TestSynchronizer(t *testing.T) {
+	c := newTestCSRF(Synchronizer)
+	token, cookies := issue(t, c)
+
+	t.Run("should keep the token in the session", func(t *testing.T) {
+		assert.Equal(t, 1, len(cookies))
+		assert.Equal(t, "SID", cookies[0].Name)
+	})
+
+	t.Run("should verify the token of the session", func(t *testing.T) {
+		r := post(url.Values{"_csrf": {token}}.Encode(), "application/x-www-form-urlencoded", cookies)
+		assert.Equal(t, nil, c.verify(httptest.NewRecorder(), r))
+	})
+
+	t.Run("should reject the request without session", func(t *testing.T) {
+		r := post("", "", []*http.Cookie{{Name: "XSRF-TOKEN", Value: "foo"}})
+		r.Header.Set("X-XSRF-TOKEN", token)
+		assert.Equal(t, ErrInvalidToken, c.verify(httptest.NewRecorder(), r))
+	})
+
+	t.Run("should reject the token of another session", func(t *testing.T) {
+		another, _ := issue(t, c)
+		r := post("", "", cookies)
+		r.Header.Set("X-XSRF-TOKEN", another)
+		assert.Equal(t, ErrInvalidToken, c.verify(httptest.NewRecorder(), r))
+	})
+
+	t.Run("should reject the request if the session starter is not used", func(t *testing.T) {
+		c := newCSRF(&csrfProperties{Mode: Synchronizer}, nil, nil)
+		_, err := c.token(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), true)
+		assert.Equal(t, ErrSessionNotFound, err)
+		assert.Equal(t, ErrSessionNotFound, c.verify(httptest.NewRecorder(), post("", "", cookies)))
+	})
+}
+
+func TestBindingCookie(t *testing.T) {
+	c := newCSRF(&csrfProperties{
+		Mode:          DoubleSubmit,
+		CookieName:    "XSRF-TOKEN",
+		HeaderName:    "X-XSRF-TOKEN",
+		FieldName:     "_csrf",
+		BindingCookie: "identity",
+	}, nil, nil)
+
+	issueFor := func(identity string) (token string, cookies []*http.Cookie) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.AddCookie(&http.Cookie{Name: "identity", Value: identity})
+		token, err := c.token(w, r, true)
+		assert.Equal(t, nil, err)
+		return token, append(w.Result().Cookies(), &http.Cookie{Name: "identity", Value: identity})
+	}
+
+	t.Run("should not issue the token without the binding cookie", func(t *testing.T) {
+		_, err := c.token(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), true)
+		assert.Equal(t, ErrBindingNotFound, err)
+	})
+
+	t.Run("should verify the token of the identity", func(t *testing.T) {
+		token, cookies := issueFor("johnd")
+		r := post("", "", cookies)
+		r.Header.Set("X-XSRF-TOKEN", token)
+		assert.Equal(t, nil, c.verify(httptest.NewRecorder(), r))
+	})
+
+	t.Run("should reject the token that is minted for another identity", func(t *testing.T) {
+		minted, mintedCookies := issueFor("mallory")
+		r := post("", "", []*http.Cookie{{Name: "identity", Value: "johnd"}, cookie(mintedCookies, "XSRF-TOKEN")})
+		r.Header.Set("X-XSRF-TOKEN", minted)
+		assert.Equal(t, ErrInvalidToken, c.verify(httptest.NewRecorder(), r))
+	})
+}
@@ -0,0 +1,62 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// headers writes the security response headers
+type headers struct {
+	properties *headersProperties
+	hsts       string
+}
+
+func newHeaders(p *headersProperties) *headers {
+	h := &headers{properties: p}
+	if p.HSTS.MaxAge > 0 {
+		h.hsts = fmt.Sprintf("max-age=%d", p.HSTS.MaxAge)
+		if p.HSTS.IncludeSubDomains {
+			h.hsts += "; includeSubDomains"
+		}
+		if p.HSTS.Preload {
+			h.hsts += "; preload"
+		}
+	}
+	return h
+}
+
+// secure reports whether the request is over https, the proxy is trusted to set X-Forwarded-Proto
+func secure(r *http.Request) bool {
+	return r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
+
+// write writes the headers of the response to r, the headers that the handlers set later override them
+func (h *headers) write(header http.Header, r *http.Request) {
+	set := func(name, value string) {
+		if value != "" {
+			header.Set(name, value)
+		}
+	}
+	if secure(r) {
+		set("Strict-Transport-Security", h.hsts)
+	}
+	set("Content-Security-Policy", h.properties.ContentSecurityPolicy)
+	set("X-Frame-Options", h.properties.FrameOptions)
+	set("Referrer-Policy", h.properties.ReferrerPolicy)
+	set("X-Content-Type-Options", h.properties.ContentTypeOptions)
+}
@@ -0,0 +1,61 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"crypto/tls"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHeaders(t *testing.T) {
+	p := &headersProperties{
+		HSTS:                  hstsProperties{MaxAge: 31536000, IncludeSubDomains: true},
+		ContentSecurityPolicy: "default-src 'self'",
+		FrameOptions:          "DENY",
+		ReferrerPolicy:        "strict-origin-when-cross-origin",
+		ContentTypeOptions:    "nosniff",
+	}
+	h := newHeaders(p)
+
+	t.Run("should write the security headers", func(t *testing.T) {
+		header := http.Header{}
+		h.write(header, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, "default-src 'self'", header.Get("Content-Security-Policy"))
+		assert.Equal(t, "DENY", header.Get("X-Frame-Options"))
+		assert.Equal(t, "strict-origin-when-cross-origin", header.Get("Referrer-Policy"))
+		assert.Equal(t, "nosniff", header.Get("X-Content-Type-Options"))
+		assert.Equal(t, "", header.Get("Strict-Transport-Security"))
+	})
+
+	t.Run("should write hsts over https", func(t *testing.T) {
+		header := http.Header{}
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.TLS = &tls.ConnectionState{}
+		h.write(header, r)
+		assert.Equal(t, "max-age=31536000; includeSubDomains", header.Get("Strict-Transport-Security"))
+	})
+
+	t.Run("should write hsts behind the proxy of https", func(t *testing.T) {
+		header := http.Header{}
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Forwarded-Proto", "https")
+		newHeaders(&headersProperties{HSTS: hstsProperties{MaxAge: 600, Preload: true}}).write(header, r)
+		assert.Equal(t, "max-age=600; preload", header.Get("Strict-Transport-Security"))
+		assert.Equal(t, 1, len(header))
+	})
+}
@@ -0,0 +1,107 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/app/web/upload"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/log"
+	"hidevops.io/hiboot/pkg/utils/str"
+	"net/http"
+)
+
+// Middleware writes the security response headers and verifies the csrf token of the unsafe requests
+type Middleware struct {
+	properties *Properties
+	headers    *headers
+	csrf       *csrf
+}
+
+// Token is the csrf token of the request for runtime dependency injection,
+// it is rendered in the forms as the hidden field, or it is submitted in the header by the scripts
+type Token struct {
+	at.ContextAware
+
+	// Value is the token
+	Value string
+	// FieldName is the name of the form field that the token is submitted in
+	FieldName string
+	// HeaderName is the name of the header that the token is submitted in
+	HeaderName string
+}
+
+// NewMiddleware is the constructor of security middleware, getInstance is used for looking up the session manager
+// in synchronizer mode, it can be nil in double_submit mode, and the multipart forms are parsed within the upload properties
+func NewMiddleware(p *Properties, getInstance InstanceGetter, uploadProperties *upload.Properties) *Middleware {
+	if uploadProperties == nil {
+		uploadProperties = &upload.Properties{MaxSize: 32 << 20, MaxMemory: 10 << 20}
+	}
+	return &Middleware{
+		properties: p,
+		headers:    newHeaders(&p.Headers),
+		csrf:       newCSRF(&p.CSRF, getInstance, uploadProperties),
+	}
+}
+
+func (m *Middleware) excluded(path string) bool {
+	for _, p := range m.properties.CSRF.ExcludedPaths {
+		if str.MatchPath(p, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Serve the middleware's action
+func (m *Middleware) Serve(ctx context.Context) {
+	w, r := ctx.ResponseWriter(), ctx.Request()
+	m.headers.write(w.Header(), r)
+
+	if m.properties.CSRF.Enabled && !m.excluded(r.URL.Path) {
+		// issue the cookie that the scripts submit the token of
+		if m.properties.CSRF.Mode != Synchronizer && safe(r.Method) {
+			// the session is not started for every anonymous request, the client injects *security.Token to start it
+			if _, err := m.csrf.token(w, r, false); err != nil && err != ErrBindingNotFound {
+				log.Warnf("security: failed to issue the csrf token: %v", err)
+			}
+		}
+		// the form that is parsed to look up the token field is not removed by the server once the request is replaced
+		defer upload.RemoveAll(r)
+		if err := m.csrf.verify(w, r); err != nil {
+			code := http.StatusForbidden
+			if err == upload.ErrTooLarge {
+				code = http.StatusRequestEntityTooLarge
+			} else if err != ErrInvalidToken {
+				log.Warnf("security: failed to verify the csrf token of %v: %v", r.URL.Path, err)
+				err = ErrInvalidToken
+			}
+			ctx.ResponseError(err.Error(), code)
+			return
+		}
+	}
+	ctx.Next()
+}
+
+// Token returns the csrf token of the request, it is created if it does not exist,
+// the session that the token is bound to is started if the binding cookie is not set
+func (m *Middleware) Token(ctx context.Context) (token *Token, err error) {
+	token = &Token{
+		FieldName:  m.properties.CSRF.FieldName,
+		HeaderName: m.properties.CSRF.HeaderName,
+	}
+	token.Value, err = m.csrf.token(ctx.ResponseWriter(), ctx.Request(), true)
+	return
+}
@@ -0,0 +1,69 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+type csrfProperties struct {
+	// Enabled set to true to verify the csrf token of the unsafe requests, e.g. POST, PUT, PATCH or DELETE,
+	// the requests that carry the bearer token or do not carry any cookie are not verified as they are not able to be forged across sites
+	Enabled bool `json:"enabled"`
+	// Mode is the way the token is kept, double_submit keeps it in the cookie, synchronizer keeps it in the session
+	Mode string `json:"mode" default:"double_submit"`
+	// CookieName is the name of the cookie that carries the token in double_submit mode, it is readable by the scripts
+	CookieName string `json:"cookie_name" default:"XSRF-TOKEN"`
+	// HeaderName is the name of the header that the token is submitted in
+	HeaderName string `json:"header_name" default:"X-XSRF-TOKEN"`
+	// FieldName is the name of the form field that the token is submitted in
+	FieldName string `json:"field_name" default:"_csrf"`
+	// BindingCookie is the name of the cookie of the auth identity that the double_submit token is bound to,
+	// the token is bound to the session id of the session starter if it is empty, the token without the binding is rejected
+	BindingCookie string `json:"binding_cookie"`
+	// Secret is the key that signs the token and its binding in double_submit mode, a random key is used if it is empty,
+	// it must be set to share the tokens between the instances or keep them valid after restart
+	Secret string `json:"secret"`
+	// Secure set to true to send the cookie over https only
+	Secure bool `json:"secure" default:"true"`
+	// ExcludedPaths are the paths that are not verified, the trailing * matches any path with the prefix, e.g. /api/*
+	ExcludedPaths []string `json:"excluded_paths"`
+}
+
+type hstsProperties struct {
+	// MaxAge is the time in seconds that the browsers access the host over https only, 0 means no Strict-Transport-Security
+	MaxAge int `json:"max_age" default:"31536000"`
+	// IncludeSubDomains set to true to apply the policy to the sub domains
+	IncludeSubDomains bool `json:"include_sub_domains" default:"true"`
+	// Preload set to true to allow the host to be preloaded by the browsers
+	Preload bool `json:"preload"`
+}
+
+type headersProperties struct {
+	// HSTS is the Strict-Transport-Security header, it is sent over https only
+	HSTS hstsProperties `json:"hsts"`
+	// ContentSecurityPolicy is the Content-Security-Policy header
+	ContentSecurityPolicy string `json:"content_security_policy" default:"default-src 'self'"`
+	// FrameOptions is the X-Frame-Options header, DENY or SAMEORIGIN
+	FrameOptions string `json:"frame_options" default:"DENY"`
+	// ReferrerPolicy is the Referrer-Policy header
+	ReferrerPolicy string `json:"referrer_policy" default:"strict-origin-when-cross-origin"`
+	// ContentTypeOptions is the X-Content-Type-Options header
+	ContentTypeOptions string `json:"content_type_options" default:"nosniff"`
+}
+
+// Properties the security properties
+type Properties struct {
+	// CSRF is the properties of the csrf protection
+	CSRF csrfProperties `json:"csrf"`
+	// Headers is the properties of the security response headers, the empty header is not sent
+	Headers headersProperties `json:"headers"`
+}
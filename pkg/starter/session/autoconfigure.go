@@ -0,0 +1,73 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package session provides the hiboot starter for injectable server side session
+package session
+
+import (
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/log"
+)
+
+const (
+	// Profile is the profile of session, it should be as same as the package name
+	Profile = "session"
+)
+
+type configuration struct {
+	at.AutoConfiguration
+
+	Properties Properties `mapstructure:"session"`
+}
+
+func init() {
+	app.Register(newConfiguration)
+}
+
+func newConfiguration() *configuration {
+	return &configuration{}
+}
+
+// store returns the store of the properties, the memory store is used if the store is not found
+func (c *configuration) store() Store {
+	if c.Properties.Store == FileStore {
+		store, err := NewFileStore(c.Properties.File.Dir)
+		if err == nil {
+			return store
+		}
+		log.Errorf("session: failed to create the file store in %v: %v", c.Properties.File.Dir, err)
+	} else if store, ok := stores.Get(c.Properties.Store); ok {
+		return store.(Store)
+	} else {
+		log.Errorf("session: store %v is not registered", c.Properties.Store)
+	}
+	store, _ := stores.Get(MemoryStore)
+	return store.(Store)
+}
+
+// Manager is the session manager
+func (c *configuration) Manager() *Manager {
+	return NewManager(&c.Properties, c.store())
+}
+
+// Session is the session of the request for runtime dependency injection
+func (c *configuration) Session(ctx context.Context, manager *Manager) *Session {
+	s, err := manager.Session(ctx.ResponseWriter(), ctx.Request())
+	if err != nil {
+		log.Warnf("session: failed to load the session: %v", err)
+	}
+	return s
+}
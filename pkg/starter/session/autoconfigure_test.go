@@ -0,0 +1,88 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"hidevops.io/hiboot/pkg/app/web"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/starter/session"
+	"net/http"
+	"testing"
+)
+
+type fooController struct {
+	at.RestController
+}
+
+func newFooController() *fooController {
+	return &fooController{}
+}
+
+// GetLogin GET /foo/login
+func (c *fooController) GetLogin(s *session.Session) {
+	s.Regenerate()
+	s.Set("user", "alice")
+}
+
+// GetUser GET /foo/user
+func (c *fooController) GetUser(s *session.Session) string {
+	return s.GetString("user")
+}
+
+// GetLogout GET /foo/logout
+func (c *fooController) GetLogout(s *session.Session) {
+	s.Invalidate()
+}
+
+func TestSession(t *testing.T) {
+	testApp := web.NewTestApp(newFooController).Run(t)
+
+	var cookie *http.Cookie
+	t.Run("should issue the session cookie", func(t *testing.T) {
+		cookies := testApp.Get("/foo/login").
+			Expect().Status(http.StatusOK).
+			Raw().Cookies()
+		assert.Equal(t, 1, len(cookies))
+		cookie = cookies[0]
+		assert.Equal(t, "HIBOOT_SESSION", cookie.Name)
+		assert.Equal(t, true, cookie.HttpOnly)
+		assert.Equal(t, true, cookie.Secure)
+	})
+
+	t.Run("should get the value of the session", func(t *testing.T) {
+		testApp.Get("/foo/user").
+			WithCookie(cookie.Name, cookie.Value).
+			Expect().Status(http.StatusOK).
+			Body().Equal("alice")
+	})
+
+	t.Run("should not get the value without session", func(t *testing.T) {
+		testApp.Get("/foo/user").
+			Expect().Status(http.StatusOK).
+			Body().Equal("")
+	})
+
+	t.Run("should invalidate the session", func(t *testing.T) {
+		testApp.Get("/foo/logout").
+			WithCookie(cookie.Name, cookie.Value).
+			Expect().Status(http.StatusOK)
+
+		testApp.Get("/foo/user").
+			WithCookie(cookie.Name, cookie.Value).
+			Expect().Status(http.StatusOK).
+			Body().Equal("")
+	})
+}
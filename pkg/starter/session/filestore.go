@@ -0,0 +1,159 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const fileExt = ".session"
+
+// ErrInvalidID the session id is not valid
+var ErrInvalidID = errors.New("[session] invalid session id")
+
+type fileEntry struct {
+	Record   *Record   `json:"record"`
+	ExpireAt time.Time `json:"expire_at"`
+}
+
+type fileStore struct {
+	dir     string
+	mu      sync.Mutex
+	sweptAt time.Time
+}
+
+// NewFileStore create the file store, each session is stored in a file of the directory,
+// the store can be shared by the instances that mount the same directory
+func NewFileStore(dir string) (Store, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &fileStore{dir: dir}, nil
+}
+
+// path returns the file of the session id, the id must not escape the directory
+func (s *fileStore) path(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return "", ErrInvalidID
+	}
+	return filepath.Join(s.dir, id+fileExt), nil
+}
+
+func (s *fileStore) read(path string) (e *fileEntry, err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	e = new(fileEntry)
+	err = json.Unmarshal(data, e)
+	return
+}
+
+// due reports whether the expired files should be swept, they are swept at most once a minute
+func (s *fileStore) due(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.Sub(s.sweptAt) < time.Minute {
+		return false
+	}
+	s.sweptAt = now
+	return true
+}
+
+// sweep remove the expired files
+func (s *fileStore) sweep(now time.Time) {
+	paths, _ := filepath.Glob(filepath.Join(s.dir, "*"+fileExt))
+	for _, path := range paths {
+		if e, err := s.read(path); err == nil && !e.ExpireAt.IsZero() && now.After(e.ExpireAt) {
+			os.Remove(path)
+		}
+	}
+}
+
+// Get implements Store
+func (s *fileStore) Get(id string) (record *Record, err error) {
+	path, err := s.path(id)
+	if err != nil {
+		return
+	}
+	e, err := s.read(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return
+	}
+	if !e.ExpireAt.IsZero() && time.Now().After(e.ExpireAt) {
+		os.Remove(path)
+		return
+	}
+	return e.Record, nil
+}
+
+// Save implements Store, the file is replaced atomically
+func (s *fileStore) Save(id string, record *Record, ttl time.Duration) (err error) {
+	path, err := s.path(id)
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	if s.due(now) {
+		go s.sweep(now)
+	}
+
+	e := &fileEntry{Record: record}
+	if ttl > 0 {
+		e.ExpireAt = now.Add(ttl)
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+
+	tmp, err := ioutil.TempFile(s.dir, id+".tmp")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if e := tmp.Close(); err == nil {
+		err = e
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return
+}
+
+// Delete implements Store
+func (s *fileStore) Delete(id string) error {
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(path); os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
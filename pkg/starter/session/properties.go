@@ -0,0 +1,50 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+type cookieProperties struct {
+	// Name is the name of the cookie that carries the session id
+	Name string `json:"name" default:"HIBOOT_SESSION"`
+	// Path is the path of the cookie
+	Path string `json:"path" default:"/"`
+	// Domain is the domain of the cookie, it is the host of the request if it is empty
+	Domain string `json:"domain"`
+	// Secure set to true to send the cookie over https only
+	Secure bool `json:"secure" default:"true"`
+	// HttpOnly set to true to hide the cookie from the scripts
+	HttpOnly bool `json:"http_only" default:"true"`
+	// SameSite is the same site policy of the cookie, lax, strict or none
+	SameSite string `json:"same_site" default:"lax"`
+}
+
+type fileProperties struct {
+	// Dir is the directory that the sessions are stored in
+	Dir string `json:"dir" default:"sessions"`
+}
+
+// Properties the session properties
+type Properties struct {
+	// Cookie is the properties of the session cookie
+	Cookie cookieProperties `json:"cookie"`
+	// IdleTimeout is the timeout in seconds that the session expires if it is not accessed, 0 means no idle timeout,
+	// the session is saved on access at most once per quarter of it
+	IdleTimeout int `json:"idle_timeout" default:"1800"`
+	// AbsoluteTimeout is the timeout in seconds that the session expires after it is created, 0 means no absolute timeout
+	AbsoluteTimeout int `json:"absolute_timeout" default:"86400"`
+	// Store is the name of the store, memory, file or the name of the registered store
+	Store string `json:"store" default:"memory"`
+	// File is the properties of the file store
+	File fileProperties `json:"file"`
+}
@@ -0,0 +1,280 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"hidevops.io/hiboot/pkg/at"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// idLength is the length of the encoded session id, it is 256 bits of randomness
+	idLength = 43
+
+	// slideDivisor limits the writes of sliding the idle timeout, the session is saved on access
+	// only if it is not accessed within the idle timeout divided by it
+	slideDivisor = 4
+)
+
+// ErrKeyNotFound the key is not found in the session
+var ErrKeyNotFound = errors.New("[session] key not found")
+
+// Manager loads the sessions of the requests from the store and writes the session cookies
+type Manager struct {
+	properties *Properties
+	store      Store
+	now        func() time.Time
+}
+
+// NewManager is the constructor of Manager
+func NewManager(properties *Properties, store Store) *Manager {
+	return &Manager{
+		properties: properties,
+		store:      store,
+		now:        time.Now,
+	}
+}
+
+// Session is the server side session of the request, it can be injected into the controller methods,
+// the changes are saved to the store as soon as they are made, so the session can be used before the response is written
+type Session struct {
+	at.ContextAware
+
+	manager *Manager
+	w       http.ResponseWriter
+	r       *http.Request
+	id      string
+	record  *Record
+	isNew   bool
+}
+
+func newID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func validID(id string) bool {
+	if len(id) != idLength {
+		return false
+	}
+	_, err := base64.RawURLEncoding.DecodeString(id)
+	return err == nil
+}
+
+// Session returns the session of the request, a new session is returned if the request does not carry a valid one,
+// the new session is not saved until a value is set
+func (m *Manager) Session(w http.ResponseWriter, r *http.Request) (s *Session, err error) {
+	now := m.now()
+	if cookie, e := r.Cookie(m.properties.Cookie.Name); e == nil && validID(cookie.Value) {
+		var record *Record
+		record, err = m.store.Get(cookie.Value)
+		if record != nil && !m.expired(record, now) {
+			s = &Session{manager: m, w: w, r: r, id: cookie.Value, record: record}
+			if m.sliding(record, now) {
+				err = s.save()
+			}
+			return
+		}
+		if record != nil {
+			err = m.store.Delete(cookie.Value)
+		}
+	}
+
+	s = &Session{
+		manager: m,
+		w:       w,
+		r:       r,
+		record:  &Record{Values: make(map[string]json.RawMessage), CreatedAt: now, AccessedAt: now},
+		isNew:   true,
+	}
+	return
+}
+
+func (m *Manager) expired(record *Record, now time.Time) bool {
+	idle := time.Duration(m.properties.IdleTimeout) * time.Second
+	absolute := time.Duration(m.properties.AbsoluteTimeout) * time.Second
+	return (idle > 0 && now.Sub(record.AccessedAt) > idle) ||
+		(absolute > 0 && now.Sub(record.CreatedAt) > absolute)
+}
+
+// sliding checks if the idle timeout of the record is due to slide, the record is not saved on each access
+// so that the store is not written by every request, the session expires between 3/4 and all of the idle timeout after the last access
+func (m *Manager) sliding(record *Record, now time.Time) bool {
+	idle := time.Duration(m.properties.IdleTimeout) * time.Second
+	return idle > 0 && now.Sub(record.AccessedAt) >= idle/slideDivisor
+}
+
+// ttl returns the time to live of the record, it is the shorter one of the idle timeout and the absolute timeout
+func (m *Manager) ttl(record *Record, now time.Time) (ttl time.Duration) {
+	ttl = time.Duration(m.properties.IdleTimeout) * time.Second
+	if m.properties.AbsoluteTimeout > 0 {
+		remaining := record.CreatedAt.Add(time.Duration(m.properties.AbsoluteTimeout) * time.Second).Sub(now)
+		if ttl == 0 || remaining < ttl {
+			ttl = remaining
+		}
+	}
+	return
+}
+
+func (m *Manager) cookie(value string, maxAge int) *http.Cookie {
+	p := m.properties.Cookie
+	cookie := &http.Cookie{
+		Name:     p.Name,
+		Value:    value,
+		Path:     p.Path,
+		Domain:   p.Domain,
+		MaxAge:   maxAge,
+		Secure:   p.Secure,
+		HttpOnly: p.HttpOnly,
+	}
+	switch strings.ToLower(p.SameSite) {
+	case "strict":
+		cookie.SameSite = http.SameSiteStrictMode
+	case "none":
+		cookie.SameSite = http.SameSiteNoneMode
+	case "lax":
+		cookie.SameSite = http.SameSiteLaxMode
+	}
+	return cookie
+}
+
+// setRequestCookie replaces the session cookie of the request, so that the sessions that are loaded later
+// within the request are the same one, the cookie is removed if the id is empty
+func (s *Session) setRequestCookie(id string) {
+	name := s.manager.properties.Cookie.Name
+	cookies := s.r.Cookies()
+	s.r.Header.Del("Cookie")
+	for _, cookie := range cookies {
+		if cookie.Name != name {
+			s.r.AddCookie(cookie)
+		}
+	}
+	if id != "" {
+		s.r.AddCookie(&http.Cookie{Name: name, Value: id})
+	}
+}
+
+// save saves the record, the id and the cookie are issued if the session is not saved yet
+func (s *Session) save() (err error) {
+	now := s.manager.now()
+	if s.id == "" {
+		if s.id, err = newID(); err != nil {
+			return
+		}
+		http.SetCookie(s.w, s.manager.cookie(s.id, 0))
+		s.setRequestCookie(s.id)
+	}
+	s.record.AccessedAt = now
+	return s.manager.store.Save(s.id, s.record, s.manager.ttl(s.record, now))
+}
+
+// update reloads the record before the values are changed, so that the changes that are made by the other sessions
+// of the same id are kept, e.g. the session that the middleware loads within the same request
+func (s *Session) update(change func(values map[string]json.RawMessage)) error {
+	if s.id != "" {
+		record, err := s.manager.store.Get(s.id)
+		if err != nil {
+			return err
+		}
+		if record != nil {
+			s.record = record
+		}
+	}
+	change(s.record.Values)
+	return s.save()
+}
+
+// ID returns the session id, it is empty if the new session is not saved yet
+func (s *Session) ID() string {
+	return s.id
+}
+
+// IsNew reports whether the session is created by current request
+func (s *Session) IsNew() bool {
+	return s.isNew
+}
+
+// CreatedAt returns the time that the session is created
+func (s *Session) CreatedAt() time.Time {
+	return s.record.CreatedAt
+}
+
+// Get decodes the value of the key into value, it returns ErrKeyNotFound if the key does not exist
+func (s *Session) Get(key string, value interface{}) error {
+	data, ok := s.record.Values[key]
+	if !ok {
+		return ErrKeyNotFound
+	}
+	return json.Unmarshal(data, value)
+}
+
+// GetString returns the string value of the key, it is empty if the key does not exist
+func (s *Session) GetString(key string) (value string) {
+	s.Get(key, &value)
+	return
+}
+
+// Set sets the value of the key, the value is encoded in json
+func (s *Session) Set(key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return s.update(func(values map[string]json.RawMessage) {
+		values[key] = data
+	})
+}
+
+// Delete deletes the key from the session
+func (s *Session) Delete(key string) error {
+	return s.update(func(values map[string]json.RawMessage) {
+		delete(values, key)
+	})
+}
+
+// Regenerate issues a new id for the session and keeps the values, it should be called once the user is authenticated
+// to prevent the session fixation
+func (s *Session) Regenerate() (err error) {
+	if s.id != "" {
+		if err = s.manager.store.Delete(s.id); err != nil {
+			return
+		}
+		s.id = ""
+	}
+	return s.save()
+}
+
+// Invalidate deletes the session from the store and expires the cookie, e.g. on logout
+func (s *Session) Invalidate() (err error) {
+	if s.id != "" {
+		err = s.manager.store.Delete(s.id)
+		http.SetCookie(s.w, s.manager.cookie("", -1))
+		s.setRequestCookie("")
+	}
+	now := s.manager.now()
+	s.id = ""
+	s.isNew = true
+	s.record = &Record{Values: make(map[string]json.RawMessage), CreatedAt: now, AccessedAt: now}
+	return
+}
@@ -0,0 +1,236 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+// countingStore counts the saves of the store
+type countingStore struct {
+	Store
+	saves int
+}
+
+func (s *countingStore) Save(id string, record *Record, ttl time.Duration) error {
+	s.saves++
+	return s.Store.Save(id, record, ttl)
+}
+
+func newTestManager() (*Manager, *clock) {
+	c := &clock{now: time.Now()}
+	m := NewManager(&Properties{
+		Cookie:          cookieProperties{Name: "SID", Path: "/", Secure: true, HttpOnly: true, SameSite: "lax"},
+		IdleTimeout:     60,
+		AbsoluteTimeout: 300,
+	}, NewMemoryStore())
+	m.now = c.Now
+	return m, c
+}
+
+func request(cookies ...*http.Cookie) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, cookie := range cookies {
+		r.AddCookie(cookie)
+	}
+	return r
+}
+
+// login creates the session with a value and returns its cookie
+func login(t *testing.T, m *Manager) *http.Cookie {
+	w := httptest.NewRecorder()
+	s, err := m.Session(w, request())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, s.Set("user", "alice"))
+	cookies := w.Result().Cookies()
+	assert.Equal(t, 1, len(cookies))
+	return cookies[0]
+}
+
+func TestManager(t *testing.T) {
+	t.Run("should not issue the cookie for the session without values", func(t *testing.T) {
+		m, _ := newTestManager()
+		w := httptest.NewRecorder()
+		s, err := m.Session(w, request())
+		assert.Equal(t, nil, err)
+		assert.Equal(t, true, s.IsNew())
+		assert.Equal(t, "", s.ID())
+		assert.Equal(t, "", w.Header().Get("Set-Cookie"))
+	})
+
+	t.Run("should issue the cookie with secure defaults", func(t *testing.T) {
+		m, _ := newTestManager()
+		w := httptest.NewRecorder()
+		s, _ := m.Session(w, request())
+		assert.Equal(t, nil, s.Set("user", "alice"))
+		assert.Equal(t, idLength, len(s.ID()))
+
+		header := w.Header().Get("Set-Cookie")
+		assert.Equal(t, true, strings.HasPrefix(header, "SID="+s.ID()))
+		assert.Contains(t, header, "HttpOnly")
+		assert.Contains(t, header, "Secure")
+		assert.Contains(t, header, "SameSite=Lax")
+	})
+
+	t.Run("should load the session of the cookie", func(t *testing.T) {
+		m, _ := newTestManager()
+		cookie := login(t, m)
+
+		w := httptest.NewRecorder()
+		s, err := m.Session(w, request(cookie))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, false, s.IsNew())
+		assert.Equal(t, cookie.Value, s.ID())
+		assert.Equal(t, "alice", s.GetString("user"))
+		assert.Equal(t, "", w.Header().Get("Set-Cookie"))
+	})
+
+	t.Run("should decode the values", func(t *testing.T) {
+		m, _ := newTestManager()
+		s, _ := m.Session(httptest.NewRecorder(), request())
+		assert.Equal(t, nil, s.Set("cart", []int{1, 2}))
+
+		var cart []int
+		assert.Equal(t, nil, s.Get("cart", &cart))
+		assert.Equal(t, []int{1, 2}, cart)
+		assert.Equal(t, ErrKeyNotFound, s.Get("missing", &cart))
+
+		assert.Equal(t, nil, s.Delete("cart"))
+		assert.Equal(t, ErrKeyNotFound, s.Get("cart", &cart))
+	})
+
+	t.Run("should share the session within the request", func(t *testing.T) {
+		m, _ := newTestManager()
+		w, r := httptest.NewRecorder(), request()
+		first, _ := m.Session(w, r)
+		assert.Equal(t, nil, first.Set("user", "alice"))
+
+		second, _ := m.Session(w, r)
+		assert.Equal(t, first.ID(), second.ID())
+		assert.Equal(t, nil, second.Set("role", "admin"))
+		assert.Equal(t, nil, first.Set("theme", "dark"))
+
+		s, _ := m.Session(httptest.NewRecorder(), request(w.Result().Cookies()[0]))
+		assert.Equal(t, "alice", s.GetString("user"))
+		assert.Equal(t, "admin", s.GetString("role"))
+		assert.Equal(t, "dark", s.GetString("theme"))
+		assert.Equal(t, 1, len(w.Result().Cookies()))
+	})
+
+	t.Run("should ignore the forged session id", func(t *testing.T) {
+		m, _ := newTestManager()
+		s, err := m.Session(httptest.NewRecorder(), request(&http.Cookie{Name: "SID", Value: "../../etc/passwd"}))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, true, s.IsNew())
+	})
+
+	t.Run("should expire the idle session", func(t *testing.T) {
+		m, c := newTestManager()
+		cookie := login(t, m)
+
+		c.now = c.now.Add(50 * time.Second)
+		s, _ := m.Session(httptest.NewRecorder(), request(cookie))
+		assert.Equal(t, false, s.IsNew())
+
+		c.now = c.now.Add(50 * time.Second)
+		s, _ = m.Session(httptest.NewRecorder(), request(cookie))
+		assert.Equal(t, false, s.IsNew())
+
+		c.now = c.now.Add(61 * time.Second)
+		s, _ = m.Session(httptest.NewRecorder(), request(cookie))
+		assert.Equal(t, true, s.IsNew())
+		assert.Equal(t, "", s.GetString("user"))
+	})
+
+	t.Run("should slide the idle timeout only if the session is not accessed recently", func(t *testing.T) {
+		m, c := newTestManager()
+		store := &countingStore{Store: m.store}
+		m.store = store
+		cookie := login(t, m)
+		saves := store.saves
+
+		c.now = c.now.Add(10 * time.Second)
+		s, _ := m.Session(httptest.NewRecorder(), request(cookie))
+		assert.Equal(t, false, s.IsNew())
+		assert.Equal(t, saves, store.saves)
+
+		c.now = c.now.Add(10 * time.Second)
+		s, _ = m.Session(httptest.NewRecorder(), request(cookie))
+		assert.Equal(t, false, s.IsNew())
+		assert.Equal(t, saves+1, store.saves)
+
+		c.now = c.now.Add(50 * time.Second)
+		s, _ = m.Session(httptest.NewRecorder(), request(cookie))
+		assert.Equal(t, false, s.IsNew())
+	})
+
+	t.Run("should expire the session after the absolute timeout", func(t *testing.T) {
+		m, c := newTestManager()
+		cookie := login(t, m)
+
+		for i := 0; i < 6; i++ {
+			c.now = c.now.Add(50 * time.Second)
+			s, _ := m.Session(httptest.NewRecorder(), request(cookie))
+			assert.Equal(t, false, s.IsNew())
+		}
+
+		c.now = c.now.Add(50 * time.Second)
+		s, _ := m.Session(httptest.NewRecorder(), request(cookie))
+		assert.Equal(t, true, s.IsNew())
+	})
+
+	t.Run("should regenerate the session id", func(t *testing.T) {
+		m, _ := newTestManager()
+		cookie := login(t, m)
+
+		w := httptest.NewRecorder()
+		s, _ := m.Session(w, request(cookie))
+		assert.Equal(t, nil, s.Regenerate())
+		assert.NotEqual(t, cookie.Value, s.ID())
+		assert.Equal(t, s.ID(), w.Result().Cookies()[0].Value)
+
+		old, _ := m.Session(httptest.NewRecorder(), request(cookie))
+		assert.Equal(t, true, old.IsNew())
+		renewed, _ := m.Session(httptest.NewRecorder(), request(w.Result().Cookies()[0]))
+		assert.Equal(t, "alice", renewed.GetString("user"))
+	})
+
+	t.Run("should invalidate the session", func(t *testing.T) {
+		m, _ := newTestManager()
+		cookie := login(t, m)
+
+		w := httptest.NewRecorder()
+		s, _ := m.Session(w, request(cookie))
+		assert.Equal(t, nil, s.Invalidate())
+		assert.Equal(t, "", s.GetString("user"))
+		assert.Equal(t, true, w.Result().Cookies()[0].MaxAge < 0)
+
+		s, _ = m.Session(httptest.NewRecorder(), request(cookie))
+		assert.Equal(t, true, s.IsNew())
+	})
+}
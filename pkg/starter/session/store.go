@@ -0,0 +1,137 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"encoding/json"
+	"hidevops.io/hiboot/pkg/utils/cmap"
+	"sync"
+	"time"
+)
+
+const (
+	// MemoryStore is the name of in-memory store
+	MemoryStore = "memory"
+	// FileStore is the name of file store
+	FileStore = "file"
+)
+
+// Record is the state of the session that is kept in the store, the values are encoded in json
+type Record struct {
+	Values     map[string]json.RawMessage `json:"values"`
+	CreatedAt  time.Time                  `json:"created_at"`
+	AccessedAt time.Time                  `json:"accessed_at"`
+}
+
+// Store is the storage of the sessions, the store may be shared by many instances
+type Store interface {
+	// Get returns the record of the session id, the record is nil if it does not exist or it is expired
+	Get(id string) (record *Record, err error)
+	// Save saves the record of the session id, the record expires after ttl, 0 means it never expires
+	Save(id string, record *Record, ttl time.Duration) error
+	// Delete deletes the record of the session id
+	Delete(id string) error
+}
+
+var stores cmap.ConcurrentMap
+
+func init() {
+	stores = cmap.New()
+	RegisterStore(MemoryStore, NewMemoryStore())
+}
+
+// RegisterStore register the store, it can be referenced by store in application.yml
+func RegisterStore(name string, store Store) {
+	stores.Set(name, store)
+}
+
+// copy returns the copy of the record, the values are not shared with the copy
+func (r *Record) copy() *Record {
+	c := *r
+	c.Values = make(map[string]json.RawMessage, len(r.Values))
+	for k, v := range r.Values {
+		c.Values[k] = v
+	}
+	return &c
+}
+
+type memoryEntry struct {
+	record   *Record
+	expireAt time.Time
+}
+
+type memoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+	sweptAt time.Time
+}
+
+// NewMemoryStore create the in-memory store, it is only shared within current process
+func NewMemoryStore() Store {
+	return &memoryStore{entries: make(map[string]*memoryEntry)}
+}
+
+// sweep remove the expired records, it is called with lock held
+func (s *memoryStore) sweep(now time.Time) {
+	if now.Sub(s.sweptAt) < time.Minute {
+		return
+	}
+	s.sweptAt = now
+	for id, e := range s.entries {
+		if !e.expireAt.IsZero() && now.After(e.expireAt) {
+			delete(s.entries, id)
+		}
+	}
+}
+
+// Get implements Store
+func (s *memoryStore) Get(id string) (record *Record, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[id]
+	if !ok {
+		return
+	}
+	if !e.expireAt.IsZero() && time.Now().After(e.expireAt) {
+		delete(s.entries, id)
+		return
+	}
+	return e.record.copy(), nil
+}
+
+// Save implements Store
+func (s *memoryStore) Save(id string, record *Record, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+	e := &memoryEntry{record: record.copy()}
+	if ttl > 0 {
+		e.expireAt = now.Add(ttl)
+	}
+	s.entries[id] = e
+	return nil
+}
+
+// Delete implements Store
+func (s *memoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, id)
+	return nil
+}
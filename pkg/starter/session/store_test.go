@@ -0,0 +1,102 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func testStore(t *testing.T, store Store) {
+	record := &Record{
+		Values:    map[string]json.RawMessage{"user": json.RawMessage(`"alice"`)},
+		CreatedAt: time.Now(),
+	}
+
+	t.Run("should not get the missing record", func(t *testing.T) {
+		r, err := store.Get("missing")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, (*Record)(nil), r)
+	})
+
+	t.Run("should save and get the record", func(t *testing.T) {
+		assert.Equal(t, nil, store.Save("foo", record, time.Minute))
+		r, err := store.Get("foo")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, `"alice"`, string(r.Values["user"]))
+	})
+
+	t.Run("should delete the record", func(t *testing.T) {
+		assert.Equal(t, nil, store.Delete("foo"))
+		assert.Equal(t, nil, store.Delete("foo"))
+		r, _ := store.Get("foo")
+		assert.Equal(t, (*Record)(nil), r)
+	})
+
+	t.Run("should not get the expired record", func(t *testing.T) {
+		assert.Equal(t, nil, store.Save("bar", record, time.Millisecond))
+		time.Sleep(10 * time.Millisecond)
+		r, err := store.Get("bar")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, (*Record)(nil), r)
+	})
+
+	t.Run("should keep the record without ttl", func(t *testing.T) {
+		assert.Equal(t, nil, store.Save("baz", record, 0))
+		r, _ := store.Get("baz")
+		assert.NotEqual(t, (*Record)(nil), r)
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	testStore(t, store)
+
+	t.Run("should not share the values with the store", func(t *testing.T) {
+		record := &Record{Values: map[string]json.RawMessage{}}
+		assert.Equal(t, nil, store.Save("qux", record, time.Minute))
+		record.Values["user"] = json.RawMessage(`"bob"`)
+		r, _ := store.Get("qux")
+		assert.Equal(t, 0, len(r.Values))
+	})
+}
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "session")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+
+	store, err := NewFileStore(dir)
+	assert.Equal(t, nil, err)
+	testStore(t, store)
+
+	t.Run("should share the records between the stores of the same directory", func(t *testing.T) {
+		another, err := NewFileStore(dir)
+		assert.Equal(t, nil, err)
+		r, err := another.Get("baz")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, `"alice"`, string(r.Values["user"]))
+	})
+
+	t.Run("should reject the id that escapes the directory", func(t *testing.T) {
+		_, err := store.Get("../passwd")
+		assert.Equal(t, ErrInvalidID, err)
+		assert.Equal(t, ErrInvalidID, store.Save("a/b", &Record{}, 0))
+	})
+}
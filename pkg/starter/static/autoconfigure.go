@@ -0,0 +1,84 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package static provides the hiboot starter for serving the static resources
+package static
+
+import (
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/log"
+	"net/http"
+	"strings"
+)
+
+const (
+	// Profile is the profile of static, it should be as same as the package name
+	Profile = "static"
+
+	// pathParam is the wildcard path param of the mapping routes
+	pathParam = "filepath"
+)
+
+type configuration struct {
+	at.AutoConfiguration
+
+	Properties         Properties `mapstructure:"static"`
+	applicationContext app.ApplicationContext
+}
+
+// Resources is the static resources that are mapped onto the routes
+type Resources struct {
+	Handlers []*Handler
+}
+
+func init() {
+	app.Register(newConfiguration)
+}
+
+func newConfiguration(applicationContext app.ApplicationContext) *configuration {
+	return &configuration{
+		applicationContext: applicationContext,
+	}
+}
+
+// Resources maps the static resources onto the routes of GET and HEAD methods
+func (c *configuration) Resources() *Resources {
+	resources := new(Resources)
+	for i := range c.Properties.Mappings {
+		mapping := &c.Properties.Mappings[i]
+		h, err := NewHandler(mapping)
+		if err != nil {
+			log.Errorf("static: failed to map %v: %v", mapping.Path, err)
+			continue
+		}
+		resources.Handlers = append(resources.Handlers, h)
+
+		prefix := strings.TrimSuffix(mapping.Path, "/")
+		serve := func(ctx context.Context) {
+			if !h.ServeFile(ctx.ResponseWriter(), ctx.Request(), ctx.Params().Get(pathParam)) {
+				ctx.NotFound()
+			}
+		}
+		for _, method := range []string{http.MethodGet, http.MethodHead} {
+			if prefix != "" {
+				c.applicationContext.Handle(method, prefix, serve)
+			}
+			c.applicationContext.Handle(method, prefix+"/{"+pathParam+":path}", serve)
+		}
+		log.Infof("Mapped \"%v\" onto static resources %v%v", mapping.Path, mapping.Dir, mapping.FileSystem)
+	}
+	return resources
+}
@@ -0,0 +1,86 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package static_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"hidevops.io/hiboot/pkg/app/web"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/starter/static"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type fooController struct {
+	at.RestController
+}
+
+func newFooController() *fooController {
+	return &fooController{}
+}
+
+// Get GET /foo
+func (c *fooController) Get() string {
+	return "foo"
+}
+
+func TestStaticResources(t *testing.T) {
+	dir, err := ioutil.TempDir("", "static")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+	assert.Equal(t, nil, ioutil.WriteFile(filepath.Join(dir, "index.html"), []byte("<html>app</html>"), 0644))
+	assert.Equal(t, nil, ioutil.WriteFile(filepath.Join(dir, "app.js"), []byte("console.log('app')"), 0644))
+	assert.Equal(t, nil, ioutil.WriteFile(filepath.Join(dir, "app.js.gz"), []byte("gzipped app"), 0644))
+
+	testApp := web.NewTestApp(newFooController).
+		SetProperty("static.mappings", []static.Mapping{
+			{Path: "/assets", Dir: dir, CacheControl: "public, max-age=31536000", Precompressed: true},
+			{Path: "/", Dir: dir, Spa: true},
+		}).
+		Run(t)
+
+	t.Run("should serve the static resource", func(t *testing.T) {
+		testApp.Get("/assets/app.js").
+			Expect().Status(http.StatusOK).
+			Header("Cache-Control").Equal("public, max-age=31536000")
+	})
+
+	t.Run("should serve the precompressed resource", func(t *testing.T) {
+		testApp.Get("/assets/app.js").
+			WithHeader("Accept-Encoding", "gzip").
+			Expect().Status(http.StatusOK).
+			Header("Content-Encoding").Equal("gzip")
+	})
+
+	t.Run("should not serve the missing resource", func(t *testing.T) {
+		testApp.Get("/assets/missing.js").
+			Expect().Status(http.StatusNotFound)
+	})
+
+	t.Run("should serve the controller", func(t *testing.T) {
+		testApp.Get("/foo").
+			Expect().Status(http.StatusOK).
+			Body().Equal("foo")
+	})
+
+	t.Run("should fall back to the index of the single page app", func(t *testing.T) {
+		testApp.Get("/users/42").
+			Expect().Status(http.StatusOK).
+			Body().Equal("<html>app</html>")
+	})
+}
@@ -0,0 +1,181 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package static
+
+import (
+	"errors"
+	"fmt"
+	"hidevops.io/hiboot/pkg/utils/cmap"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+const defaultIndex = "index.html"
+
+// encodings are the precompressed encodings in the order of preference
+var encodings = []struct {
+	name string
+	ext  string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+var fileSystems cmap.ConcurrentMap
+
+func init() {
+	fileSystems = cmap.New()
+}
+
+// RegisterFileSystem register the file system, e.g. the embedded resources, it can be referenced by file_system in application.yml
+func RegisterFileSystem(name string, fs http.FileSystem) {
+	fileSystems.Set(name, fs)
+}
+
+// Handler serves the static resources of a mapping
+type Handler struct {
+	mapping *Mapping
+	fs      http.FileSystem
+	index   string
+}
+
+// NewHandler create the handler of the mapping, the mapping must have either the dir or the file system
+func NewHandler(mapping *Mapping) (h *Handler, err error) {
+	h = &Handler{mapping: mapping, index: mapping.Index}
+	if h.index == "" {
+		h.index = defaultIndex
+	}
+	switch {
+	case mapping.FileSystem != "":
+		fs, ok := fileSystems.Get(mapping.FileSystem)
+		if !ok {
+			return nil, fmt.Errorf("file system %v is not registered", mapping.FileSystem)
+		}
+		h.fs = fs.(http.FileSystem)
+	case mapping.Dir != "":
+		h.fs = http.Dir(mapping.Dir)
+	default:
+		// http.Dir("") would serve the working directory of the application
+		return nil, errors.New("the mapping has neither dir nor file_system")
+	}
+	return
+}
+
+// open opens the file that is not a directory
+func (h *Handler) open(name string) (file http.File, info os.FileInfo, err error) {
+	file, err = h.fs.Open(name)
+	if err != nil {
+		return
+	}
+	info, err = file.Stat()
+	if err == nil && info.IsDir() {
+		err = os.ErrNotExist
+	}
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	return
+}
+
+// ServeFile serves the file of the name that is relative to the mapping path, it returns false if the file is not found
+func (h *Handler) ServeFile(w http.ResponseWriter, r *http.Request, name string) bool {
+	requested := path.Clean("/" + name)
+	name = requested
+	file, info, err := h.open(name)
+	if err != nil {
+		name = path.Join(requested, h.index)
+		file, info, err = h.open(name)
+	}
+	spa := false
+	if err != nil {
+		// the routes of the single page app have no file extension, the missing assets are not found
+		if !h.mapping.Spa || path.Ext(requested) != "" {
+			return false
+		}
+		name = "/" + h.index
+		if file, info, err = h.open(name); err != nil {
+			return false
+		}
+		spa = true
+	}
+	defer file.Close()
+
+	header := w.Header()
+	content, encoding := file, ""
+	if h.mapping.Precompressed {
+		header.Add("Vary", "Accept-Encoding")
+		for _, enc := range encodings {
+			if !acceptsEncoding(r, enc.name) {
+				continue
+			}
+			if f, i, err := h.open(name + enc.ext); err == nil {
+				defer f.Close()
+				content, info, encoding = f, i, enc.name
+				break
+			}
+		}
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" && encoding != "" {
+		contentType = "application/octet-stream"
+	}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	if encoding != "" {
+		header.Set("Content-Encoding", encoding)
+	}
+	header.Set("ETag", etag(info, encoding))
+	if spa {
+		// the index of the single page app must be revalidated so that the new release takes effect
+		header.Set("Cache-Control", "no-cache")
+	} else if h.mapping.CacheControl != "" {
+		header.Set("Cache-Control", h.mapping.CacheControl)
+	}
+	// ServeContent handles If-None-Match, If-Modified-Since and the ranges
+	http.ServeContent(w, r, name, info.ModTime(), content)
+	return true
+}
+
+// etag returns the weak etag that is made of the size and the modification time of the file
+func etag(info os.FileInfo, encoding string) string {
+	tag := fmt.Sprintf("%x-%x", info.ModTime().UnixNano(), info.Size())
+	if encoding != "" {
+		tag += "-" + encoding
+	}
+	return `W/"` + tag + `"`
+}
+
+// acceptsEncoding checks if the client accepts the encoding, the encodings with q=0 are not accepted
+func acceptsEncoding(r *http.Request, encoding string) bool {
+	for _, accepted := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		parts := strings.Split(accepted, ";")
+		if strings.TrimSpace(parts[0]) != encoding {
+			continue
+		}
+		for _, param := range parts[1:] {
+			if q := strings.Replace(param, " ", "", -1); q == "q=0" || q == "q=0.0" || q == "q=0.00" || q == "q=0.000" {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package static
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newResourceDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "static")
+	assert.Equal(t, nil, err)
+	files := map[string]string{
+		"index.html":      "<html>index</html>",
+		"app.js":          "console.log('app')",
+		"app.js.gz":       "gzipped app",
+		"app.js.br":       "brotli app",
+		"style.css":       "body {}",
+		"style.css.gz":    "gzipped style",
+		"docs/index.html": "<html>docs</html>",
+	}
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		assert.Equal(t, nil, os.MkdirAll(filepath.Dir(file), 0755))
+		assert.Equal(t, nil, ioutil.WriteFile(file, []byte(content), 0644))
+	}
+	return dir
+}
+
+func serve(h *Handler, name string, headers ...string) (*httptest.ResponseRecorder, bool) {
+	r := httptest.NewRequest(http.MethodGet, "/"+name, nil)
+	for i := 0; i+1 < len(headers); i += 2 {
+		r.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	served := h.ServeFile(w, r, name)
+	return w, served
+}
+
+func TestHandler(t *testing.T) {
+	dir := newResourceDir(t)
+	defer os.RemoveAll(dir)
+
+	h, err := NewHandler(&Mapping{Path: "/", Dir: dir, CacheControl: "public, max-age=3600", Precompressed: true, Spa: true})
+	assert.Equal(t, nil, err)
+
+	t.Run("should serve the file with the cache headers", func(t *testing.T) {
+		w, served := serve(h, "app.js")
+		assert.Equal(t, true, served)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "console.log('app')", w.Body.String())
+		assert.Contains(t, w.Header().Get("Content-Type"), "javascript")
+		assert.Equal(t, "public, max-age=3600", w.Header().Get("Cache-Control"))
+		assert.NotEqual(t, "", w.Header().Get("ETag"))
+		assert.NotEqual(t, "", w.Header().Get("Last-Modified"))
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+	})
+
+	t.Run("should revalidate the file by etag and last modified", func(t *testing.T) {
+		w, _ := serve(h, "app.js")
+		w, _ = serve(h, "app.js", "If-None-Match", w.Header().Get("ETag"))
+		assert.Equal(t, http.StatusNotModified, w.Code)
+
+		w, _ = serve(h, "app.js")
+		w, _ = serve(h, "app.js", "If-Modified-Since", w.Header().Get("Last-Modified"))
+		assert.Equal(t, http.StatusNotModified, w.Code)
+	})
+
+	t.Run("should serve the precompressed file", func(t *testing.T) {
+		w, _ := serve(h, "app.js", "Accept-Encoding", "gzip, deflate, br")
+		assert.Equal(t, "brotli app", w.Body.String())
+		assert.Equal(t, "br", w.Header().Get("Content-Encoding"))
+		assert.Contains(t, w.Header().Get("Content-Type"), "javascript")
+
+		gz, _ := serve(h, "app.js", "Accept-Encoding", "gzip, br;q=0")
+		assert.Equal(t, "gzipped app", gz.Body.String())
+		assert.Equal(t, "gzip", gz.Header().Get("Content-Encoding"))
+		assert.NotEqual(t, w.Header().Get("ETag"), gz.Header().Get("ETag"))
+
+		w, _ = serve(h, "style.css", "Accept-Encoding", "br, gzip")
+		assert.Equal(t, "gzipped style", w.Body.String())
+		assert.Contains(t, w.Header().Get("Content-Type"), "text/css")
+	})
+
+	t.Run("should serve the index of the directory", func(t *testing.T) {
+		w, served := serve(h, "")
+		assert.Equal(t, true, served)
+		assert.Equal(t, "<html>index</html>", w.Body.String())
+
+		w, _ = serve(h, "docs")
+		assert.Equal(t, "<html>docs</html>", w.Body.String())
+	})
+
+	t.Run("should fall back to the index for the single page app routes", func(t *testing.T) {
+		w, served := serve(h, "users/42")
+		assert.Equal(t, true, served)
+		assert.Equal(t, "<html>index</html>", w.Body.String())
+		assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+	})
+
+	t.Run("should not fall back to the index for the missing assets", func(t *testing.T) {
+		_, served := serve(h, "missing.js")
+		assert.Equal(t, false, served)
+	})
+
+	t.Run("should not serve the files out of the directory", func(t *testing.T) {
+		_, served := serve(h, "../../etc/passwd.txt")
+		assert.Equal(t, false, served)
+	})
+
+	t.Run("should not serve the missing file without spa", func(t *testing.T) {
+		h, err := NewHandler(&Mapping{Path: "/assets", Dir: dir})
+		assert.Equal(t, nil, err)
+		_, served := serve(h, "users/42")
+		assert.Equal(t, false, served)
+
+		w, _ := serve(h, "app.js", "Accept-Encoding", "gzip")
+		assert.Equal(t, "console.log('app')", w.Body.String())
+		assert.Equal(t, "", w.Header().Get("Cache-Control"))
+	})
+
+	t.Run("should serve the registered file system", func(t *testing.T) {
+		RegisterFileSystem("embedded", http.Dir(filepath.Join(dir, "docs")))
+		h, err := NewHandler(&Mapping{Path: "/docs", FileSystem: "embedded"})
+		assert.Equal(t, nil, err)
+		w, _ := serve(h, "index.html")
+		assert.Equal(t, "<html>docs</html>", w.Body.String())
+
+		_, err = NewHandler(&Mapping{Path: "/docs", FileSystem: "unknown"})
+		assert.NotEqual(t, nil, err)
+	})
+
+	t.Run("should reject the mapping without dir and file system", func(t *testing.T) {
+		_, err := NewHandler(&Mapping{Path: "/assets"})
+		assert.NotEqual(t, nil, err)
+	})
+}
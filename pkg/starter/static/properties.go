@@ -0,0 +1,39 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package static
+
+// Mapping maps the url path prefix onto the directory or the registered file system
+type Mapping struct {
+	// Path is the url path prefix, e.g. /assets, / maps all the paths that are not mapped onto the controllers
+	Path string `json:"path"`
+	// Dir is the directory of the resources
+	Dir string `json:"dir"`
+	// FileSystem is the name of the registered file system, e.g. the embedded resources, it takes precedence over dir
+	FileSystem string `json:"file_system"`
+	// Index is the file that is served for the directories, index.html if it is empty
+	Index string `json:"index"`
+	// CacheControl is the Cache-Control header of the resources, e.g. public, max-age=31536000
+	CacheControl string `json:"cache_control"`
+	// Precompressed serves the .br or .gz file next to the resource if the client accepts the encoding
+	Precompressed bool `json:"precompressed"`
+	// Spa serves the index file for the unknown paths that have no file extension, e.g. the routes of a single page app
+	Spa bool `json:"spa"`
+}
+
+// Properties the static resource properties
+type Properties struct {
+	// Mappings are the mappings of the static resources
+	Mappings []Mapping `json:"mappings"`
+}
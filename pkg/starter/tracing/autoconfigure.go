@@ -0,0 +1,89 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tracing provides the hiboot starter for distributed tracing, the spans are created for each web route
+// and grpc call, and propagated across services by the W3C traceparent header
+package tracing
+
+import (
+	"hidevops.io/hiboot/pkg/app"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/log"
+)
+
+const (
+	// Profile is the profile of tracing, it should be as same as the package name
+	Profile = "tracing"
+)
+
+type configuration struct {
+	at.AutoConfiguration
+
+	Properties         Properties `mapstructure:"tracing"`
+	applicationContext app.ApplicationContext
+}
+
+func init() {
+	app.Register(newConfiguration)
+}
+
+func newConfiguration(applicationContext app.ApplicationContext) *configuration {
+	return &configuration{
+		applicationContext: applicationContext,
+	}
+}
+
+func (c *configuration) exporter() Exporter {
+	switch name := c.Properties.Exporter; name {
+	case NoneExporter, "":
+		return nil
+	case LogExporter:
+		return new(logExporter)
+	case StdoutExporter:
+		return NewStdoutExporter()
+	case FileExporter:
+		exporter, err := NewFileExporter(c.Properties.File)
+		if err != nil {
+			log.Errorf("failed to create tracing file exporter: %v", err)
+			return nil
+		}
+		return exporter
+	default:
+		if exporter, ok := exporters.Get(name); ok {
+			return exporter.(Exporter)
+		}
+		log.Warnf("tracing exporter %v is not registered", name)
+		return nil
+	}
+}
+
+// Tracer create the injectable tracer
+func (c *configuration) Tracer() Tracer {
+	return NewTracer(c.Properties.ServiceName, c.exporter(), c.Properties.SampleRatio)
+}
+
+// Middleware starts the server span of each request and carries it in the request context
+func (c *configuration) Middleware(tracer Tracer) *Middleware {
+	mw := NewMiddleware(tracer)
+
+	c.applicationContext.Use(mw.Serve)
+
+	return mw
+}
+
+// GrpcInterceptor create the grpc interceptor component that creates the spans for the grpc calls,
+// it takes effect only if the grpc starter is imported
+func (c *configuration) GrpcInterceptor(tracer Tracer) *GrpcInterceptor {
+	return NewGrpcInterceptor(tracer)
+}
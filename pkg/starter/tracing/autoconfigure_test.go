@@ -0,0 +1,99 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing_test
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"hidevops.io/hiboot/pkg/app/web"
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/starter/tracing"
+	"net/http"
+	"testing"
+)
+
+type fooController struct {
+	at.RestController
+	tracer tracing.Tracer
+}
+
+func newFooController(tracer tracing.Tracer) *fooController {
+	return &fooController{tracer: tracer}
+}
+
+func (c *fooController) GetById(id int) string {
+	return "foo"
+}
+
+func (c *fooController) GetQuery(ctx context.Context) string {
+	_, span := c.tracer.StartSpan(ctx.Request().Context(), "query")
+	span.End()
+	return span.TraceID
+}
+
+func (c *fooController) GetFailure() error {
+	return errors.New("failure")
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	exporter := tracing.NewInMemoryExporter()
+	tracing.RegisterExporter("test", exporter)
+
+	testApp := web.NewTestApp(newFooController).
+		SetProperty("tracing.exporter", "test").
+		Run(t)
+
+	t.Run("should create server span for route", func(t *testing.T) {
+		exporter.Reset()
+		testApp.Get("/foo/id/1").Expect().Status(http.StatusOK)
+
+		spans := exporter.Find("GET /foo/id/{id}")
+		assert.Equal(t, 1, len(spans))
+		server := spans[0]
+		assert.Equal(t, tracing.KindServer, server.Kind)
+		assert.Equal(t, "/foo/id/1", server.Attribute("http.path"))
+		assert.Equal(t, "200", server.Attribute("http.status_code"))
+	})
+
+	t.Run("should start child span from request context", func(t *testing.T) {
+		exporter.Reset()
+		traceID := testApp.Get("/foo/query").
+			Expect().Status(http.StatusOK).Body().Raw()
+
+		server := exporter.Find("GET /foo/query")
+		assert.Equal(t, 1, len(server))
+		assert.Equal(t, traceID, server[0].TraceID)
+
+		query := exporter.Find("query")
+		assert.Equal(t, 1, len(query))
+		assert.Equal(t, server[0].SpanID, query[0].ParentSpanID)
+	})
+
+	t.Run("should continue the trace of traceparent header", func(t *testing.T) {
+		testApp.Get("/foo/query").
+			WithHeader(tracing.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01").
+			Expect().Status(http.StatusOK).
+			Body().Equal("4bf92f3577b34da6a3ce929d0e0e4736")
+	})
+
+	t.Run("should record error status", func(t *testing.T) {
+		exporter.Reset()
+		testApp.Get("/foo/failure").Expect().Status(http.StatusInternalServerError)
+		spans := exporter.Spans()
+		assert.Equal(t, 1, len(spans))
+		assert.Equal(t, http.StatusText(http.StatusInternalServerError), spans[0].Error)
+	})
+}
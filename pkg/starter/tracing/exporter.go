@@ -0,0 +1,153 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"encoding/json"
+	"hidevops.io/hiboot/pkg/log"
+	"hidevops.io/hiboot/pkg/utils/cmap"
+	"io"
+	"os"
+	"sync"
+)
+
+const (
+	// NoneExporter discards all spans
+	NoneExporter = "none"
+	// LogExporter writes spans to the debug log
+	LogExporter = "log"
+	// StdoutExporter writes spans to stdout in JSON lines
+	StdoutExporter = "stdout"
+	// FileExporter writes spans to the file specified by tracing.file in JSON lines
+	FileExporter = "file"
+)
+
+// Exporter export the finished spans
+type Exporter interface {
+	Export(span *Span) error
+}
+
+var exporters cmap.ConcurrentMap
+
+func init() {
+	exporters = cmap.New()
+}
+
+// RegisterExporter register the exporter, it can be referenced by tracing.exporter in application.yml
+func RegisterExporter(name string, exporter Exporter) {
+	exporters.Set(name, exporter)
+}
+
+// JSONExporter writes spans to the writer in JSON lines
+type JSONExporter struct {
+	mu      sync.Mutex
+	writer  io.Writer
+	encoder *json.Encoder
+}
+
+// NewJSONExporter create the exporter that writes spans to w in JSON lines
+func NewJSONExporter(w io.Writer) *JSONExporter {
+	return &JSONExporter{
+		writer:  w,
+		encoder: json.NewEncoder(w),
+	}
+}
+
+// NewStdoutExporter create the exporter that writes spans to stdout in JSON lines
+func NewStdoutExporter() *JSONExporter {
+	return NewJSONExporter(os.Stdout)
+}
+
+// NewFileExporter create the exporter that appends spans to the file in JSON lines
+func NewFileExporter(path string) (*JSONExporter, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return NewJSONExporter(f), nil
+}
+
+// Export write the span as a JSON line
+func (e *JSONExporter) Export(span *Span) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	span.mu.Lock()
+	defer span.mu.Unlock()
+	return e.encoder.Encode(span)
+}
+
+// Close close the underlying writer if it is closable
+func (e *JSONExporter) Close() error {
+	if c, ok := e.writer.(io.Closer); ok && e.writer != os.Stdout {
+		return c.Close()
+	}
+	return nil
+}
+
+type logExporter struct{}
+
+// Export write the span to the debug log
+func (e *logExporter) Export(span *Span) error {
+	log.Debugf("span %v kind=%v trace=%v span=%v parent=%v duration=%v error=%v",
+		span.Name, span.Kind, span.TraceID, span.SpanID, span.ParentSpanID, span.Duration(), span.Error)
+	return nil
+}
+
+// InMemoryExporter keeps the spans in memory, it is useful for assertions in unit tests
+type InMemoryExporter struct {
+	mu    sync.RWMutex
+	spans []*Span
+}
+
+// NewInMemoryExporter create the in-memory exporter
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{}
+}
+
+// Export keep the span in memory
+func (e *InMemoryExporter) Export(span *Span) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, span)
+	return nil
+}
+
+// Spans return the exported spans in the order they are ended
+func (e *InMemoryExporter) Spans() []*Span {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	spans := make([]*Span, len(e.spans))
+	copy(spans, e.spans)
+	return spans
+}
+
+// Find return the exported spans with the name
+func (e *InMemoryExporter) Find(name string) (spans []*Span) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	for _, span := range e.spans {
+		if span.Name == name {
+			spans = append(spans, span)
+		}
+	}
+	return
+}
+
+// Reset drop all the exported spans
+func (e *InMemoryExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = nil
+}
@@ -0,0 +1,180 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"hidevops.io/hiboot/pkg/at"
+	"io"
+	"sync"
+)
+
+// GrpcInterceptor is the grpc interceptor component that creates the spans for the grpc calls,
+// it is chained by the grpc starter before the other interceptor components
+type GrpcInterceptor struct {
+	at.GrpcInterceptor `order:"-1"`
+
+	tracer Tracer
+}
+
+// NewGrpcInterceptor is the constructor of the tracing grpc interceptor
+func NewGrpcInterceptor(tracer Tracer) *GrpcInterceptor {
+	return &GrpcInterceptor{
+		tracer: tracer,
+	}
+}
+
+// startClientSpan start the client span and propagate it by the traceparent metadata
+func (i *GrpcInterceptor) startClientSpan(ctx context.Context, method string) (context.Context, *Span) {
+	ctx, span := i.tracer.StartSpan(ctx, method,
+		WithKind(KindClient),
+		WithAttribute("rpc.system", "grpc"),
+		WithAttribute("rpc.method", method),
+	)
+	return metadata.AppendToOutgoingContext(ctx, TraceparentHeader, span.Context().Traceparent()), span
+}
+
+// startServerSpan start the server span, the remote parent is read from the traceparent metadata
+func (i *GrpcInterceptor) startServerSpan(ctx context.Context, method string) (context.Context, *Span) {
+	opts := []SpanOption{
+		WithKind(KindServer),
+		WithAttribute("rpc.system", "grpc"),
+		WithAttribute("rpc.method", method),
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if tp := md.Get(TraceparentHeader); len(tp) != 0 {
+			if parent, err := ParseTraceparent(tp[0]); err == nil {
+				opts = append(opts, WithParent(parent))
+			}
+		}
+	}
+	return i.tracer.StartSpan(ctx, method, opts...)
+}
+
+// UnaryClientIntercept create the client span for each unary call
+func (i *GrpcInterceptor) UnaryClientIntercept(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	ctx, span := i.startClientSpan(ctx, method)
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	span.SetError(err).End()
+	return err
+}
+
+// tracedClientStream end the client span when the stream is finished, the client may stop receiving
+// once it gets the expected messages, so the span is also ended when the context is done
+type tracedClientStream struct {
+	grpc.ClientStream
+	desc *grpc.StreamDesc
+	span *Span
+	once sync.Once
+	done chan struct{}
+}
+
+func newTracedClientStream(ctx context.Context, cs grpc.ClientStream, desc *grpc.StreamDesc, span *Span) *tracedClientStream {
+	s := &tracedClientStream{ClientStream: cs, desc: desc, span: span, done: make(chan struct{})}
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.finish(ctx.Err())
+		case <-s.done:
+		}
+	}()
+	return s
+}
+
+// finish end the span once with the error
+func (s *tracedClientStream) finish(err error) {
+	s.once.Do(func() {
+		close(s.done)
+		s.span.SetError(err).End()
+	})
+}
+
+// Header end the span on error
+func (s *tracedClientStream) Header() (metadata.MD, error) {
+	md, err := s.ClientStream.Header()
+	if err != nil {
+		s.finish(err)
+	}
+	return md, err
+}
+
+// SendMsg end the span on error
+func (s *tracedClientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	if err != nil {
+		s.finish(err)
+	}
+	return err
+}
+
+// CloseSend end the span on error, or if the server does not stream the messages
+func (s *tracedClientStream) CloseSend() error {
+	err := s.ClientStream.CloseSend()
+	if err != nil || !s.desc.ServerStreams {
+		s.finish(err)
+	}
+	return err
+}
+
+// RecvMsg end the span on io.EOF or error, or once the message is received if the server does not stream the messages
+func (s *tracedClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == io.EOF {
+		s.finish(nil)
+	} else if err != nil || !s.desc.ServerStreams {
+		s.finish(err)
+	}
+	return err
+}
+
+// StreamClientIntercept create the client span for each stream
+func (i *GrpcInterceptor) StreamClientIntercept(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	ctx, span := i.startClientSpan(ctx, method)
+	cs, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		span.SetError(err).End()
+		return cs, err
+	}
+	return newTracedClientStream(ctx, cs, desc, span), nil
+}
+
+// UnaryServerIntercept create the server span for each unary call, the handler context carries the span
+func (i *GrpcInterceptor) UnaryServerIntercept(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, span := i.startServerSpan(ctx, info.FullMethod)
+	resp, err := handler(ctx, req)
+	span.SetError(err).End()
+	return resp, err
+}
+
+// tracedServerStream replaces the stream context with the context that carries the server span
+type tracedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context return the context that carries the server span
+func (s *tracedServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// StreamServerIntercept create the server span for each stream, the stream context carries the span
+func (i *GrpcInterceptor) StreamServerIntercept(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, span := i.startServerSpan(ss.Context(), info.FullMethod)
+	err := handler(srv, &tracedServerStream{ServerStream: ss, ctx: ctx})
+	span.SetError(err).End()
+	return err
+}
@@ -0,0 +1,133 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"io"
+	"testing"
+	"time"
+)
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeServerStream) Context() context.Context {
+	return s.ctx
+}
+
+type fakeClientStream struct {
+	grpc.ClientStream
+	received int
+}
+
+func (s *fakeClientStream) CloseSend() error {
+	return nil
+}
+
+func (s *fakeClientStream) RecvMsg(m interface{}) error {
+	s.received++
+	return nil
+}
+
+func TestGrpcInterceptor(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	interceptor := NewGrpcInterceptor(NewTracer("foo", exporter, 1))
+
+	t.Run("should propagate client span to server span", func(t *testing.T) {
+		exporter.Reset()
+		err := interceptor.UnaryClientIntercept(context.Background(), "/foo.Bar/Baz", nil, nil, nil,
+			func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				md, _ := metadata.FromOutgoingContext(ctx)
+				serverCtx := metadata.NewIncomingContext(context.Background(), md)
+				_, err := interceptor.UnaryServerIntercept(serverCtx, nil, &grpc.UnaryServerInfo{FullMethod: method},
+					func(ctx context.Context, req interface{}) (interface{}, error) {
+						assert.NotEqual(t, (*Span)(nil), SpanFromContext(ctx))
+						return nil, nil
+					})
+				return err
+			})
+		assert.Equal(t, nil, err)
+
+		spans := exporter.Find("/foo.Bar/Baz")
+		assert.Equal(t, 2, len(spans))
+		server, client := spans[0], spans[1]
+		assert.Equal(t, KindServer, server.Kind)
+		assert.Equal(t, KindClient, client.Kind)
+		assert.Equal(t, client.TraceID, server.TraceID)
+		assert.Equal(t, client.SpanID, server.ParentSpanID)
+	})
+
+	t.Run("should start client span from the span in context", func(t *testing.T) {
+		exporter.Reset()
+		ctx, parent := NewTracer("foo", exporter, 1).StartSpan(context.Background(), "parent")
+		err := interceptor.UnaryClientIntercept(ctx, "/foo.Bar/Baz", nil, nil, nil,
+			func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				return nil
+			})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, parent.SpanID, exporter.Find("/foo.Bar/Baz")[0].ParentSpanID)
+	})
+
+	t.Run("should record error of server span", func(t *testing.T) {
+		exporter.Reset()
+		err := interceptor.StreamServerIntercept(nil, &fakeServerStream{ctx: context.Background()},
+			&grpc.StreamServerInfo{FullMethod: "/foo.Bar/Stream"},
+			func(srv interface{}, stream grpc.ServerStream) error {
+				assert.NotEqual(t, (*Span)(nil), SpanFromContext(stream.Context()))
+				return io.ErrUnexpectedEOF
+			})
+		assert.Equal(t, io.ErrUnexpectedEOF, err)
+		assert.Equal(t, io.ErrUnexpectedEOF.Error(), exporter.Find("/foo.Bar/Stream")[0].Error)
+	})
+
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return &fakeClientStream{}, nil
+	}
+
+	t.Run("should end client span when the client stream is closed", func(t *testing.T) {
+		exporter.Reset()
+		cs, err := interceptor.StreamClientIntercept(context.Background(), &grpc.StreamDesc{ClientStreams: true}, nil, "/foo.Bar/Upload", streamer)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 0, len(exporter.Find("/foo.Bar/Upload")))
+		assert.Equal(t, nil, cs.CloseSend())
+		assert.Equal(t, 1, len(exporter.Find("/foo.Bar/Upload")))
+		assert.Equal(t, nil, cs.RecvMsg(nil))
+		assert.Equal(t, 1, len(exporter.Find("/foo.Bar/Upload")))
+	})
+
+	t.Run("should end client span when the context is canceled", func(t *testing.T) {
+		exporter.Reset()
+		ctx, cancel := context.WithCancel(context.Background())
+		cs, err := interceptor.StreamClientIntercept(ctx, &grpc.StreamDesc{ServerStreams: true}, nil, "/foo.Bar/Watch", streamer)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, nil, cs.RecvMsg(nil))
+		assert.Equal(t, nil, cs.CloseSend())
+		assert.Equal(t, 0, len(exporter.Find("/foo.Bar/Watch")))
+		cancel()
+		deadline := time.Now().Add(time.Second)
+		for len(exporter.Find("/foo.Bar/Watch")) == 0 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		spans := exporter.Find("/foo.Bar/Watch")
+		assert.Equal(t, 1, len(spans))
+		assert.Equal(t, context.Canceled.Error(), spans[0].Error)
+	})
+}
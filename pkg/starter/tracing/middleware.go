@@ -0,0 +1,79 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"hidevops.io/hiboot/pkg/app/web/context"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// ContextKey is the key of the server span that stored in the context values
+	ContextKey = "span"
+)
+
+// Middleware is the tracing middleware, it starts a server span for each route, the remote parent
+// is read from the traceparent header
+type Middleware struct {
+	tracer Tracer
+}
+
+// NewMiddleware is the constructor of tracing middleware
+func NewMiddleware(tracer Tracer) *Middleware {
+	return &Middleware{
+		tracer: tracer,
+	}
+}
+
+// Serve the tracing middleware handler
+func (m *Middleware) Serve(ctx context.Context) {
+	name := ctx.Method() + " " + ctx.Path()
+	opts := []SpanOption{
+		WithKind(KindServer),
+		WithAttribute("http.method", ctx.Method()),
+		WithAttribute("http.path", ctx.Path()),
+	}
+	if route := ctx.GetCurrentRoute(); route != nil {
+		name = ctx.Method() + " " + route.Path()
+		opts = append(opts, WithAttribute("http.handler", route.MainHandlerName()))
+	}
+	if parent, err := ParseTraceparent(ctx.GetHeader(TraceparentHeader)); err == nil {
+		opts = append(opts, WithParent(parent))
+	}
+
+	r := ctx.Request()
+	spanCtx, span := m.tracer.StartSpan(r.Context(), name, opts...)
+	ctx.Values().Set(ContextKey, span)
+	// the request context carries the span, so that the spans started from it become its children,
+	// e.g. the grpc calls made by a web controller
+	ctx.ResetRequest(r.WithContext(spanCtx))
+	defer func() {
+		status := ctx.GetStatusCode()
+		span.SetAttribute("http.status_code", strconv.Itoa(status))
+		if status >= http.StatusInternalServerError {
+			span.Error = http.StatusText(status)
+		}
+		span.End()
+	}()
+
+	ctx.Next()
+}
+
+// SpanFromRequest return the server span of current request, or nil
+func SpanFromRequest(ctx context.Context) *Span {
+	span, _ := ctx.Values().Get(ContextKey).(*Span)
+	return span
+}
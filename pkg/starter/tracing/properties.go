@@ -0,0 +1,28 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+// Properties the tracing properties
+type Properties struct {
+	// ServiceName is the name of the service that recorded in spans
+	ServiceName string `json:"service_name" default:"${app.name}"`
+	// Exporter is the name of the exporter, the built-in exporters are none, log, stdout and file,
+	// the custom exporter can be registered by tracing.RegisterExporter
+	Exporter string `json:"exporter" default:"log"`
+	// File is the file that the file exporter writes to
+	File string `json:"file" default:"trace.json"`
+	// SampleRatio is the ratio of the traces to be sampled, from 0 to 1
+	SampleRatio float64 `json:"sample_ratio" default:"1"`
+}
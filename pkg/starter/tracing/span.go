@@ -0,0 +1,105 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"sync"
+	"time"
+)
+
+// SpanKind is the role of the span in the trace
+type SpanKind string
+
+const (
+	// KindInternal is the span of an internal operation
+	KindInternal SpanKind = "internal"
+	// KindServer is the span that handles a request from remote client
+	KindServer SpanKind = "server"
+	// KindClient is the span that sends a request to remote server
+	KindClient SpanKind = "client"
+)
+
+// Span is a single operation of a trace
+type Span struct {
+	Name         string            `json:"name"`
+	Kind         SpanKind          `json:"kind"`
+	Service      string            `json:"service,omitempty"`
+	TraceID      string            `json:"trace_id"`
+	SpanID       string            `json:"span_id"`
+	ParentSpanID string            `json:"parent_span_id,omitempty"`
+	StartTime    time.Time         `json:"start_time"`
+	EndTime      time.Time         `json:"end_time"`
+	Attributes   map[string]string `json:"attributes,omitempty"`
+	Error        string            `json:"error,omitempty"`
+
+	context SpanContext
+	tracer  *tracer
+	mu      sync.Mutex
+	ended   bool
+}
+
+// Context return the span context that to be propagated
+func (s *Span) Context() SpanContext {
+	return s.context
+}
+
+// SetAttribute set the attribute of the span
+func (s *Span) SetAttribute(key, value string) *Span {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Attributes == nil {
+		s.Attributes = make(map[string]string)
+	}
+	s.Attributes[key] = value
+	return s
+}
+
+// Attribute return the attribute of the span
+func (s *Span) Attribute(key string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Attributes[key]
+}
+
+// SetError record the error of the span, nil error is ignored
+func (s *Span) SetError(err error) *Span {
+	if err != nil {
+		s.mu.Lock()
+		s.Error = err.Error()
+		s.mu.Unlock()
+	}
+	return s
+}
+
+// Duration return the duration of the span, it is zero before the span is ended
+func (s *Span) Duration() time.Duration {
+	return s.EndTime.Sub(s.StartTime)
+}
+
+// End finish the span and export it if it is sampled, it is safe to call End more than once
+func (s *Span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.EndTime = time.Now()
+	s.mu.Unlock()
+
+	if s.tracer != nil {
+		s.tracer.export(s)
+	}
+}
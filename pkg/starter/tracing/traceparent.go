@@ -0,0 +1,125 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// TraceparentHeader is the W3C trace context header, it is also used as the grpc metadata key
+	TraceparentHeader = "traceparent"
+
+	traceparentVersion = "00"
+	flagSampled        = 0x01
+)
+
+var (
+	// ErrInvalidTraceparent the traceparent is not a valid W3C trace context
+	ErrInvalidTraceparent = errors.New("invalid traceparent")
+)
+
+// TraceID is the 16 bytes id of the trace
+type TraceID [16]byte
+
+// SpanID is the 8 bytes id of the span
+type SpanID [8]byte
+
+// String return the lowercase hex string of the trace id
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid report whether the trace id is not all zeros
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+// String return the lowercase hex string of the span id
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid report whether the span id is not all zeros
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+func newTraceID() (id TraceID) {
+	rand.Read(id[:])
+	return
+}
+
+func newSpanID() (id SpanID) {
+	rand.Read(id[:])
+	return
+}
+
+// SpanContext is the part of span that propagated across process boundaries
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// IsValid report whether both the trace id and the span id are valid
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Traceparent encode the span context in W3C traceparent format, e.g.
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func (sc SpanContext) Traceparent() string {
+	var flags byte
+	if sc.Sampled {
+		flags |= flagSampled
+	}
+	return fmt.Sprintf("%v-%v-%v-%02x", traceparentVersion, sc.TraceID, sc.SpanID, flags)
+}
+
+// ParseTraceparent decode the W3C traceparent
+func ParseTraceparent(traceparent string) (sc SpanContext, err error) {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	// future versions may append fields, but version 00 must have exactly 4 fields
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" ||
+		(parts[0] == traceparentVersion && len(parts) != 4) {
+		err = ErrInvalidTraceparent
+		return
+	}
+	if !decodeHex(sc.TraceID[:], parts[1]) || !decodeHex(sc.SpanID[:], parts[2]) {
+		err = ErrInvalidTraceparent
+		return
+	}
+	var flags [1]byte
+	if !decodeHex(flags[:], parts[3]) || !sc.IsValid() {
+		err = ErrInvalidTraceparent
+		return
+	}
+	sc.Sampled = flags[0]&flagSampled != 0
+	return
+}
+
+// decodeHex decode the lowercase hex string s into dst, s must be exactly len(dst) bytes
+func decodeHex(dst []byte, s string) bool {
+	if len(s) != hex.EncodedLen(len(dst)) || strings.ToLower(s) != s {
+		return false
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err == nil
+}
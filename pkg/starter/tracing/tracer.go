@@ -0,0 +1,146 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"hidevops.io/hiboot/pkg/log"
+	"math/rand"
+	"time"
+)
+
+// Tracer is the injectable tracer that creates spans
+type Tracer interface {
+	// StartSpan start a new span, the parent is the span in ctx unless it is specified by WithParent,
+	// the returned context carries the new span
+	StartSpan(ctx context.Context, name string, opts ...SpanOption) (context.Context, *Span)
+}
+
+// SpanOption is the option of StartSpan
+type SpanOption func(o *spanOptions)
+
+type spanOptions struct {
+	kind       SpanKind
+	parent     SpanContext
+	attributes map[string]string
+}
+
+// WithKind specify the kind of the span, the default kind is KindInternal
+func WithKind(kind SpanKind) SpanOption {
+	return func(o *spanOptions) {
+		o.kind = kind
+	}
+}
+
+// WithParent specify the remote parent of the span, e.g. the span context parsed from traceparent
+func WithParent(parent SpanContext) SpanOption {
+	return func(o *spanOptions) {
+		o.parent = parent
+	}
+}
+
+// WithAttribute set the attribute of the span
+func WithAttribute(key, value string) SpanOption {
+	return func(o *spanOptions) {
+		if o.attributes == nil {
+			o.attributes = make(map[string]string)
+		}
+		o.attributes[key] = value
+	}
+}
+
+type tracer struct {
+	service     string
+	exporter    Exporter
+	sampleRatio float64
+}
+
+// NewTracer create a tracer, the sampled spans are exported by exporter, nil exporter discards all spans
+func NewTracer(service string, exporter Exporter, sampleRatio float64) Tracer {
+	return &tracer{
+		service:     service,
+		exporter:    exporter,
+		sampleRatio: sampleRatio,
+	}
+}
+
+func (t *tracer) sample() bool {
+	return t.sampleRatio >= 1 || (t.sampleRatio > 0 && rand.Float64() < t.sampleRatio)
+}
+
+// StartSpan start a new span
+func (t *tracer) StartSpan(ctx context.Context, name string, opts ...SpanOption) (context.Context, *Span) {
+	o := &spanOptions{kind: KindInternal}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	parent := o.parent
+	if !parent.IsValid() {
+		if ps := SpanFromContext(ctx); ps != nil {
+			parent = ps.Context()
+		}
+	}
+
+	span := &Span{
+		Name:       name,
+		Kind:       o.kind,
+		Service:    t.service,
+		StartTime:  time.Now(),
+		Attributes: o.attributes,
+		tracer:     t,
+	}
+	if parent.IsValid() {
+		span.context.TraceID = parent.TraceID
+		span.context.Sampled = parent.Sampled
+		span.ParentSpanID = parent.SpanID.String()
+	} else {
+		span.context.TraceID = newTraceID()
+		span.context.Sampled = t.sample()
+	}
+	span.context.SpanID = newSpanID()
+	span.TraceID = span.context.TraceID.String()
+	span.SpanID = span.context.SpanID.String()
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return ContextWithSpan(ctx, span), span
+}
+
+func (t *tracer) export(span *Span) {
+	if t.exporter == nil || !span.context.Sampled {
+		return
+	}
+	if err := t.exporter.Export(span); err != nil {
+		log.Warnf("failed to export span %v: %v", span.Name, err)
+	}
+}
+
+type spanKey struct{}
+
+// ContextWithSpan return a copy of ctx that carries the span
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext return the span carried by ctx, or nil
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
@@ -0,0 +1,119 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTracer(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	tracer := NewTracer("foo", exporter, 1)
+
+	t.Run("should start root span", func(t *testing.T) {
+		exporter.Reset()
+		ctx, span := tracer.StartSpan(context.Background(), "root", WithAttribute("foo", "bar"))
+		assert.Equal(t, span, SpanFromContext(ctx))
+		assert.Equal(t, KindInternal, span.Kind)
+		assert.Equal(t, "foo", span.Service)
+		assert.Equal(t, "", span.ParentSpanID)
+		assert.Equal(t, true, span.Context().IsValid())
+		assert.Equal(t, true, span.Context().Sampled)
+		assert.Equal(t, "bar", span.Attribute("foo"))
+
+		assert.Equal(t, 0, len(exporter.Spans()))
+		span.End()
+		span.End()
+		assert.Equal(t, 1, len(exporter.Spans()))
+		assert.Equal(t, true, span.Duration() >= 0)
+	})
+
+	t.Run("should start child span from context", func(t *testing.T) {
+		exporter.Reset()
+		ctx, parent := tracer.StartSpan(context.Background(), "parent")
+		_, child := tracer.StartSpan(ctx, "child", WithKind(KindClient))
+		child.SetError(errors.New("failed")).End()
+		parent.End()
+
+		assert.Equal(t, parent.TraceID, child.TraceID)
+		assert.Equal(t, parent.SpanID, child.ParentSpanID)
+		assert.Equal(t, "failed", exporter.Find("child")[0].Error)
+		assert.Equal(t, []*Span{child, parent}, exporter.Spans())
+	})
+
+	t.Run("should start child span from remote parent", func(t *testing.T) {
+		parent, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+		_, span := tracer.StartSpan(context.Background(), "remote", WithParent(parent), WithKind(KindServer))
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.TraceID)
+		assert.Equal(t, "00f067aa0ba902b7", span.ParentSpanID)
+		assert.Equal(t, false, span.Context().Sampled)
+
+		exporter.Reset()
+		span.End()
+		assert.Equal(t, 0, len(exporter.Spans()))
+	})
+
+	t.Run("should not sample with zero sample ratio", func(t *testing.T) {
+		_, span := NewTracer("foo", exporter, 0).StartSpan(nil, "foo")
+		assert.Equal(t, false, span.Context().Sampled)
+	})
+}
+
+func TestExporter(t *testing.T) {
+	tracer := NewTracer("foo", nil, 1)
+
+	t.Run("should export span in json", func(t *testing.T) {
+		var buf bytes.Buffer
+		exporter := NewJSONExporter(&buf)
+		_, span := tracer.StartSpan(context.Background(), "foo")
+		span.SetAttribute("bar", "baz").End()
+		assert.Equal(t, nil, exporter.Export(span))
+
+		var m map[string]interface{}
+		assert.Equal(t, nil, json.Unmarshal(buf.Bytes(), &m))
+		assert.Equal(t, "foo", m["name"])
+		assert.Equal(t, span.TraceID, m["trace_id"])
+		assert.Equal(t, map[string]interface{}{"bar": "baz"}, m["attributes"])
+	})
+
+	t.Run("should export span to file", func(t *testing.T) {
+		path := filepath.Join(os.TempDir(), "hiboot-trace-test.json")
+		defer os.Remove(path)
+		exporter, err := NewFileExporter(path)
+		assert.Equal(t, nil, err)
+		_, span := tracer.StartSpan(context.Background(), "foo")
+		span.End()
+		assert.Equal(t, nil, exporter.Export(span))
+		assert.Equal(t, nil, exporter.Close())
+
+		b, err := ioutil.ReadFile(path)
+		assert.Equal(t, nil, err)
+		assert.Contains(t, string(b), span.SpanID)
+	})
+
+	t.Run("should export span to log", func(t *testing.T) {
+		_, span := tracer.StartSpan(context.Background(), "foo")
+		span.End()
+		assert.Equal(t, nil, new(logExporter).Export(span))
+	})
+}
@@ -20,6 +20,9 @@ import (
 	"hidevops.io/hiboot/pkg/app"
 	"hidevops.io/hiboot/pkg/app/web/context"
 	"hidevops.io/hiboot/pkg/at"
+	"hidevops.io/hiboot/pkg/log"
+	"net/http"
+	"time"
 )
 
 const (
@@ -35,15 +38,16 @@ type configuration struct {
 	// embedded annotation at.AutoConfiguration
 	at.AutoConfiguration
 
-	Properties properties `mapstructure:"websocket"`
+	Properties         properties `mapstructure:"websocket"`
+	applicationContext app.ApplicationContext
 }
 
 type Server struct {
 	*websocket.Server
 }
 
-func newConfiguration() *configuration {
-	return &configuration{}
+func newConfiguration(applicationContext app.ApplicationContext) *configuration {
+	return &configuration{applicationContext: applicationContext}
 }
 
 func init() {
@@ -52,19 +56,93 @@ func init() {
 
 // Server websocket server
 func (c *configuration) Server() *Server {
-	s := websocket.New(websocket.Config{
+	config := websocket.Config{
 		ReadBufferSize:  c.Properties.ReadBufferSize,
 		WriteBufferSize: c.Properties.WriteBufferSize,
-	})
+		CheckOrigin:     checkOrigin(c.Properties.AllowedOrigins),
+		PingPeriod:      time.Duration(c.Properties.PingPeriod) * time.Second,
+		PongTimeout:     time.Duration(c.Properties.PongTimeout) * time.Second,
+		WriteTimeout:    time.Duration(c.Properties.WriteTimeout) * time.Second,
+		MaxMessageSize:  c.Properties.MaxMessageSize,
+	}
+	if c.Properties.Auth.Enabled && c.Properties.Auth.Subprotocol != "" {
+		// echo the auth subprotocol, otherwise the browsers close the connection that the token is sent by the subprotocol
+		config.Subprotocols = []string{c.Properties.Auth.Subprotocol}
+	}
+	s := websocket.New(config)
 
 	return &Server{
 		Server: s,
 	}
 }
 
-// Connection websocket connection for runtime dependency injection
-func (c *configuration) Connection(ctx context.Context, server *Server) *Connection {
-	conn := newConnection(server.Upgrade(ctx))
+// Hub manages the websocket connections, the broadcasts are fanned out to the other instances if the broker is configured
+func (c *configuration) Hub() (hub *Hub) {
+	hub = NewHub()
+	if c.Properties.Broker == "" {
+		return
+	}
+	var broker Broker
+	var err error
+	if c.Properties.Broker == RedisBroker {
+		broker = NewRedisBroker(c.Properties.Redis.Address, c.Properties.Redis.Password)
+	} else {
+		broker, err = getBroker(c.Properties.Broker)
+	}
+	if err == nil {
+		err = hub.UseBroker(broker, c.Properties.Channel)
+	}
+	if err != nil {
+		log.Errorf("websocket: failed to use the broker %v: %v", c.Properties.Broker, err)
+		return
+	}
+	c.applicationContext.RegisterShutdownHook(hub.Close)
+	return
+}
+
+// guard create the guard of the upgrade requests, the token of the jwt starter is looked up only if the auth is enabled,
+// so that the jwt starter is not required otherwise
+func (c *configuration) guard() *guard {
+	var token verifyKeyGetter
+	if c.Properties.Auth.Enabled {
+		token, _ = c.applicationContext.GetInstance("jwt.token").(verifyKeyGetter)
+		if token == nil {
+			log.Warn("websocket: the jwt starter is required by websocket.auth.enabled")
+		}
+	}
+	return newGuard(&c.Properties, token)
+}
+
+// Connection websocket connection for runtime dependency injection,
+// the upgrade request is rejected if it reaches the max connections or it is not authenticated while the auth is enabled
+func (c *configuration) Connection(ctx context.Context, server *Server, hub *Hub) *Connection {
+	claims, status, err := c.guard().check(ctx.Request())
+	if err != nil {
+		ctx.ResponseError(err.Error(), status)
+		return newRejectedConnection(err)
+	}
+	// the slot is reserved before the upgrade, so that the concurrent upgrades do not exceed the max connections
+	if !hub.reserve(c.Properties.MaxConnections) {
+		ctx.ResponseError(ErrTooManyConnections.Error(), http.StatusServiceUnavailable)
+		return newRejectedConnection(ErrTooManyConnections)
+	}
+	conn := newContextConnection(server.Upgrade(ctx), ctx, claims, c.Properties.SubjectClaim)
+	if conn.Err() != nil {
+		hub.release(nil)
+	} else {
+		conn.hub = hub
+		outbound := c.Properties.Outbound
+		if outbound.QueueSize > 0 {
+			conn.queue = newOutboundQueue(conn.Connection, outbound.QueueSize, outbound.Overflow, conn.Disconnect)
+		}
+		hub.release(conn)
+		conn.OnDisconnect(func() {
+			hub.Remove(conn)
+			if conn.queue != nil {
+				conn.queue.close()
+			}
+		})
+	}
 	return conn
 }
 
@@ -72,3 +150,8 @@ func (c *configuration) Connection(ctx context.Context, server *Server) *Connect
 func (c *configuration) RegisterHandler() Register {
 	return registerHandler
 }
+
+// RegisterEventHandler is function that register the event handler
+func (c *configuration) RegisterEventHandler() EventRegister {
+	return registerEventHandler
+}
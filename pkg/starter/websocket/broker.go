@@ -0,0 +1,118 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websocket
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"hidevops.io/hiboot/pkg/utils/cmap"
+	"sync"
+)
+
+const (
+	// MemoryBroker is the name of the in-memory broker
+	MemoryBroker = "memory"
+	// RedisBroker is the name of the redis broker
+	RedisBroker = "redis"
+)
+
+var (
+	// ErrBrokerNotFound the broker is not registered
+	ErrBrokerNotFound = errors.New("[websocket] broker is not found")
+
+	brokers cmap.ConcurrentMap
+)
+
+// Broker fans out the messages between the instances of the service, e.g. the broadcasts of the hub
+type Broker interface {
+	// Publish publishes the message to all the subscribers of the channel, including the ones of current instance,
+	// it should not block on the network as the hub calls it on each broadcast
+	Publish(channel string, message []byte) error
+	// Subscribe calls the handler with each message of the channel until unsubscribe is called
+	Subscribe(channel string, handler func(message []byte)) (unsubscribe func(), err error)
+}
+
+func init() {
+	brokers = cmap.New()
+	RegisterBroker(MemoryBroker, NewMemoryBroker())
+}
+
+// RegisterBroker register the broker, it can be referenced by websocket.broker in application.yml
+func RegisterBroker(name string, broker Broker) {
+	brokers.Set(name, broker)
+}
+
+// getBroker returns the registered broker
+func getBroker(name string) (broker Broker, err error) {
+	if b, ok := brokers.Get(name); ok {
+		broker = b.(Broker)
+		return
+	}
+	return nil, ErrBrokerNotFound
+}
+
+type memoryBroker struct {
+	mu          sync.RWMutex
+	nextID      int
+	subscribers map[string]map[int]func(message []byte)
+}
+
+// NewMemoryBroker create the in-memory broker, it only fans out the messages within current process, e.g. the hubs of the tests
+func NewMemoryBroker() Broker {
+	return &memoryBroker{subscribers: make(map[string]map[int]func(message []byte))}
+}
+
+// Publish implements Broker, the handlers are called synchronously
+func (b *memoryBroker) Publish(channel string, message []byte) error {
+	b.mu.RLock()
+	var handlers []func(message []byte)
+	for _, handler := range b.subscribers[channel] {
+		handlers = append(handlers, handler)
+	}
+	b.mu.RUnlock()
+	for _, handler := range handlers {
+		handler(message)
+	}
+	return nil
+}
+
+// Subscribe implements Broker
+func (b *memoryBroker) Subscribe(channel string, handler func(message []byte)) (unsubscribe func(), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nextID++
+	id := b.nextID
+	if b.subscribers[channel] == nil {
+		b.subscribers[channel] = make(map[int]func(message []byte))
+	}
+	b.subscribers[channel][id] = handler
+	unsubscribe = func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subscribers[channel], id)
+		if len(b.subscribers[channel]) == 0 {
+			delete(b.subscribers, channel)
+		}
+	}
+	return
+}
+
+// newNodeID generates the random id of current instance, the hub ignores the messages that it published itself
+func newNodeID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
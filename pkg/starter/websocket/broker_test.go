@@ -0,0 +1,105 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websocket
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMemoryBroker(t *testing.T) {
+	broker := NewMemoryBroker()
+	var received []string
+	unsubscribe, err := broker.Subscribe("news", func(message []byte) {
+		received = append(received, string(message))
+	})
+	assert.Equal(t, nil, err)
+
+	t.Run("should publish the message to the subscribers", func(t *testing.T) {
+		assert.Equal(t, nil, broker.Publish("news", []byte("hello")))
+		assert.Equal(t, nil, broker.Publish("sports", []byte("goal")))
+		assert.Equal(t, []string{"hello"}, received)
+	})
+
+	t.Run("should not publish the message after unsubscribe", func(t *testing.T) {
+		unsubscribe()
+		assert.Equal(t, nil, broker.Publish("news", []byte("again")))
+		assert.Equal(t, []string{"hello"}, received)
+	})
+
+	t.Run("should get the registered broker", func(t *testing.T) {
+		b, err := getBroker(MemoryBroker)
+		assert.Equal(t, nil, err)
+		assert.NotEqual(t, nil, b)
+		_, err = getBroker("unknown")
+		assert.Equal(t, ErrBrokerNotFound, err)
+	})
+}
+
+// testHubFanOut checks that the broadcasts of one hub reach the clients of the other hub exactly once
+func testHubFanOut(t *testing.T, broker1, broker2 Broker, wait func(client *fakeClient, n int) []string) {
+	hub1 := NewHub()
+	hub2 := NewHub()
+	assert.Equal(t, nil, hub1.UseBroker(broker1, "test"))
+	assert.Equal(t, nil, hub2.UseBroker(broker2, "test"))
+
+	alice := newFakeClient("alice", "/chat", "alice")
+	bob := newFakeClient("bob", "/chat", "bob")
+	carol := newFakeClient("carol", "/news", "carol")
+	hub1.Add(alice)
+	hub2.Add(bob)
+	hub2.Add(carol)
+	hub2.Join(bob, "general")
+
+	t.Run("should broadcast to the clients of all the instances", func(t *testing.T) {
+		hub1.Broadcast([]byte("hello"), "carol")
+		assert.Equal(t, []string{"hello"}, wait(bob, 1))
+		assert.Equal(t, []string{"hello"}, wait(alice, 1))
+	})
+
+	t.Run("should broadcast to the room of the other instance", func(t *testing.T) {
+		hub1.BroadcastTo("general", []byte("room"))
+		assert.Equal(t, []string{"room"}, wait(bob, 1))
+	})
+
+	t.Run("should broadcast to the namespace of the other instance", func(t *testing.T) {
+		hub1.BroadcastNamespace("/news", []byte("news"))
+		assert.Equal(t, []string{"news"}, wait(carol, 1))
+	})
+
+	t.Run("should send to the subject of the other instance", func(t *testing.T) {
+		hub2.SendTo("alice", []byte("hi alice"))
+		assert.Equal(t, []string{"hi alice"}, wait(alice, 1))
+		assert.Equal(t, 0, len(bob.received()))
+		assert.Equal(t, 0, len(carol.received()))
+	})
+
+	t.Run("should not fan out after the hub is closed", func(t *testing.T) {
+		hub2.Close()
+		hub1.Broadcast([]byte("bye"))
+		assert.Equal(t, []string{"bye"}, wait(alice, 1))
+		hub2.Broadcast([]byte("local"))
+		assert.Equal(t, []string{"local"}, wait(bob, 1))
+		assert.Equal(t, 0, len(alice.received()))
+	})
+	hub1.Close()
+}
+
+func TestHubWithMemoryBroker(t *testing.T) {
+	broker := NewMemoryBroker()
+	testHubFanOut(t, broker, broker, func(client *fakeClient, n int) []string {
+		return client.received()
+	})
+}
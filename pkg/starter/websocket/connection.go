@@ -1,7 +1,10 @@
 package websocket
 
 import (
+	"fmt"
+	jwtgo "github.com/dgrijalva/jwt-go"
 	"github.com/kataras/iris/websocket"
+	"hidevops.io/hiboot/pkg/app/web/context"
 	"hidevops.io/hiboot/pkg/at"
 )
 
@@ -9,8 +12,123 @@ import (
 type Connection struct {
 	at.ContextAware
 	websocket.Connection
+
+	namespace string
+	subject   string
+	claims    jwtgo.MapClaims
+	err       error
+	queue     *outboundQueue
+	hub       *Hub
+}
+
+// Emitter sends the messages to the clients of the hub room
+type Emitter interface {
+	// EmitMessage sends the message
+	EmitMessage(message []byte) error
+	// EmitEvent sends the event envelope
+	EmitEvent(event string, data interface{}) error
+}
+
+// roomEmitter broadcasts the messages to the room of the hub
+type roomEmitter struct {
+	hub  *Hub
+	room string
 }
 
 func newConnection(conn websocket.Connection) *Connection {
 	return &Connection{Connection: conn}
 }
+
+// newRejectedConnection create the connection of the upgrade request that is rejected, it is not upgraded
+func newRejectedConnection(err error) *Connection {
+	return &Connection{err: err}
+}
+
+// newContextConnection create the connection with the namespace and the jwt claims of the upgrade request,
+// the claims are the ones authenticated on upgrade, or the ones of the token that the jwt middleware verified
+func newContextConnection(conn websocket.Connection, ctx context.Context, claims jwtgo.MapClaims, subjectClaim string) *Connection {
+	c := newConnection(conn)
+	c.namespace = ctx.Path()
+	if claims == nil {
+		if token, ok := ctx.Values().Get("jwt").(*jwtgo.Token); ok && token.Valid {
+			claims, _ = token.Claims.(jwtgo.MapClaims)
+		}
+	}
+	if claims != nil {
+		c.claims = claims
+		if sub, ok := claims[subjectClaim]; ok {
+			c.subject = fmt.Sprintf("%v", sub)
+		}
+	}
+	return c
+}
+
+// Err returns the error of the upgrade, the connection must not be used if it is not nil
+func (c *Connection) Err() error {
+	if c.err != nil || c.Connection == nil {
+		return c.err
+	}
+	return c.Connection.Err()
+}
+
+// Namespace returns the path that the connection is upgraded on
+func (c *Connection) Namespace() string {
+	return c.namespace
+}
+
+// Subject returns the jwt subject of the connection, it is empty if the connection is not authenticated
+func (c *Connection) Subject() string {
+	return c.subject
+}
+
+// Claims returns the jwt claims of the connection
+func (c *Connection) Claims() jwtgo.MapClaims {
+	return c.claims
+}
+
+// EmitMessage sends the message through the outbound queue if it is enabled
+func (c *Connection) EmitMessage(message []byte) error {
+	if c.queue != nil {
+		return c.queue.EmitMessage(message)
+	}
+	return c.Connection.EmitMessage(message)
+}
+
+// Write sends the message of the websocket message type through the outbound queue if it is enabled
+func (c *Connection) Write(messageType int, data []byte) error {
+	if c.queue != nil {
+		return c.queue.Write(messageType, data)
+	}
+	return c.Connection.Write(messageType, data)
+}
+
+// EmitEvent sends the event envelope through the outbound queue if it is enabled, the event handlers that are
+// registered by RegisterEventHandler reply the envelopes, while Emit sends the native iris message
+func (c *Connection) EmitEvent(event string, data interface{}) error {
+	return Emit(c, event, data)
+}
+
+// ToRoom returns the emitter that broadcasts to the room of the hub that the connections are joined by Hub.Join,
+// the messages are sent through the outbound queue of each connection and reach the other instances through the broker,
+// while To sends to the native iris rooms directly
+func (c *Connection) ToRoom(room string) Emitter {
+	return &roomEmitter{hub: c.hub, room: room}
+}
+
+// EmitMessage broadcasts the message to the room
+func (e *roomEmitter) EmitMessage(message []byte) error {
+	if e.hub == nil {
+		return ErrConnectionClosed
+	}
+	e.hub.BroadcastTo(e.room, message)
+	return nil
+}
+
+// EmitEvent broadcasts the event envelope to the room
+func (e *roomEmitter) EmitEvent(event string, data interface{}) error {
+	message, err := NewEvent(event, data)
+	if err == nil {
+		err = e.EmitMessage(message)
+	}
+	return err
+}
@@ -0,0 +1,113 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websocket
+
+import (
+	gorilla "github.com/gorilla/websocket"
+	"github.com/kataras/iris"
+	iriscontext "github.com/kataras/iris/context"
+	"github.com/kataras/iris/websocket"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConnectionOutbound(t *testing.T) {
+	t.Run("should send the messages through the outbound queue", func(t *testing.T) {
+		w := &slowWriter{release: make(chan struct{})}
+		conn := &Connection{queue: newOutboundQueue(w, 4, OverflowDrop, nil)}
+		defer conn.queue.close()
+		assert.Equal(t, nil, conn.EmitMessage([]byte("a")))
+		assert.Equal(t, nil, conn.EmitEvent("chat", "hi"))
+		assert.Equal(t, nil, conn.Write(2, []byte("b")))
+		close(w.release)
+		eventually(t, func() bool { return len(w.written()) == 3 })
+		assert.Equal(t, []string{"a", `{"event":"chat","data":"hi"}`, "2:b"}, w.written())
+	})
+
+	t.Run("should broadcast to the room of the hub", func(t *testing.T) {
+		hub := NewHub()
+		foo := newFakeClient("foo", "/chat", "")
+		bar := newFakeClient("bar", "/chat", "")
+		hub.Add(foo)
+		hub.Add(bar)
+		hub.Join(foo, "news")
+		conn := &Connection{hub: hub}
+		assert.Equal(t, nil, conn.ToRoom("news").EmitEvent("chat", "hi"))
+		assert.Equal(t, []string{`{"event":"chat","data":"hi"}`}, foo.received())
+		assert.Equal(t, 0, len(bar.received()))
+	})
+
+	t.Run("should not broadcast without the hub", func(t *testing.T) {
+		conn := &Connection{}
+		assert.Equal(t, ErrConnectionClosed, conn.ToRoom("news").EmitMessage([]byte("a")))
+	})
+}
+
+func TestConnectionNativeEmit(t *testing.T) {
+	server := websocket.New(websocket.Config{})
+	connections := make(chan *Connection, 2)
+	application := iris.New()
+	application.Get("/ws", func(ctx iriscontext.Context) {
+		conn := newConnection(server.Upgrade(ctx))
+		if conn.Err() != nil {
+			return
+		}
+		connections <- conn
+		conn.Wait()
+	})
+	assert.Equal(t, nil, application.Build())
+	s := httptest.NewServer(application)
+	defer s.Close()
+
+	dial := func() (*gorilla.Conn, *Connection) {
+		client, _, err := gorilla.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http")+"/ws", nil)
+		assert.Equal(t, nil, err)
+		select {
+		case conn := <-connections:
+			return client, conn
+		case <-time.After(time.Second):
+			t.Fatal("the connection is not upgraded")
+		}
+		return nil, nil
+	}
+	read := func(client *gorilla.Conn) string {
+		client.SetReadDeadline(time.Now().Add(time.Second))
+		_, message, err := client.ReadMessage()
+		assert.Equal(t, nil, err)
+		return string(message)
+	}
+
+	fooClient, foo := dial()
+	defer fooClient.Close()
+	barClient, _ := dial()
+	defer barClient.Close()
+
+	t.Run("should send the native iris message by Emit", func(t *testing.T) {
+		assert.Equal(t, nil, foo.Emit("chat", "hi"))
+		message := read(fooClient)
+		assert.Equal(t, true, strings.HasPrefix(message, "iris-websocket-message:chat;"))
+		assert.Equal(t, true, strings.HasSuffix(message, "hi"))
+	})
+
+	t.Run("should broadcast the native iris message by To", func(t *testing.T) {
+		assert.Equal(t, nil, foo.To(Broadcast).Emit("news", "hello"))
+		message := read(barClient)
+		assert.Equal(t, true, strings.HasPrefix(message, "iris-websocket-message:news;"))
+		assert.Equal(t, true, strings.HasSuffix(message, "hello"))
+	})
+}
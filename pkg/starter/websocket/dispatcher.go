@@ -0,0 +1,230 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"hidevops.io/hiboot/pkg/utils/str"
+	"hidevops.io/hiboot/pkg/utils/validator"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	// eventPrefix is the prefix of the event handler methods, e.g. the event chat is handled by OnChat
+	eventPrefix = "On"
+
+	// ErrorEvent is the event that is replied when the message is not a valid envelope
+	ErrorEvent = "error"
+)
+
+var (
+	// ErrInvalidEnvelope the message is not a json event envelope
+	ErrInvalidEnvelope = errors.New("[websocket] invalid event envelope")
+
+	// ErrEventNotFound the handler does not have the method for the event
+	ErrEventNotFound = errors.New("[websocket] event handler is not found")
+
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+	// reservedMethods are the methods of Handler which are not events
+	reservedMethods = []string{"OnMessage", "OnDisconnect"}
+)
+
+// Envelope is the json message of the event protocol, e.g. {"event": "chat", "data": {"text": "hello"}}
+//
+// The id is optional, if it is sent the reply echoes it so that the client is able to correlate the ack
+type Envelope struct {
+	Event string          `json:"event"`
+	ID    string          `json:"id,omitempty"`
+	Data  json.RawMessage `json:"data,omitempty"`
+	Error string          `json:"error,omitempty"`
+}
+
+// eventMethod is the handler method of an event
+type eventMethod struct {
+	method  reflect.Value
+	argType reflect.Type
+}
+
+// Dispatcher dispatches the events to the On<Event> methods of the handler,
+// the event name is converted to camel case, so that chat-message, chat_message and chatMessage are all handled by OnChatMessage
+//
+// The method takes zero or one argument which the data is decoded to and validated by the validate tags,
+// it may return a reply, an error or both, e.g.
+//
+//	func (h *chatHandler) OnChat(msg *ChatMessage) (reply *ChatReply, err error)
+type Dispatcher struct {
+	methods map[string]*eventMethod
+}
+
+// NewDispatcher creates the dispatcher of the handler
+func NewDispatcher(handler interface{}) *Dispatcher {
+	d := &Dispatcher{methods: make(map[string]*eventMethod)}
+	val := reflect.ValueOf(handler)
+	typ := val.Type()
+	for i := 0; i < typ.NumMethod(); i++ {
+		m := typ.Method(i)
+		if !strings.HasPrefix(m.Name, eventPrefix) || len(m.Name) == len(eventPrefix) || str.InSlice(m.Name, reservedMethods) {
+			continue
+		}
+		method := val.Method(i)
+		mt := method.Type()
+		if mt.NumIn() > 1 || !validReturns(mt) {
+			continue
+		}
+		em := &eventMethod{method: method}
+		if mt.NumIn() == 1 {
+			em.argType = mt.In(0)
+		}
+		d.methods[m.Name] = em
+	}
+	return d
+}
+
+// validReturns the method may return nothing, a reply, an error, or a reply and an error
+func validReturns(mt reflect.Type) bool {
+	switch mt.NumOut() {
+	case 0, 1:
+		return true
+	case 2:
+		return mt.Out(1) == errorType
+	}
+	return false
+}
+
+// Events returns the method names of the events that the dispatcher handles
+func (d *Dispatcher) Events() (events []string) {
+	for name := range d.methods {
+		events = append(events, name)
+	}
+	sort.Strings(events)
+	return
+}
+
+// Dispatch decodes the envelope, calls the event method and emits the reply to the client,
+// a message with an id is always acked even if the method has no reply
+func (d *Dispatcher) Dispatch(client Client, message []byte) (err error) {
+	var req Envelope
+	if err = json.Unmarshal(message, &req); err != nil || req.Event == "" {
+		reply(client, &Envelope{Event: ErrorEvent, Error: ErrInvalidEnvelope.Error()}, nil)
+		return ErrInvalidEnvelope
+	}
+
+	em, ok := d.methods[eventPrefix+str.ToCamel(req.Event)]
+	if !ok {
+		reply(client, &Envelope{Event: req.Event, ID: req.ID, Error: ErrEventNotFound.Error()}, nil)
+		return ErrEventNotFound
+	}
+
+	var inputs []reflect.Value
+	if em.argType != nil {
+		var arg reflect.Value
+		arg, err = decode(em.argType, req.Data)
+		if err != nil {
+			reply(client, &Envelope{Event: req.Event, ID: req.ID, Error: err.Error()}, nil)
+			return
+		}
+		inputs = append(inputs, arg)
+	}
+
+	var data interface{}
+	results := em.method.Call(inputs)
+	for _, result := range results {
+		if result.Type() == errorType {
+			if !result.IsNil() {
+				err = result.Interface().(error)
+			}
+		} else if !isNil(result) {
+			data = result.Interface()
+		}
+	}
+
+	res := &Envelope{Event: req.Event, ID: req.ID}
+	if err != nil {
+		res.Error = err.Error()
+	} else if data == nil && req.ID == "" {
+		return
+	}
+	if e := reply(client, res, data); e != nil && err == nil {
+		err = e
+	}
+	return
+}
+
+// decode decodes the data to the argument type and validates it
+func decode(argType reflect.Type, data json.RawMessage) (arg reflect.Value, err error) {
+	typ := argType
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	ptr := reflect.New(typ)
+	if len(data) != 0 {
+		if err = json.Unmarshal(data, ptr.Interface()); err != nil {
+			return
+		}
+	}
+	if typ.Kind() == reflect.Struct {
+		if err = validator.Validate.Struct(ptr.Interface()); err != nil {
+			return
+		}
+	}
+	if argType.Kind() == reflect.Ptr {
+		arg = ptr
+	} else {
+		arg = ptr.Elem()
+	}
+	return
+}
+
+// isNil reports whether the reply is a nil value
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func:
+		return v.IsNil()
+	}
+	return false
+}
+
+// encode encodes the data into the envelope
+func encode(envelope *Envelope, data interface{}) (message []byte, err error) {
+	if data != nil {
+		if envelope.Data, err = json.Marshal(data); err != nil {
+			return
+		}
+	}
+	return json.Marshal(envelope)
+}
+
+// reply emits the envelope to the client
+func reply(client Client, envelope *Envelope, data interface{}) error {
+	message, err := encode(envelope, data)
+	if err == nil {
+		err = client.EmitMessage(message)
+	}
+	return err
+}
+
+// NewEvent encodes the event envelope, it is used to broadcast the event through the hub
+func NewEvent(event string, data interface{}) ([]byte, error) {
+	return encode(&Envelope{Event: event}, data)
+}
+
+// Emit sends the event to the client
+func Emit(client Client, event string, data interface{}) error {
+	return reply(client, &Envelope{Event: event}, data)
+}
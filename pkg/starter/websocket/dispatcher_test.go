@@ -0,0 +1,148 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websocket
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type chatMessage struct {
+	Room string `json:"room" validate:"required"`
+	Text string `json:"text" validate:"required"`
+}
+
+type chatReply struct {
+	Room  string `json:"room"`
+	Count int    `json:"count"`
+}
+
+type chatHandler struct {
+	messages []chatMessage
+	pinged   bool
+}
+
+func (h *chatHandler) OnChatMessage(msg *chatMessage) (*chatReply, error) {
+	if msg.Room == "closed" {
+		return nil, errors.New("room is closed")
+	}
+	h.messages = append(h.messages, *msg)
+	return &chatReply{Room: msg.Room, Count: len(h.messages)}, nil
+}
+
+func (h *chatHandler) OnPing() {
+	h.pinged = true
+}
+
+func (h *chatHandler) OnEcho(text string) string {
+	return text
+}
+
+func (h *chatHandler) OnDisconnect() {
+}
+
+// OnInvalid is not an event method as it has too many arguments
+func (h *chatHandler) OnInvalid(a, b string) {
+}
+
+func TestDispatcher(t *testing.T) {
+	handler := new(chatHandler)
+	dispatcher := NewDispatcher(handler)
+	client := newFakeClient("c1", "/chat", "")
+
+	t.Run("should find the event methods", func(t *testing.T) {
+		assert.Equal(t, []string{"OnChatMessage", "OnEcho", "OnPing"}, dispatcher.Events())
+	})
+
+	t.Run("should dispatch the typed event and reply", func(t *testing.T) {
+		err := dispatcher.Dispatch(client, []byte(`{"event":"chat-message","id":"1","data":{"room":"general","text":"hi"}}`))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []chatMessage{{Room: "general", Text: "hi"}}, handler.messages)
+		assert.Equal(t, []string{`{"event":"chat-message","id":"1","data":{"room":"general","count":1}}`}, client.received())
+	})
+
+	t.Run("should dispatch the camel case event", func(t *testing.T) {
+		err := dispatcher.Dispatch(client, []byte(`{"event":"chatMessage","data":{"room":"general","text":"again"}}`))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []string{`{"event":"chatMessage","data":{"room":"general","count":2}}`}, client.received())
+	})
+
+	t.Run("should decode the non struct argument", func(t *testing.T) {
+		err := dispatcher.Dispatch(client, []byte(`{"event":"echo","data":"hello"}`))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []string{`{"event":"echo","data":"hello"}`}, client.received())
+	})
+
+	t.Run("should ack the event without reply if it has an id", func(t *testing.T) {
+		err := dispatcher.Dispatch(client, []byte(`{"event":"ping","id":"2"}`))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, true, handler.pinged)
+		assert.Equal(t, []string{`{"event":"ping","id":"2"}`}, client.received())
+	})
+
+	t.Run("should not reply the event without reply and id", func(t *testing.T) {
+		err := dispatcher.Dispatch(client, []byte(`{"event":"ping"}`))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 0, len(client.received()))
+	})
+
+	t.Run("should reply the error of the handler", func(t *testing.T) {
+		err := dispatcher.Dispatch(client, []byte(`{"event":"chat-message","id":"3","data":{"room":"closed","text":"hi"}}`))
+		assert.Equal(t, "room is closed", err.Error())
+		assert.Equal(t, []string{`{"event":"chat-message","id":"3","error":"room is closed"}`}, client.received())
+	})
+
+	t.Run("should reply the validation error", func(t *testing.T) {
+		err := dispatcher.Dispatch(client, []byte(`{"event":"chat-message","id":"4","data":{"room":"general"}}`))
+		assert.NotEqual(t, nil, err)
+		assert.Contains(t, client.received()[0], `"id":"4","error":`)
+		assert.Equal(t, 2, len(handler.messages))
+	})
+
+	t.Run("should reply the decoding error", func(t *testing.T) {
+		err := dispatcher.Dispatch(client, []byte(`{"event":"chat-message","data":"hi"}`))
+		assert.NotEqual(t, nil, err)
+		assert.Contains(t, client.received()[0], `"error":`)
+	})
+
+	t.Run("should reply the unknown event", func(t *testing.T) {
+		err := dispatcher.Dispatch(client, []byte(`{"event":"invalid","id":"5"}`))
+		assert.Equal(t, ErrEventNotFound, err)
+		assert.Equal(t, []string{`{"event":"invalid","id":"5","error":"[websocket] event handler is not found"}`}, client.received())
+	})
+
+	t.Run("should not dispatch the reserved method", func(t *testing.T) {
+		err := dispatcher.Dispatch(client, []byte(`{"event":"disconnect"}`))
+		assert.Equal(t, ErrEventNotFound, err)
+		client.received()
+	})
+
+	t.Run("should reply the invalid envelope", func(t *testing.T) {
+		err := dispatcher.Dispatch(client, []byte(`hello`))
+		assert.Equal(t, ErrInvalidEnvelope, err)
+		assert.Equal(t, []string{`{"event":"error","error":"[websocket] invalid event envelope"}`}, client.received())
+	})
+
+	t.Run("should emit the event", func(t *testing.T) {
+		err := Emit(client, "notice", &chatReply{Room: "general"})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []string{`{"event":"notice","data":{"room":"general","count":0}}`}, client.received())
+
+		message, err := NewEvent("notice", nil)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, `{"event":"notice"}`, string(message))
+	})
+}
@@ -0,0 +1,122 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websocket
+
+import (
+	"crypto/rsa"
+	"errors"
+	jwtgo "github.com/dgrijalva/jwt-go"
+	"hidevops.io/hiboot/pkg/starter/jwt/jwtctx"
+	"hidevops.io/hiboot/pkg/utils/str"
+	"net/http"
+	"strings"
+)
+
+const (
+	// protocolHeader is the header that the browsers send the subprotocols with
+	protocolHeader = "Sec-WebSocket-Protocol"
+
+	bearerPrefix = "bearer "
+)
+
+var (
+	// ErrTooManyConnections the connections reach the max connections
+	ErrTooManyConnections = errors.New("[websocket] too many connections")
+
+	// ErrUnauthorized the upgrade request does not carry a valid token
+	ErrUnauthorized = errors.New("[websocket] unauthorized")
+)
+
+// verifyKeyGetter is implemented by the token of the jwt starter
+type verifyKeyGetter interface {
+	VerifyKey() *rsa.PublicKey
+}
+
+// guard authenticates the upgrade request before the connection is upgraded
+type guard struct {
+	properties *properties
+	verifyKey  *rsa.PublicKey
+}
+
+// newGuard is the constructor of guard, token is the token of the jwt starter that is looked up
+// if the auth is enabled, the requests are rejected if it is nil
+func newGuard(p *properties, token verifyKeyGetter) *guard {
+	g := &guard{properties: p}
+	if token != nil {
+		g.verifyKey = token.VerifyKey()
+	}
+	return g
+}
+
+// check returns the claims of the authenticated request, or the http status and the error if the request is rejected
+func (g *guard) check(r *http.Request) (claims jwtgo.MapClaims, status int, err error) {
+	if !g.properties.Auth.Enabled {
+		return
+	}
+	token := extractToken(r, &g.properties.Auth)
+	if token == "" {
+		return nil, http.StatusUnauthorized, ErrUnauthorized
+	}
+	claims, err = jwtctx.ParseToken(token, g.verifyKey)
+	if err != nil {
+		return nil, http.StatusUnauthorized, ErrUnauthorized
+	}
+	return
+}
+
+// extractToken extracts the token from the authorization header, the query param or the subprotocols in order,
+// browsers are not able to set the header, so that they send the token as the subprotocol next to the auth subprotocol,
+// e.g. new WebSocket(url, ["access_token", token])
+func extractToken(r *http.Request, p *authProperties) string {
+	header := r.Header.Get("Authorization")
+	if len(header) > len(bearerPrefix) && strings.ToLower(header[:len(bearerPrefix)]) == bearerPrefix {
+		return strings.TrimSpace(header[len(bearerPrefix):])
+	}
+	if p.QueryParam != "" {
+		if token := r.URL.Query().Get(p.QueryParam); token != "" {
+			return token
+		}
+	}
+	if p.Subprotocol != "" {
+		protocols := strings.Split(r.Header.Get(protocolHeader), ",")
+		for i, protocol := range protocols {
+			if strings.TrimSpace(protocol) == p.Subprotocol && i+1 < len(protocols) {
+				return strings.TrimSpace(protocols[i+1])
+			}
+		}
+	}
+	return ""
+}
+
+// checkOrigin returns the origin checker of the allowed origins, any origin is allowed if it is empty,
+// the origin may contain a wildcard, e.g. https://*.example.com
+func checkOrigin(allowedOrigins []string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		if len(allowedOrigins) == 0 {
+			return true
+		}
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			// not a browser
+			return true
+		}
+		for _, allowed := range allowedOrigins {
+			if str.MatchOrigin(allowed, origin) {
+				return true
+			}
+		}
+		return false
+	}
+}
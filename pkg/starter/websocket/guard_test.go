@@ -0,0 +1,121 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websocket
+
+import (
+	"github.com/stretchr/testify/assert"
+	"hidevops.io/hiboot/pkg/starter/jwt"
+	"hidevops.io/hiboot/pkg/utils/io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGuard(t *testing.T) {
+	io.EnsureWorkDir(1, "config/ssl/app.rsa")
+	token := jwt.NewJwtToken(&jwt.Properties{
+		PrivateKeyPath: "config/ssl/app.rsa",
+		PublicKeyPath:  "config/ssl/app.rsa.pub",
+	})
+	tokenStr, err := token.Generate(jwt.Map{"sub": "johnd"}, 10, time.Second)
+	assert.Equal(t, nil, err)
+
+	p := &properties{
+		Auth: authProperties{Enabled: true, QueryParam: "token", Subprotocol: "access_token"},
+	}
+	g := newGuard(p, token)
+
+	t.Run("should authenticate the token of the header", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/ws", nil)
+		r.Header.Set("Authorization", "Bearer "+tokenStr)
+		claims, status, err := g.check(r)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 0, status)
+		assert.Equal(t, "johnd", claims["sub"])
+	})
+
+	t.Run("should authenticate the token of the query param", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/ws?token="+tokenStr, nil)
+		claims, _, err := g.check(r)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "johnd", claims["sub"])
+	})
+
+	t.Run("should authenticate the token of the subprotocol", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/ws", nil)
+		r.Header.Set(protocolHeader, "access_token, "+tokenStr)
+		claims, _, err := g.check(r)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "johnd", claims["sub"])
+	})
+
+	t.Run("should reject the request without token", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/ws", nil)
+		_, status, err := g.check(r)
+		assert.Equal(t, ErrUnauthorized, err)
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+
+	t.Run("should reject the invalid token", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/ws?token=invalid", nil)
+		_, status, err := g.check(r)
+		assert.Equal(t, ErrUnauthorized, err)
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+
+	t.Run("should not authenticate if the auth is disabled", func(t *testing.T) {
+		g := newGuard(&properties{}, nil)
+		r := httptest.NewRequest(http.MethodGet, "/ws", nil)
+		claims, _, err := g.check(r)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 0, len(claims))
+	})
+
+	t.Run("should reject the token if the jwt is not configured", func(t *testing.T) {
+		g := newGuard(p, nil)
+		r := httptest.NewRequest(http.MethodGet, "/ws?token="+tokenStr, nil)
+		_, _, err := g.check(r)
+		assert.Equal(t, ErrUnauthorized, err)
+	})
+}
+
+func TestCheckOrigin(t *testing.T) {
+	request := func(origin string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/ws", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		return r
+	}
+
+	t.Run("should allow any origin by default", func(t *testing.T) {
+		assert.Equal(t, true, checkOrigin(nil)(request("https://evil.com")))
+	})
+
+	check := checkOrigin([]string{"https://hidevops.io", "https://*.example.com"})
+
+	t.Run("should allow the origins", func(t *testing.T) {
+		assert.Equal(t, true, check(request("https://hidevops.io")))
+		assert.Equal(t, true, check(request("https://app.example.com")))
+		assert.Equal(t, true, check(request("")))
+	})
+
+	t.Run("should not allow the other origins", func(t *testing.T) {
+		assert.Equal(t, false, check(request("https://evil.com")))
+		assert.Equal(t, false, check(request("https://.example.com")))
+		assert.Equal(t, false, check(request("https://example.com")))
+	})
+}
@@ -1,5 +1,7 @@
 package websocket
 
+import "hidevops.io/hiboot/pkg/log"
+
 // Handler is the interface the websocket handler
 type Handler interface {
 	OnMessage(data []byte)
@@ -9,9 +11,32 @@ type Handler interface {
 // Register is the handler register
 type Register func(handler Handler, conn *Connection)
 
+// EventRegister is the register of the event handler, the json event envelopes are dispatched to the On<Event> methods of the handler
+type EventRegister func(handler interface{}, conn *Connection)
+
 // registerHandler is the handler for websocket
 func registerHandler(handler Handler, conn *Connection) {
+	if conn.Err() != nil {
+		return
+	}
 	conn.OnMessage(handler.OnMessage)
 	conn.OnDisconnect(handler.OnDisconnect)
 	conn.Wait()
 }
+
+// registerEventHandler dispatches the events of the connection to the handler, OnDisconnect is called if the handler implements it
+func registerEventHandler(handler interface{}, conn *Connection) {
+	if conn.Err() != nil {
+		return
+	}
+	dispatcher := NewDispatcher(handler)
+	conn.OnMessage(func(data []byte) {
+		if err := dispatcher.Dispatch(conn, data); err != nil {
+			log.Debugf("[websocket] connection %v: %v", conn.ID(), err)
+		}
+	})
+	if h, ok := handler.(interface{ OnDisconnect() }); ok {
+		conn.OnDisconnect(h.OnDisconnect)
+	}
+	conn.Wait()
+}
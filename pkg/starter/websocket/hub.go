@@ -0,0 +1,362 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websocket
+
+import (
+	"encoding/json"
+	"hidevops.io/hiboot/pkg/log"
+	"sort"
+	"sync"
+)
+
+const (
+	scopeAll       = "all"
+	scopeRoom      = "room"
+	scopeNamespace = "namespace"
+	scopeSubject   = "subject"
+)
+
+// Client is the connected client that the hub manages, *Connection is the client of the websocket connection
+type Client interface {
+	// ID returns the unique id of the client
+	ID() string
+	// Namespace returns the namespace of the client, it is the path that the connection is upgraded on
+	Namespace() string
+	// Subject returns the jwt subject of the client, it is empty if the client is not authenticated
+	Subject() string
+	// EmitMessage sends the message to the client
+	EmitMessage(message []byte) error
+}
+
+// Hub manages the connected clients, it joins the clients to the rooms,
+// broadcasts the messages and looks up the clients by id or jwt subject
+type Hub struct {
+	mu sync.RWMutex
+	// clients id => client
+	clients map[string]Client
+	// rooms room => id => client
+	rooms map[string]map[string]Client
+	// joined id => room => true
+	joined map[string]map[string]bool
+	// subjects subject => id => client
+	subjects map[string]map[string]Client
+	// reserved is the number of the slots that are reserved for the connections being upgraded
+	reserved int
+
+	// node is the id of current instance
+	node        string
+	broker      Broker
+	channel     string
+	unsubscribe func()
+}
+
+// broadcast is the message that is published to the other instances through the broker
+type broadcast struct {
+	Node    string   `json:"node"`
+	Scope   string   `json:"scope"`
+	Target  string   `json:"target,omitempty"`
+	Except  []string `json:"except,omitempty"`
+	Message []byte   `json:"message"`
+}
+
+// NewHub is the constructor of Hub
+func NewHub() *Hub {
+	return &Hub{
+		clients:  make(map[string]Client),
+		rooms:    make(map[string]map[string]Client),
+		joined:   make(map[string]map[string]bool),
+		subjects: make(map[string]map[string]Client),
+		node:     newNodeID(),
+	}
+}
+
+// UseBroker publishes the broadcasts of the hub to the other instances through the broker channel,
+// and delivers the broadcasts of the other instances to the local clients
+func (h *Hub) UseBroker(broker Broker, channel string) (err error) {
+	unsubscribe, err := broker.Subscribe(channel, h.receive)
+	if err != nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.unsubscribe != nil {
+		h.unsubscribe()
+	}
+	h.broker, h.channel, h.unsubscribe = broker, channel, unsubscribe
+	return
+}
+
+// Close stops publishing and receiving the broadcasts through the broker
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.unsubscribe != nil {
+		h.unsubscribe()
+	}
+	h.broker, h.unsubscribe = nil, nil
+}
+
+// Add adds the connected client to the hub
+func (h *Hub) Add(client Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.add(client)
+}
+
+func (h *Hub) add(client Client) {
+	h.clients[client.ID()] = client
+	if subject := client.Subject(); subject != "" {
+		if h.subjects[subject] == nil {
+			h.subjects[subject] = make(map[string]Client)
+		}
+		h.subjects[subject][client.ID()] = client
+	}
+}
+
+// reserve reserves a slot for the connection being upgraded, it returns false if the connected clients
+// and the reserved slots reach max, 0 means unlimited
+func (h *Hub) reserve(max int) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if max > 0 && len(h.clients)+h.reserved >= max {
+		return false
+	}
+	h.reserved++
+	return true
+}
+
+// release releases the reserved slot, the client is added in place of the slot if it is not nil
+func (h *Hub) release(client Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.reserved--
+	if client != nil {
+		h.add(client)
+	}
+}
+
+// Remove removes the disconnected client from the hub and all the rooms it joined
+func (h *Hub) Remove(client Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	id := client.ID()
+	for room := range h.joined[id] {
+		h.leave(id, room)
+	}
+	delete(h.clients, id)
+	if subject := client.Subject(); subject != "" {
+		delete(h.subjects[subject], id)
+		if len(h.subjects[subject]) == 0 {
+			delete(h.subjects, subject)
+		}
+	}
+}
+
+// Join joins the client to the room
+func (h *Hub) Join(client Client, room string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	id := client.ID()
+	if _, ok := h.clients[id]; !ok {
+		return
+	}
+	if h.rooms[room] == nil {
+		h.rooms[room] = make(map[string]Client)
+	}
+	h.rooms[room][id] = client
+	if h.joined[id] == nil {
+		h.joined[id] = make(map[string]bool)
+	}
+	h.joined[id][room] = true
+}
+
+// Leave removes the client from the room
+func (h *Hub) Leave(client Client, room string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.leave(client.ID(), room)
+}
+
+func (h *Hub) leave(id, room string) {
+	delete(h.rooms[room], id)
+	if len(h.rooms[room]) == 0 {
+		delete(h.rooms, room)
+	}
+	delete(h.joined[id], room)
+	if len(h.joined[id]) == 0 {
+		delete(h.joined, id)
+	}
+}
+
+// IsJoined checks if the client has joined the room
+func (h *Hub) IsJoined(client Client, room string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.joined[client.ID()][room]
+}
+
+// Rooms returns the sorted rooms that the client joined
+func (h *Hub) Rooms(client Client) (rooms []string) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for room := range h.joined[client.ID()] {
+		rooms = append(rooms, room)
+	}
+	sort.Strings(rooms)
+	return
+}
+
+// Get returns the client by id
+func (h *Hub) Get(id string) (client Client, ok bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	client, ok = h.clients[id]
+	return
+}
+
+// GetBySubject returns the clients of the jwt subject, a subject may connect more than once
+func (h *Hub) GetBySubject(subject string) []Client {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return sorted(h.subjects[subject])
+}
+
+// Clients returns all the connected clients that are sorted by id
+func (h *Hub) Clients() []Client {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return sorted(h.clients)
+}
+
+// Namespace returns the clients of the namespace
+func (h *Hub) Namespace(namespace string) (clients []Client) {
+	for _, client := range h.Clients() {
+		if client.Namespace() == namespace {
+			clients = append(clients, client)
+		}
+	}
+	return
+}
+
+// Room returns the clients that joined the room
+func (h *Hub) Room(room string) []Client {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return sorted(h.rooms[room])
+}
+
+// Count returns the number of the connected clients
+func (h *Hub) Count() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients)
+}
+
+// Broadcast sends the message to all the connected clients except the clients of the ids
+func (h *Hub) Broadcast(message []byte, except ...string) {
+	h.fanOut(&broadcast{Scope: scopeAll, Message: message, Except: except})
+}
+
+// BroadcastTo sends the message to the clients that joined the room except the clients of the ids
+func (h *Hub) BroadcastTo(room string, message []byte, except ...string) {
+	h.fanOut(&broadcast{Scope: scopeRoom, Target: room, Message: message, Except: except})
+}
+
+// BroadcastNamespace sends the message to the clients of the namespace except the clients of the ids
+func (h *Hub) BroadcastNamespace(namespace string, message []byte, except ...string) {
+	h.fanOut(&broadcast{Scope: scopeNamespace, Target: namespace, Message: message, Except: except})
+}
+
+// SendTo sends the message to the clients of the jwt subject
+func (h *Hub) SendTo(subject string, message []byte) {
+	h.fanOut(&broadcast{Scope: scopeSubject, Target: subject, Message: message})
+}
+
+// fanOut delivers the message to the local clients, and publishes it to the other instances if the broker is used
+func (h *Hub) fanOut(m *broadcast) {
+	h.deliver(m)
+
+	h.mu.RLock()
+	broker, channel := h.broker, h.channel
+	h.mu.RUnlock()
+	if broker == nil {
+		return
+	}
+	m.Node = h.node
+	data, err := json.Marshal(m)
+	if err == nil {
+		err = broker.Publish(channel, data)
+	}
+	if err != nil {
+		log.Warnf("websocket: failed to publish the %v broadcast: %v", m.Scope, err)
+	}
+}
+
+// receive delivers the broadcast of the other instances
+func (h *Hub) receive(data []byte) {
+	m := new(broadcast)
+	if err := json.Unmarshal(data, m); err != nil {
+		log.Warnf("websocket: invalid broadcast: %v", err)
+		return
+	}
+	if m.Node != h.node {
+		h.deliver(m)
+	}
+}
+
+// deliver sends the message to the local clients in the scope
+func (h *Hub) deliver(m *broadcast) {
+	var clients []Client
+	switch m.Scope {
+	case scopeAll:
+		clients = h.Clients()
+	case scopeRoom:
+		clients = h.Room(m.Target)
+	case scopeNamespace:
+		clients = h.Namespace(m.Target)
+	case scopeSubject:
+		clients = h.GetBySubject(m.Target)
+	}
+	emit(clients, m.Message, m.Except)
+}
+
+func emit(clients []Client, message []byte, except []string) {
+	for _, client := range clients {
+		excluded := false
+		for _, id := range except {
+			if client.ID() == id {
+				excluded = true
+				break
+			}
+		}
+		if excluded {
+			continue
+		}
+		if err := client.EmitMessage(message); err != nil {
+			log.Debugf("websocket: failed to send message to %v: %v", client.ID(), err)
+		}
+	}
+}
+
+// sorted returns the clients that are sorted by id
+func sorted(clients map[string]Client) (retVal []Client) {
+	for _, client := range clients {
+		retVal = append(retVal, client)
+	}
+	sort.Slice(retVal, func(i, j int) bool {
+		return retVal[i].ID() < retVal[j].ID()
+	})
+	return
+}
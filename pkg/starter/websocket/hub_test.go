@@ -0,0 +1,178 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websocket
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+// fakeClient records the messages it receives
+type fakeClient struct {
+	id        string
+	namespace string
+	subject   string
+	mu        sync.Mutex
+	messages  []string
+	err       error
+}
+
+func newFakeClient(id, namespace, subject string) *fakeClient {
+	return &fakeClient{id: id, namespace: namespace, subject: subject}
+}
+
+func (c *fakeClient) ID() string        { return c.id }
+func (c *fakeClient) Namespace() string { return c.namespace }
+func (c *fakeClient) Subject() string   { return c.subject }
+
+func (c *fakeClient) EmitMessage(message []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	c.messages = append(c.messages, string(message))
+	return nil
+}
+
+func (c *fakeClient) received() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	messages := c.messages
+	c.messages = nil
+	return messages
+}
+
+func TestHub(t *testing.T) {
+	hub := NewHub()
+	foo := newFakeClient("foo", "/chat", "johnd")
+	bar := newFakeClient("bar", "/chat", "johnd")
+	baz := newFakeClient("baz", "/status", "")
+	hub.Add(foo)
+	hub.Add(bar)
+	hub.Add(baz)
+
+	t.Run("should list the connected clients", func(t *testing.T) {
+		assert.Equal(t, 3, hub.Count())
+		assert.Equal(t, []Client{bar, baz, foo}, hub.Clients())
+		assert.Equal(t, []Client{bar, foo}, hub.Namespace("/chat"))
+	})
+
+	t.Run("should look up the clients by id and subject", func(t *testing.T) {
+		client, ok := hub.Get("foo")
+		assert.Equal(t, true, ok)
+		assert.Equal(t, foo, client)
+		_, ok = hub.Get("unknown")
+		assert.Equal(t, false, ok)
+		assert.Equal(t, []Client{bar, foo}, hub.GetBySubject("johnd"))
+		assert.Equal(t, 0, len(hub.GetBySubject("")))
+	})
+
+	t.Run("should join and leave the rooms", func(t *testing.T) {
+		hub.Join(foo, "news")
+		hub.Join(foo, "sports")
+		hub.Join(baz, "news")
+		hub.Join(newFakeClient("unknown", "", ""), "news")
+		assert.Equal(t, true, hub.IsJoined(foo, "news"))
+		assert.Equal(t, []string{"news", "sports"}, hub.Rooms(foo))
+		assert.Equal(t, []Client{baz, foo}, hub.Room("news"))
+
+		hub.Leave(foo, "sports")
+		assert.Equal(t, false, hub.IsJoined(foo, "sports"))
+		assert.Equal(t, 0, len(hub.Room("sports")))
+	})
+
+	t.Run("should broadcast to all the clients", func(t *testing.T) {
+		hub.Broadcast([]byte("hello"), "bar")
+		assert.Equal(t, []string{"hello"}, foo.received())
+		assert.Equal(t, 0, len(bar.received()))
+		assert.Equal(t, []string{"hello"}, baz.received())
+	})
+
+	t.Run("should broadcast to the room", func(t *testing.T) {
+		hub.BroadcastTo("news", []byte("breaking"))
+		assert.Equal(t, []string{"breaking"}, foo.received())
+		assert.Equal(t, 0, len(bar.received()))
+		assert.Equal(t, []string{"breaking"}, baz.received())
+	})
+
+	t.Run("should broadcast to the namespace", func(t *testing.T) {
+		hub.BroadcastNamespace("/chat", []byte("hi"), "foo")
+		assert.Equal(t, 0, len(foo.received()))
+		assert.Equal(t, []string{"hi"}, bar.received())
+		assert.Equal(t, 0, len(baz.received()))
+	})
+
+	t.Run("should send to the clients of the subject", func(t *testing.T) {
+		hub.SendTo("johnd", []byte("notice"))
+		assert.Equal(t, []string{"notice"}, foo.received())
+		assert.Equal(t, []string{"notice"}, bar.received())
+		assert.Equal(t, 0, len(baz.received()))
+	})
+
+	t.Run("should continue broadcasting if a client fails", func(t *testing.T) {
+		bar.err = errors.New("closed")
+		hub.Broadcast([]byte("hello"))
+		assert.Equal(t, []string{"hello"}, foo.received())
+		assert.Equal(t, []string{"hello"}, baz.received())
+		bar.err = nil
+	})
+
+	t.Run("should remove the client from the hub and its rooms", func(t *testing.T) {
+		hub.Remove(foo)
+		_, ok := hub.Get("foo")
+		assert.Equal(t, false, ok)
+		assert.Equal(t, []Client{baz}, hub.Room("news"))
+		assert.Equal(t, 0, len(hub.Rooms(foo)))
+		assert.Equal(t, []Client{bar}, hub.GetBySubject("johnd"))
+
+		hub.Remove(bar)
+		assert.Equal(t, 0, len(hub.GetBySubject("johnd")))
+		assert.Equal(t, 1, hub.Count())
+	})
+}
+
+func TestHubReserve(t *testing.T) {
+	hub := NewHub()
+
+	t.Run("should reserve the slots up to the max connections", func(t *testing.T) {
+		assert.Equal(t, true, hub.reserve(1))
+		assert.Equal(t, false, hub.reserve(1))
+	})
+
+	t.Run("should keep the slot when the reservation is released with a client", func(t *testing.T) {
+		foo := newFakeClient("foo", "/chat", "johnd")
+		hub.release(foo)
+		assert.Equal(t, 1, hub.Count())
+		assert.Equal(t, false, hub.reserve(1))
+
+		hub.Remove(foo)
+		assert.Equal(t, true, hub.reserve(1))
+	})
+
+	t.Run("should free the slot when the reservation is released without a client", func(t *testing.T) {
+		hub.release(nil)
+		assert.Equal(t, 0, hub.Count())
+		assert.Equal(t, true, hub.reserve(1))
+		hub.release(nil)
+	})
+
+	t.Run("should not limit the connections when the max is not set", func(t *testing.T) {
+		assert.Equal(t, true, hub.reserve(0))
+		assert.Equal(t, true, hub.reserve(0))
+	})
+}
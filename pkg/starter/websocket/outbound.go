@@ -0,0 +1,143 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websocket
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// OverflowDrop drops the message if the outbound queue is full
+	OverflowDrop = "drop"
+	// OverflowClose closes the connection if the outbound queue is full
+	OverflowClose = "close"
+)
+
+var (
+	// ErrQueueFull the message is dropped as the outbound queue of the connection is full
+	ErrQueueFull = errors.New("[websocket] outbound queue is full")
+
+	// ErrConnectionClosed the connection is closed
+	ErrConnectionClosed = errors.New("[websocket] connection is closed")
+)
+
+// messageWriter writes the message to the connection
+type messageWriter interface {
+	EmitMessage(message []byte) error
+	Write(messageType int, data []byte) error
+}
+
+// outboundMessage is the queued message, the message of type 0 is written by EmitMessage
+type outboundMessage struct {
+	messageType int
+	data        []byte
+}
+
+// outboundQueue queues the outbound messages of the connection and writes them one by one,
+// so that the slow connections do not block the broadcasters and are not able to hold unlimited messages
+type outboundQueue struct {
+	writer     messageWriter
+	disconnect func() error
+	overflow   string
+	queue      chan outboundMessage
+	done       chan struct{}
+	once       sync.Once
+	dropped    uint64
+}
+
+func newOutboundQueue(writer messageWriter, size int, overflow string, disconnect func() error) *outboundQueue {
+	q := &outboundQueue{
+		writer:     writer,
+		disconnect: disconnect,
+		overflow:   overflow,
+		queue:      make(chan outboundMessage, size),
+		done:       make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// EmitMessage queues the message, it does not wait for the message to be written
+func (q *outboundQueue) EmitMessage(message []byte) error {
+	return q.push(outboundMessage{data: message})
+}
+
+// Write queues the message of the websocket message type, it does not wait for the message to be written
+func (q *outboundQueue) Write(messageType int, data []byte) error {
+	return q.push(outboundMessage{messageType: messageType, data: data})
+}
+
+func (q *outboundQueue) push(message outboundMessage) error {
+	select {
+	case <-q.done:
+		return ErrConnectionClosed
+	default:
+	}
+	select {
+	case q.queue <- message:
+		return nil
+	default:
+	}
+	atomic.AddUint64(&q.dropped, 1)
+	if q.overflow == OverflowClose {
+		q.evict()
+	}
+	return ErrQueueFull
+}
+
+// Dropped returns the number of the messages that are dropped
+func (q *outboundQueue) Dropped() uint64 {
+	return atomic.LoadUint64(&q.dropped)
+}
+
+func (q *outboundQueue) run() {
+	for {
+		select {
+		case message := <-q.queue:
+			if err := q.write(message); err != nil {
+				// e.g. the write timeout of a slow connection
+				q.evict()
+				return
+			}
+		case <-q.done:
+			return
+		}
+	}
+}
+
+func (q *outboundQueue) write(message outboundMessage) error {
+	if message.messageType == 0 {
+		return q.writer.EmitMessage(message.data)
+	}
+	return q.writer.Write(message.messageType, message.data)
+}
+
+// evict closes the queue and disconnects the connection without blocking the caller
+func (q *outboundQueue) evict() {
+	if q.close() && q.disconnect != nil {
+		go q.disconnect()
+	}
+}
+
+// close stops the queue, the queued messages are discarded, it returns true if the queue is closed by this call
+func (q *outboundQueue) close() (closed bool) {
+	q.once.Do(func() {
+		close(q.done)
+		closed = true
+	})
+	return
+}
@@ -0,0 +1,145 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websocket
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowWriter blocks the writes until it is released
+type slowWriter struct {
+	mu       sync.Mutex
+	release  chan struct{}
+	messages []string
+	err      error
+}
+
+func (w *slowWriter) EmitMessage(message []byte) error {
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	w.messages = append(w.messages, string(message))
+	return nil
+}
+
+func (w *slowWriter) Write(messageType int, data []byte) error {
+	return w.EmitMessage([]byte(fmt.Sprintf("%d:%s", messageType, data)))
+}
+
+func (w *slowWriter) written() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.messages
+}
+
+// disconnector counts the disconnections
+type disconnector struct {
+	disconnected chan struct{}
+}
+
+func (d *disconnector) Disconnect() error {
+	close(d.disconnected)
+	return nil
+}
+
+// eventually waits for the condition to be true
+func eventually(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition is not satisfied in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestOutboundQueue(t *testing.T) {
+	t.Run("should write the queued messages in order", func(t *testing.T) {
+		w := &slowWriter{release: make(chan struct{})}
+		q := newOutboundQueue(w, 4, OverflowDrop, nil)
+		defer q.close()
+		assert.Equal(t, nil, q.EmitMessage([]byte("a")))
+		assert.Equal(t, nil, q.EmitMessage([]byte("b")))
+		close(w.release)
+		eventually(t, func() bool { return len(w.written()) == 2 })
+		assert.Equal(t, []string{"a", "b"}, w.written())
+	})
+
+	t.Run("should write the queued raw messages with their type in order", func(t *testing.T) {
+		w := &slowWriter{release: make(chan struct{})}
+		q := newOutboundQueue(w, 4, OverflowDrop, nil)
+		defer q.close()
+		assert.Equal(t, nil, q.EmitMessage([]byte("a")))
+		assert.Equal(t, nil, q.Write(2, []byte("b")))
+		close(w.release)
+		eventually(t, func() bool { return len(w.written()) == 2 })
+		assert.Equal(t, []string{"a", "2:b"}, w.written())
+	})
+
+	t.Run("should drop the message if the queue is full", func(t *testing.T) {
+		w := &slowWriter{release: make(chan struct{})}
+		q := newOutboundQueue(w, 1, OverflowDrop, nil)
+		defer q.close()
+		// the first message is taken by the writer, the second one is queued
+		assert.Equal(t, nil, q.EmitMessage([]byte("a")))
+		eventually(t, func() bool { return len(q.queue) == 0 })
+		assert.Equal(t, nil, q.EmitMessage([]byte("b")))
+		assert.Equal(t, ErrQueueFull, q.EmitMessage([]byte("c")))
+		assert.Equal(t, uint64(1), q.Dropped())
+		close(w.release)
+		eventually(t, func() bool { return len(w.written()) == 2 })
+		assert.Equal(t, []string{"a", "b"}, w.written())
+	})
+
+	t.Run("should close the connection if the queue is full", func(t *testing.T) {
+		w := &slowWriter{release: make(chan struct{})}
+		d := &disconnector{disconnected: make(chan struct{})}
+		q := newOutboundQueue(w, 1, OverflowClose, d.Disconnect)
+		assert.Equal(t, nil, q.EmitMessage([]byte("a")))
+		eventually(t, func() bool { return len(q.queue) == 0 })
+		assert.Equal(t, nil, q.EmitMessage([]byte("b")))
+		assert.Equal(t, ErrQueueFull, q.EmitMessage([]byte("c")))
+		<-d.disconnected
+		assert.Equal(t, ErrConnectionClosed, q.EmitMessage([]byte("d")))
+		close(w.release)
+	})
+
+	t.Run("should evict the connection if the write fails", func(t *testing.T) {
+		w := &slowWriter{release: make(chan struct{}), err: errors.New("i/o timeout")}
+		d := &disconnector{disconnected: make(chan struct{})}
+		q := newOutboundQueue(w, 1, OverflowDrop, d.Disconnect)
+		close(w.release)
+		assert.Equal(t, nil, q.EmitMessage([]byte("a")))
+		<-d.disconnected
+		assert.Equal(t, ErrConnectionClosed, q.EmitMessage([]byte("b")))
+	})
+
+	t.Run("should not disconnect twice", func(t *testing.T) {
+		d := &disconnector{disconnected: make(chan struct{})}
+		q := newOutboundQueue(&slowWriter{release: make(chan struct{})}, 1, OverflowClose, d.Disconnect)
+		q.evict()
+		q.evict()
+		<-d.disconnected
+		assert.Equal(t, false, q.close())
+	})
+}
@@ -1,7 +1,55 @@
 package websocket
 
+type authProperties struct {
+	// Enabled requires the upgrade request to carry a valid jwt token
+	Enabled bool
+	// QueryParam is the query param that carries the token
+	QueryParam string `default:"token"`
+	// Subprotocol is the subprotocol that the token is sent next to
+	Subprotocol string `default:"access_token"`
+}
+
+type outboundProperties struct {
+	// QueueSize is the max number of the outbound messages that are queued for each connection, 0 disables the queue
+	QueueSize int `default:"256"`
+	// Overflow is the policy if the queue is full, drop drops the message, close closes the connection
+	Overflow string `default:"drop"`
+}
+
+type redisProperties struct {
+	// Address is the address of the redis server
+	Address string `default:"localhost:6379"`
+	// Password is the password of the redis server
+	Password string
+}
+
 type properties struct {
 	ReadBufferSize  int    `default:"1024"`
 	WriteBufferSize int    `default:"1024"`
 	Javascript      string `default:"/websocket/websocket.js"`
+	// SubjectClaim is the jwt claim that identifies the subject of the connection
+	SubjectClaim string `default:"sub"`
+	// AllowedOrigins are the origins that are allowed to connect, any origin is allowed if it is empty
+	AllowedOrigins []string
+	// MaxConnections is the max number of connections, 0 means unlimited
+	MaxConnections int
+	// PingPeriod is the period in seconds that the server pings the connections
+	PingPeriod int `default:"54"`
+	// PongTimeout is the timeout in seconds that the connection is evicted if it does not pong, it must be greater than the ping period
+	PongTimeout int `default:"60"`
+	// WriteTimeout is the timeout in seconds of writing a message, the connection is evicted if the write times out
+	WriteTimeout int `default:"10"`
+	// MaxMessageSize is the max size in bytes of the incoming messages, 0 means unlimited
+	MaxMessageSize int64 `default:"1048576"`
+	// Outbound limits the outbound messages of the slow connections
+	Outbound outboundProperties
+	// Broker is the broker that fans out the broadcasts between the instances, memory, redis or the name of the registered broker,
+	// empty means the broadcasts only reach the clients of current instance
+	Broker string
+	// Channel is the pub/sub channel of the broker
+	Channel string `default:"hiboot.websocket"`
+	// Redis is the redis server of the redis broker
+	Redis redisProperties
+	// Auth authenticates the upgrade requests with the jwt token
+	Auth authProperties
 }
@@ -0,0 +1,322 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websocket
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"hidevops.io/hiboot/pkg/log"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	redisDialTimeout = 5 * time.Second
+
+	// redisPublishQueueSize is the max number of the messages that are waiting to be published
+	redisPublishQueueSize = 1024
+)
+
+var (
+	// redisRetryBackoff is the interval of resubscribing after the subscription is lost
+	redisRetryBackoff = time.Second
+
+	// redisCommandTimeout is the timeout of writing each command and reading its reply
+	redisCommandTimeout = 5 * time.Second
+)
+
+// redisError is the error reply of redis
+type redisError string
+
+func (e redisError) Error() string {
+	return "[websocket] redis: " + string(e)
+}
+
+// redisMessage is the message that is waiting to be published
+type redisMessage struct {
+	channel string
+	message []byte
+}
+
+// redisBroker is the broker that fans out the messages through the redis pub/sub,
+// it speaks the redis protocol directly as only PUBLISH and SUBSCRIBE are used
+type redisBroker struct {
+	address  string
+	password string
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+
+	// queueMu guards the queue of the messages that are published asynchronously
+	queueMu  sync.Mutex
+	queue    []redisMessage
+	draining bool
+}
+
+// NewRedisBroker create the redis broker of the address, e.g. localhost:6379
+func NewRedisBroker(address, password string) Broker {
+	return &redisBroker{address: address, password: password}
+}
+
+// dial connects to redis and authenticates the connection
+func (b *redisBroker) dial() (conn net.Conn, reader *bufio.Reader, err error) {
+	conn, err = net.DialTimeout("tcp", b.address, redisDialTimeout)
+	if err != nil {
+		return
+	}
+	reader = bufio.NewReader(conn)
+	if b.password != "" {
+		if err = command(conn, reader, "AUTH", b.password); err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+	}
+	return
+}
+
+// Publish implements Broker, the message is queued and published by the goroutine of the broker,
+// so that the broadcasters are not blocked by the slow or unreachable redis server
+func (b *redisBroker) Publish(channel string, message []byte) error {
+	b.queueMu.Lock()
+	defer b.queueMu.Unlock()
+	if len(b.queue) >= redisPublishQueueSize {
+		return ErrQueueFull
+	}
+	b.queue = append(b.queue, redisMessage{channel: channel, message: message})
+	if !b.draining {
+		b.draining = true
+		go b.drain()
+	}
+	return nil
+}
+
+// drain publishes the queued messages in order, it returns when the queue is empty
+func (b *redisBroker) drain() {
+	for {
+		b.queueMu.Lock()
+		if len(b.queue) == 0 {
+			b.draining = false
+			b.queueMu.Unlock()
+			return
+		}
+		m := b.queue[0]
+		b.queue[0] = redisMessage{}
+		b.queue = b.queue[1:]
+		b.queueMu.Unlock()
+		if err := b.publish(m.channel, m.message); err != nil {
+			log.Warnf("websocket: failed to publish to the redis channel %v: %v", m.channel, err)
+		}
+	}
+}
+
+// publish publishes the message to redis, the stale connection is redialed once
+func (b *redisBroker) publish(channel string, message []byte) (err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for attempt := 0; attempt < 2; attempt++ {
+		reused := b.conn != nil
+		if !reused {
+			if b.conn, b.reader, err = b.dial(); err != nil {
+				b.conn = nil
+				return
+			}
+		}
+		err = command(b.conn, b.reader, "PUBLISH", channel, string(message))
+		if _, ok := err.(redisError); err == nil || ok {
+			return
+		}
+		b.conn.Close()
+		b.conn = nil
+		if !reused {
+			return
+		}
+	}
+	return
+}
+
+// Subscribe implements Broker, the subscription is resumed if the connection is lost
+func (b *redisBroker) Subscribe(channel string, handler func(message []byte)) (unsubscribe func(), err error) {
+	s := &redisSubscription{broker: b, channel: channel, handler: handler, done: make(chan struct{})}
+	reader, err := s.subscribe()
+	if err != nil {
+		return
+	}
+	go s.run(reader)
+	return s.close, nil
+}
+
+type redisSubscription struct {
+	broker  *redisBroker
+	channel string
+	handler func(message []byte)
+
+	mu   sync.Mutex
+	conn net.Conn
+	done chan struct{}
+	once sync.Once
+}
+
+// subscribe connects to redis and subscribes the channel
+func (s *redisSubscription) subscribe() (reader *bufio.Reader, err error) {
+	conn, reader, err := s.broker.dial()
+	if err != nil {
+		return
+	}
+	if err = command(conn, reader, "SUBSCRIBE", s.channel); err == nil {
+		// the subscribed connection waits for the messages without the deadline
+		err = conn.SetDeadline(time.Time{})
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.done:
+		conn.Close()
+		return nil, ErrConnectionClosed
+	default:
+	}
+	s.conn = conn
+	return
+}
+
+func (s *redisSubscription) run(reader *bufio.Reader) {
+	for {
+		err := s.receive(reader)
+		for {
+			select {
+			case <-s.done:
+				return
+			default:
+			}
+			log.Warnf("websocket: redis subscription of %v is lost: %v", s.channel, err)
+			select {
+			case <-s.done:
+				return
+			case <-time.After(redisRetryBackoff):
+			}
+			if reader, err = s.subscribe(); err == nil {
+				break
+			}
+		}
+	}
+}
+
+// receive calls the handler with the messages until the connection is lost
+func (s *redisSubscription) receive(reader *bufio.Reader) error {
+	for {
+		reply, err := readReply(reader)
+		if err != nil {
+			return err
+		}
+		// message replies are [message, channel, payload]
+		if values, ok := reply.([]interface{}); ok && len(values) == 3 {
+			kind, _ := values[0].([]byte)
+			payload, _ := values[2].([]byte)
+			if string(kind) == "message" {
+				s.handler(payload)
+			}
+		}
+	}
+}
+
+func (s *redisSubscription) close() {
+	s.once.Do(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		close(s.done)
+		if s.conn != nil {
+			s.conn.Close()
+		}
+	})
+}
+
+// command writes the command and reads its reply within the command timeout
+func command(conn net.Conn, reader *bufio.Reader, args ...string) (err error) {
+	if err = conn.SetDeadline(time.Now().Add(redisCommandTimeout)); err != nil {
+		return
+	}
+	if err = writeCommand(conn, args...); err == nil {
+		_, err = readReply(reader)
+	}
+	return
+}
+
+// writeCommand writes the command as the array of bulk strings
+func writeCommand(w io.Writer, args ...string) error {
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, arg...)
+		buf = append(buf, '\r', '\n')
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// readReply reads the reply, the bulk strings are returned as []byte, the arrays as []interface{}
+func readReply(r *bufio.Reader) (reply interface{}, err error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("[websocket] redis: invalid reply %q", line)
+	}
+	kind, line := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+':
+		return line, nil
+	case '-':
+		return nil, redisError(line)
+	case ':':
+		return strconv.ParseInt(line, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		data := make([]byte, n+2)
+		if _, err = io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return data[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		values := make([]interface{}, n)
+		for i := range values {
+			if values[i], err = readReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+	return nil, errors.New("[websocket] redis: unknown reply " + string(kind))
+}
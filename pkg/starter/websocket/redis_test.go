@@ -0,0 +1,226 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websocket
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// redisStandIn is the local stand-in of the redis server, it only serves AUTH, PUBLISH and SUBSCRIBE
+type redisStandIn struct {
+	listener net.Listener
+	password string
+
+	mu          sync.Mutex
+	conns       map[net.Conn]bool
+	subscribers map[string]map[net.Conn]*sync.Mutex
+	subscribed  int
+}
+
+func newRedisStandIn(t *testing.T, password string) *redisStandIn {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Equal(t, nil, err)
+	s := &redisStandIn{
+		listener:    listener,
+		password:    password,
+		conns:       make(map[net.Conn]bool),
+		subscribers: make(map[string]map[net.Conn]*sync.Mutex),
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.conns[conn] = true
+			s.mu.Unlock()
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *redisStandIn) address() string {
+	return s.listener.Addr().String()
+}
+
+// disconnect closes all the client connections
+func (s *redisStandIn) disconnect() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+}
+
+func (s *redisStandIn) close() {
+	s.listener.Close()
+	s.disconnect()
+}
+
+// subscriptions returns the number of the SUBSCRIBE commands that are served
+func (s *redisStandIn) subscriptions() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.subscribed
+}
+
+func (s *redisStandIn) serve(conn net.Conn) {
+	writeMu := new(sync.Mutex)
+	write := func(reply string) {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		conn.Write([]byte(reply))
+	}
+	defer func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.conns, conn)
+		for _, subscribers := range s.subscribers {
+			delete(subscribers, conn)
+		}
+		conn.Close()
+	}()
+	authenticated := s.password == ""
+	reader := bufio.NewReader(conn)
+	for {
+		reply, err := readReply(reader)
+		if err != nil {
+			return
+		}
+		var args []string
+		for _, arg := range reply.([]interface{}) {
+			args = append(args, string(arg.([]byte)))
+		}
+		switch {
+		case args[0] == "AUTH":
+			if args[1] != s.password {
+				write("-WRONGPASS invalid password\r\n")
+				continue
+			}
+			authenticated = true
+			write("+OK\r\n")
+		case !authenticated:
+			write("-NOAUTH Authentication required.\r\n")
+		case args[0] == "SUBSCRIBE":
+			s.mu.Lock()
+			if s.subscribers[args[1]] == nil {
+				s.subscribers[args[1]] = make(map[net.Conn]*sync.Mutex)
+			}
+			s.subscribers[args[1]][conn] = writeMu
+			s.subscribed++
+			s.mu.Unlock()
+			write(fmt.Sprintf("*3\r\n$9\r\nsubscribe\r\n$%d\r\n%s\r\n:1\r\n", len(args[1]), args[1]))
+		case args[0] == "PUBLISH":
+			s.mu.Lock()
+			var n int
+			for subscriber, mu := range s.subscribers[args[1]] {
+				mu.Lock()
+				writeCommand(subscriber, "message", args[1], args[2])
+				mu.Unlock()
+				n++
+			}
+			s.mu.Unlock()
+			write(fmt.Sprintf(":%d\r\n", n))
+		}
+	}
+}
+
+// waitMessages waits for the client to receive n messages
+func waitMessages(client *fakeClient, n int) (messages []string) {
+	deadline := time.Now().Add(2 * time.Second)
+	for len(messages) < n && time.Now().Before(deadline) {
+		messages = append(messages, client.received()...)
+		time.Sleep(time.Millisecond)
+	}
+	return
+}
+
+func TestRedisBroker(t *testing.T) {
+	server := newRedisStandIn(t, "secret")
+	defer server.close()
+	redisRetryBackoff = 10 * time.Millisecond
+
+	t.Run("should fan out the hub broadcasts through redis", func(t *testing.T) {
+		testHubFanOut(t, NewRedisBroker(server.address(), "secret"), NewRedisBroker(server.address(), "secret"), waitMessages)
+	})
+
+	t.Run("should publish and subscribe the messages", func(t *testing.T) {
+		broker := NewRedisBroker(server.address(), "secret")
+		received := make(chan string, 10)
+		unsubscribe, err := broker.Subscribe("news", func(message []byte) {
+			received <- string(message)
+		})
+		assert.Equal(t, nil, err)
+		defer unsubscribe()
+
+		assert.Equal(t, nil, broker.Publish("news", []byte("hello\r\nworld")))
+		assert.Equal(t, "hello\r\nworld", <-received)
+
+		t.Run("should resubscribe and republish after the connections are lost", func(t *testing.T) {
+			subscriptions := server.subscriptions()
+			server.disconnect()
+			eventually(t, func() bool { return server.subscriptions() > subscriptions })
+			assert.Equal(t, nil, broker.Publish("news", []byte("again")))
+			assert.Equal(t, "again", <-received)
+		})
+	})
+
+	t.Run("should not subscribe with the wrong password", func(t *testing.T) {
+		_, err := NewRedisBroker(server.address(), "wrong").Subscribe("news", func(message []byte) {})
+		assert.Equal(t, redisError("WRONGPASS invalid password"), err)
+	})
+
+	t.Run("should not publish without the password", func(t *testing.T) {
+		err := NewRedisBroker(server.address(), "").(*redisBroker).publish("news", []byte("hello"))
+		assert.Equal(t, redisError("NOAUTH Authentication required."), err)
+	})
+
+	t.Run("should time out the command if redis does not reply", func(t *testing.T) {
+		listener, _ := net.Listen("tcp", "127.0.0.1:0")
+		defer listener.Close()
+		go func() {
+			// accepts the connection but never replies
+			conn, err := listener.Accept()
+			if err == nil {
+				defer conn.Close()
+				<-time.After(time.Second)
+			}
+		}()
+		timeout := redisCommandTimeout
+		redisCommandTimeout = 50 * time.Millisecond
+		defer func() { redisCommandTimeout = timeout }()
+
+		broker := NewRedisBroker(listener.Addr().String(), "").(*redisBroker)
+		err := broker.publish("news", []byte("hello"))
+		netErr, ok := err.(net.Error)
+		assert.Equal(t, true, ok && netErr.Timeout())
+	})
+
+	t.Run("should not subscribe if redis is not available", func(t *testing.T) {
+		listener, _ := net.Listen("tcp", "127.0.0.1:0")
+		address := listener.Addr().String()
+		listener.Close()
+		_, err := NewRedisBroker(address, "").Subscribe("news", func(message []byte) {})
+		assert.NotEqual(t, nil, err)
+	})
+}
@@ -45,9 +45,34 @@ type App struct {
 	Banner banner
 }
 
+// TLS is the properties of https
+type TLS struct {
+	// CertFile is the certificate file, https is enabled if both the cert file and the key file are set
+	CertFile string `json:"cert_file"`
+	// KeyFile is the private key file of the certificate
+	KeyFile string `json:"key_file"`
+	// RedirectPort is the port of the http listener that redirects the requests to https, e.g. 80
+	RedirectPort string `json:"redirect_port"`
+}
+
 // Server is the properties of http server
 type Server struct {
+	// Host is the host the server binds to, e.g. 127.0.0.1, it binds to all interfaces if it is empty
+	Host string `json:"host"`
+	// Port is the port the server listens on
 	Port string `json:"port" default:"8080"`
+	// Unix is the path of the unix domain socket the server listens on as well as the port
+	Unix string `json:"unix"`
+	// H2C enables http/2 over cleartext on the listeners without tls, it is meant for the internal traffic
+	H2C bool `json:"h2c"`
+	// ReadTimeout is the timeout in seconds of reading the entire request, 0 means no timeout
+	ReadTimeout int `json:"read_timeout"`
+	// WriteTimeout is the timeout in seconds of writing the response, 0 means no timeout
+	WriteTimeout int `json:"write_timeout"`
+	// IdleTimeout is the timeout in seconds of waiting for the next request of the keep-alive connections
+	IdleTimeout int `json:"idle_timeout"`
+	// TLS is the properties of https
+	TLS TLS `json:"tls"`
 }
 
 // Logging is the properties of logging
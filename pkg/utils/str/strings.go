@@ -52,6 +52,32 @@ func InSlice(a string, list []string) bool {
 	return retVal
 }
 
+// MatchOrigin check if the origin matches the pattern case-insensitively, the pattern may contain one wildcard
+// that matches one or more characters, e.g. https://*.example.com or http://localhost:*, "*" matches any origin
+func MatchOrigin(pattern, origin string) bool {
+	if pattern == "*" {
+		return true
+	}
+	pattern, origin = strings.ToLower(pattern), strings.ToLower(origin)
+	n := strings.Index(pattern, "*")
+	if n < 0 {
+		return pattern == origin
+	}
+	prefix, suffix := pattern[:n], pattern[n+1:]
+	return len(origin) > len(prefix)+len(suffix) &&
+		strings.HasPrefix(origin, prefix) &&
+		strings.HasSuffix(origin, suffix)
+}
+
+// MatchPath check if the path matches the pattern, the pattern that ends with * matches the paths of its prefix,
+// e.g. /api/* matches /api/foo and /api/foo/bar, otherwise the path must be equal to the pattern
+func MatchPath(pattern, path string) bool {
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(path, pattern[:len(pattern)-1])
+	}
+	return pattern == path
+}
+
 // Convert convert string to specific data type
 func Convert(src string, kind reflect.Kind) (retVal interface{}) {
 	switch kind {
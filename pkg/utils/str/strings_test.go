@@ -50,6 +50,28 @@ func TestStringInSlice(t *testing.T) {
 	assert.Equal(t, true, InSlice("bar", s))
 }
 
+func TestMatchOrigin(t *testing.T) {
+	assert.Equal(t, true, MatchOrigin("https://foo.com", "https://foo.com"))
+	assert.Equal(t, true, MatchOrigin("https://foo.com", "HTTPS://FOO.COM"))
+	assert.Equal(t, false, MatchOrigin("https://foo.com", "https://bar.com"))
+	assert.Equal(t, true, MatchOrigin("https://*.foo.com", "https://api.foo.com"))
+	assert.Equal(t, false, MatchOrigin("https://*.foo.com", "https://foo.com"))
+	assert.Equal(t, false, MatchOrigin("https://*.foo.com", "https://.foo.com"))
+	assert.Equal(t, false, MatchOrigin("https://*.foo.com", "http://api.foo.com"))
+	assert.Equal(t, true, MatchOrigin("http://localhost:*", "http://localhost:8080"))
+	assert.Equal(t, true, MatchOrigin("*", "https://foo.com"))
+}
+
+func TestMatchPath(t *testing.T) {
+	assert.Equal(t, true, MatchPath("/foo", "/foo"))
+	assert.Equal(t, false, MatchPath("/foo", "/foo/bar"))
+	assert.Equal(t, true, MatchPath("/foo/*", "/foo/bar"))
+	assert.Equal(t, true, MatchPath("/foo/*", "/foo/bar/baz"))
+	assert.Equal(t, false, MatchPath("/foo/*", "/foo"))
+	assert.Equal(t, true, MatchPath("/foo*", "/foobar"))
+	assert.Equal(t, true, MatchPath("*", "/foo"))
+}
+
 func TestConvert(t *testing.T) {
 	testData := []struct {
 		src  string